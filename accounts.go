@@ -0,0 +1,296 @@
+package main
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/accountpool"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+)
+
+// newAccountScraper 为Cookie存储中的每个账号创建独立的爬虫，由账号池分配每次接口调用。
+// 没有有效会话的账号会被跳过
+func (c *Crawler) newAccountScraper() (crawler.Scraper, error) {
+	accounts := c.config.Accounts
+	if c.sessions == nil {
+		return nil, errors.New("使用多个账号需要启用Cookie存储，请设置环境变量 CRAWLER_COOKIE_KEY")
+	}
+	switch c.config.AccountStrategy {
+	case "", accountpool.RoundRobin, accountpool.LeastUsed:
+	default:
+		return nil, fmt.Errorf("未知的账号分配策略: %s", c.config.AccountStrategy)
+	}
+
+	// 同时提供的Cookie先保存为 -account 账号的会话
+	if c.config.Cookies != "" {
+		if _, err := c.accountCookies(c.config.Account, c.config.Cookies); err != nil {
+			return nil, err
+		}
+	}
+	if len(accounts) == 1 && accounts[0] == "all" {
+		accounts = c.sessions.Accounts(c.config.Platform)
+	}
+
+	c.accounts = accountpool.New(accountpool.Config{
+		Strategy:          c.config.AccountStrategy,
+		RequestsPerMinute: c.config.AccountRPM,
+	})
+	s := &accountScraper{
+		pool:     c.accounts,
+		scrapers: make(map[string]crawler.Scraper),
+		refresh:  c.refreshAccount,
+	}
+	if c.solver != nil {
+		s.solve = c.solveChallenge
+	}
+	for _, account := range accounts {
+		if _, ok := s.scrapers[account]; ok {
+			continue
+		}
+		scraper, err := c.newScraper(account, "")
+		if err != nil {
+			log.Printf("跳过账号 %s: %v", account, err)
+			continue
+		}
+		s.scrapers[account] = scraper
+		s.accounts = append(s.accounts, c.accounts.Add(account))
+	}
+	if len(s.accounts) == 0 {
+		return nil, fmt.Errorf("平台 %s 没有可用的账号，请先使用 -cookies 或 -login 保存账号会话", c.config.Platform)
+	}
+
+	log.Printf("使用 %d 个账号采集 %s", len(s.accounts), c.config.Platform)
+	return s, nil
+}
+
+// refreshAccount 刷新账号的会话，返回是否刷新成功
+func (c *Crawler) refreshAccount(account string) bool {
+	log.Printf("%s 账号 %s 的登录状态已失效，正在刷新会话", c.config.Platform, account)
+	if _, err := c.sessions.Refresh(c.config.Platform, account); err != nil {
+		log.Printf("%v", err)
+		return false
+	}
+	return true
+}
+
+// logAccountStats 输出各账号的请求统计
+func (c *Crawler) logAccountStats() {
+	if c.accounts == nil {
+		return
+	}
+	for _, stats := range c.accounts.Stats() {
+		status := "正常"
+		switch {
+		case stats.Retired:
+			status = "已停用: " + stats.Reason
+		case stats.Paused:
+			status = "已暂停: " + stats.Reason
+		case !stats.CooldownUntil.IsZero():
+			status = fmt.Sprintf("冷却至 %s: %s", stats.CooldownUntil.Format("15:04:05"), stats.Reason)
+		}
+		log.Printf("账号 %s: 请求 %d 次，失败 %d 次，%s", stats.Name, stats.Requests, stats.Failures, status)
+	}
+}
+
+// accountScraper 在多个账号的爬虫之间分配接口调用的 crawler.Scraper。
+// 可选接口在底层爬虫不支持时返回 crawler.ErrNotSupported，调用前应通过平台注册信息检查
+type accountScraper struct {
+	pool     *accountpool.Pool
+	accounts []*accountpool.Account
+	scrapers map[string]crawler.Scraper
+	refresh  func(account string) bool
+	solve    func(account string, err error) bool // 处理风控验证，未启用验证处理时为空
+}
+
+// call 使用账号池分配的账号调用爬虫。
+// 登录失效时刷新该账号的会话，刷新失败时停用账号；触发风控验证时暂停分配该账号并处理验证，
+// 验证失败的账号进入冷却，未启用验证处理时停用账号。然后换用其他账号重试
+func (s *accountScraper) call(fn func(scraper crawler.Scraper) error) error {
+	for attempt := 0; ; attempt++ {
+		account, err := s.pool.Acquire()
+		if err != nil {
+			return err
+		}
+
+		// 平台不支持和数据不存在与账号状态无关，不计为失败
+		err = fn(s.scrapers[account.Name])
+		if errors.Is(err, crawler.ErrNotSupported) || errors.Is(err, crawler.ErrNotFound) {
+			s.pool.Release(account, nil)
+		} else {
+			s.pool.Release(account, err)
+		}
+
+		switch {
+		case errors.Is(err, crawler.ErrAuthExpired):
+			if !s.refresh(account.Name) {
+				s.pool.Retire(account, err.Error())
+			}
+		case errors.Is(err, crawler.ErrChallenge):
+			if s.solve == nil {
+				s.pool.Retire(account, err.Error())
+				break
+			}
+			s.pool.Pause(account, "正在处理风控验证")
+			if s.solve(account.Name, err) {
+				s.pool.Resume(account)
+			} else {
+				s.pool.Cooldown(account, 0, err.Error())
+			}
+		default:
+			return err
+		}
+
+		if attempt >= len(s.accounts) {
+			return err
+		}
+	}
+}
+
+// Initialize 初始化全部账号的爬虫，初始化失败的账号会被停用
+func (s *accountScraper) Initialize() error {
+	var lastErr error
+	for _, account := range s.accounts {
+		if err := s.scrapers[account.Name].Initialize(); err != nil {
+			s.pool.Retire(account, fmt.Sprintf("初始化失败: %v", err))
+			lastErr = err
+		}
+	}
+	if s.pool.Size() == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+// Close 释放各账号爬虫占用的资源
+func (s *accountScraper) Close() error {
+	var lastErr error
+	for _, scraper := range s.scrapers {
+		if closer, ok := scraper.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				lastErr = err
+			}
+		}
+	}
+	return lastErr
+}
+
+// GetUserInfo 实现 crawler.Scraper
+func (s *accountScraper) GetUserInfo(userID string) (user *crawler.UserData, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		user, err = scraper.GetUserInfo(userID)
+		return err
+	})
+	return user, err
+}
+
+// GetFollowers 实现 crawler.Scraper
+func (s *accountScraper) GetFollowers(userID string, cursor string) (users []*crawler.UserData, nextCursor string, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		users, nextCursor, err = scraper.GetFollowers(userID, cursor)
+		return err
+	})
+	return users, nextCursor, err
+}
+
+// GetFollowing 实现 crawler.Scraper
+func (s *accountScraper) GetFollowing(userID string, cursor string) (users []*crawler.UserData, nextCursor string, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		users, nextCursor, err = scraper.GetFollowing(userID, cursor)
+		return err
+	})
+	return users, nextCursor, err
+}
+
+// GetUserVideos 实现 crawler.Scraper
+func (s *accountScraper) GetUserVideos(userID string, cursor string) (videos []*crawler.VideoData, nextCursor string, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		videos, nextCursor, err = scraper.GetUserVideos(userID, cursor)
+		return err
+	})
+	return videos, nextCursor, err
+}
+
+// GetVideoComments 实现 crawler.Scraper
+func (s *accountScraper) GetVideoComments(videoID string, cursor string) (comments []*crawler.CommentData, nextCursor string, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		comments, nextCursor, err = scraper.GetVideoComments(videoID, cursor)
+		return err
+	})
+	return comments, nextCursor, err
+}
+
+// GetProductInfo 实现 crawler.Scraper
+func (s *accountScraper) GetProductInfo(productID string) (product *crawler.ProductInfo, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		product, err = scraper.GetProductInfo(productID)
+		return err
+	})
+	return product, err
+}
+
+// GetProductReviews 实现 crawler.Scraper
+func (s *accountScraper) GetProductReviews(productID string, cursor string) (reviews []*crawler.ProductReview, nextCursor string, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		reviews, nextCursor, err = scraper.GetProductReviews(productID, cursor)
+		return err
+	})
+	return reviews, nextCursor, err
+}
+
+// GetShopInfo 实现 crawler.Scraper
+func (s *accountScraper) GetShopInfo(shopID string) (shop *crawler.ShopData, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		shop, err = scraper.GetShopInfo(shopID)
+		return err
+	})
+	return shop, err
+}
+
+// GetShopProducts 实现 crawler.Scraper
+func (s *accountScraper) GetShopProducts(shopID string, cursor string) (products []*crawler.ProductInfo, nextCursor string, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		products, nextCursor, err = scraper.GetShopProducts(shopID, cursor)
+		return err
+	})
+	return products, nextCursor, err
+}
+
+// GetCommentReplies 实现 crawler.ReplyScraper
+func (s *accountScraper) GetCommentReplies(videoID string, commentID string, cursor string) (replies []*crawler.CommentData, nextCursor string, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		replyScraper, ok := scraper.(crawler.ReplyScraper)
+		if !ok {
+			return fmt.Errorf("%w: 评论回复", crawler.ErrNotSupported)
+		}
+		replies, nextCursor, err = replyScraper.GetCommentReplies(videoID, commentID, cursor)
+		return err
+	})
+	return replies, nextCursor, err
+}
+
+// GetLiveRoom 实现 crawler.LiveScraper
+func (s *accountScraper) GetLiveRoom(userID string) (room *crawler.LiveRoom, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		liveScraper, ok := scraper.(crawler.LiveScraper)
+		if !ok {
+			return fmt.Errorf("%w: 直播", crawler.ErrNotSupported)
+		}
+		room, err = liveScraper.GetLiveRoom(userID)
+		return err
+	})
+	return room, err
+}
+
+// GetLiveProducts 实现 crawler.LiveScraper
+func (s *accountScraper) GetLiveProducts(roomID string) (products []*crawler.ProductInfo, err error) {
+	err = s.call(func(scraper crawler.Scraper) error {
+		liveScraper, ok := scraper.(crawler.LiveScraper)
+		if !ok {
+			return fmt.Errorf("%w: 直播", crawler.ErrNotSupported)
+		}
+		products, err = liveScraper.GetLiveProducts(roomID)
+		return err
+	})
+	return products, err
+}
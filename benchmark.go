@@ -0,0 +1,286 @@
+package main
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/pricenorm"
+	"Crawler/utils/resolve"
+	"Crawler/utils/storage"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// benchmarkOptions 比价命令参数
+type benchmarkOptions struct {
+	exportDir string
+	top       int
+	pages     int
+	detail    bool
+}
+
+// runBenchmark 比价命令：读取短视频平台的带货商品，按归一化名称在电商平台搜索同类商品，
+// 将搜索到的商品标记为 search 来源后写入文件和数据库，并导出各平台的价格对比
+func runBenchmark(args []string) {
+	flags := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	inputDir := flags.String("input", "output", "带货商品采集结果目录")
+	platform := flags.String("platform", "douyin", "带货商品所属平台，仅用于未标记平台的旧数据")
+	keywords := flags.String("keywords", "", "额外比价的商品名称，以逗号分隔")
+	platforms := flags.String("platforms", strings.Join(productPlatformNames(), ","), "比价的电商平台，以逗号分隔")
+	cookieFile := flags.String("cookies", "", "各电商平台的Cookie文件（JSON），格式为 {\"平台\": \"Cookie字符串\"}")
+	exportDir := flags.String("output", "benchmark", "导出目录")
+	configFile := flags.String("config", "", "配置文件，启用 db_config 时将比价商品同时写入数据库")
+	top := flags.Int("top", 10, "每个商品名称在每个平台保留的搜索结果数")
+	pages := flags.Int("pages", 1, "每个商品名称最多搜索的页数")
+	detail := flags.Bool("detail", false, "获取搜索结果的商品详情，补全规格价格和发货地")
+	flags.Parse(args)
+
+	// 读取各平台的Cookie
+	cookies := make(map[string]string)
+	if *cookieFile != "" {
+		data, err := os.ReadFile(*cookieFile)
+		if err != nil {
+			log.Fatalf("读取Cookie文件失败: %v", err)
+		}
+		if err := json.Unmarshal(data, &cookies); err != nil {
+			log.Fatalf("解析Cookie文件失败: %v", err)
+		}
+	}
+
+	store, err := openStore(*configFile)
+	if err != nil {
+		log.Fatalf("存储初始化失败: %v", err)
+	}
+	defer store.Close()
+
+	if err := os.MkdirAll(*exportDir, 0755); err != nil {
+		log.Fatalf("创建导出目录失败: %v", err)
+	}
+
+	normalizer := pricenorm.NewNormalizer(pricenorm.Config{Enabled: true})
+
+	// 读取带货商品，按归一化名称分组并回写比价名称
+	groups := make(map[string][]*crawler.ProductInfo)
+	seen := make(map[string]bool)
+	addShelf := func(product *crawler.ProductInfo) {
+		product.Platform = recordPlatform(product.Platform, *platform)
+		product.MatchName = resolve.NormalizeProductName(product.Name)
+		if product.MatchName == "" || seen[product.Platform+"/"+product.ProductID] {
+			return
+		}
+		seen[product.Platform+"/"+product.ProductID] = true
+		product.Source = crawler.SourceShelf
+		if product.UnitPrice == nil {
+			normalizer.NormalizeProduct(product)
+		}
+		groups[product.MatchName] = append(groups[product.MatchName], product)
+
+		if err := store.SaveProduct(product, product.Platform); err != nil {
+			log.Printf("%v", err)
+		}
+	}
+
+	err = forEachFile(*inputDir, "product_*.json", func(path string, data []byte) error {
+		var product crawler.ProductInfo
+		if err := json.Unmarshal(data, &product); err != nil {
+			return err
+		}
+		addShelf(&product)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("读取带货商品失败: %v", err)
+	}
+
+	// 视频挂载的商品可能没有单独采集详情
+	err = forEachFile(*inputDir, "video_*.json", func(path string, data []byte) error {
+		var video crawler.VideoData
+		if err := json.Unmarshal(data, &video); err != nil {
+			return err
+		}
+		if video.ProductInfo != nil && video.ProductInfo.ProductID != "" {
+			video.ProductInfo.Platform = recordPlatform(video.ProductInfo.Platform, video.Platform)
+			addShelf(video.ProductInfo)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("读取视频商品失败: %v", err)
+	}
+
+	// 额外指定的商品名称
+	for _, keyword := range strings.Split(*keywords, ",") {
+		if name := resolve.NormalizeProductName(keyword); name != "" {
+			if _, ok := groups[name]; !ok {
+				groups[name] = nil
+			}
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		log.Fatal("没有可比价的商品，请检查 -input 目录或通过 -keywords 指定商品名称")
+	}
+
+	options := benchmarkOptions{
+		exportDir: *exportDir,
+		top:       *top,
+		pages:     *pages,
+		detail:    *detail,
+	}
+
+	// 逐个平台搜索
+	results := make(map[string][]*crawler.ProductInfo)
+	for _, name := range strings.Split(*platforms, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		scraper, err := crawler.NewProductScraper(name, defaultUserAgent, cookies[name])
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+		if err := scraper.Initialize(); err != nil {
+			log.Printf("平台 %s 初始化失败，已跳过: %v", name, err)
+			continue
+		}
+
+		for _, matchName := range names {
+			products := searchBenchmark(scraper, name, matchName, normalizer, store, options)
+			results[matchName] = append(results[matchName], products...)
+		}
+	}
+
+	// 导出价格对比
+	rows := [][]string{{"match_name", "source", "platform", "count", "min_price", "median_price", "max_price", "median_price_per_jin"}}
+	for _, name := range names {
+		rows = append(rows, priceRows(name, groups[name])...)
+		rows = append(rows, priceRows(name, results[name])...)
+	}
+	if err := writeCSV(filepath.Join(*exportDir, "price_benchmark.csv"), rows); err != nil {
+		log.Fatalf("导出价格对比失败: %v", err)
+	}
+
+	log.Printf("已为 %d 个商品名称完成比价，结果已导出到 %s", len(names), *exportDir)
+}
+
+// searchBenchmark 在电商平台搜索指定名称的商品，保存并返回前 top 个结果
+func searchBenchmark(scraper crawler.ProductScraper, platform, matchName string, normalizer *pricenorm.Normalizer, store *storage.Manager, options benchmarkOptions) []*crawler.ProductInfo {
+	var products []*crawler.ProductInfo
+	cursor := ""
+
+	for page := 0; page < options.pages && len(products) < options.top; page++ {
+		items, nextCursor, err := scraper.SearchProducts(matchName, cursor)
+		if err != nil {
+			log.Printf("在 %s 搜索商品 %s 失败: %v", platform, matchName, err)
+			break
+		}
+
+		for _, product := range items {
+			if len(products) >= options.top {
+				break
+			}
+
+			// 详情获取失败时保留搜索结果中的简要信息
+			if options.detail {
+				if detail, err := scraper.GetProductInfo(product.ProductID); err == nil {
+					product = detail
+				} else {
+					log.Printf("获取 %s 商品 %s 详情失败: %v", platform, product.ProductID, err)
+				}
+				time.Sleep(time.Second * 2)
+			}
+
+			product.Platform = platform
+			product.Source = crawler.SourceSearch
+			product.MatchName = matchName
+			normalizer.NormalizeProduct(product)
+			products = append(products, product)
+
+			filePath := filepath.Join(options.exportDir, fmt.Sprintf("product_%s_%s.json", platform, product.ProductID))
+			if err := writeJSON(filePath, product); err != nil {
+				log.Printf("保存比价商品到文件失败: %v", err)
+			}
+			if err := store.SaveProduct(product, platform); err != nil {
+				log.Printf("%v", err)
+			}
+		}
+
+		if nextCursor == "" || nextCursor == cursor {
+			break
+		}
+		cursor = nextCursor
+
+		// 休眠一段时间，避免请求过于频繁
+		time.Sleep(time.Second * 3)
+	}
+
+	log.Printf("已在 %s 搜索到 %d 个 %s 商品", platform, len(products), matchName)
+	return products
+}
+
+// priceRows 按来源和平台汇总商品价格，生成价格对比行
+func priceRows(matchName string, products []*crawler.ProductInfo) [][]string {
+	type key struct{ source, platform string }
+	prices := make(map[key][]float64)
+	perJin := make(map[key][]float64)
+	var keys []key
+	for _, product := range products {
+		if product.Price <= 0 {
+			continue
+		}
+
+		k := key{product.Source, product.Platform}
+		if _, ok := prices[k]; !ok {
+			keys = append(keys, k)
+		}
+		prices[k] = append(prices[k], product.Price)
+		if product.UnitPrice != nil && product.UnitPrice.PerJin > 0 {
+			perJin[k] = append(perJin[k], product.UnitPrice.PerJin)
+		}
+	}
+
+	var rows [][]string
+	for _, k := range keys {
+		values := prices[k]
+		sort.Float64s(values)
+		rows = append(rows, []string{
+			matchName,
+			k.source,
+			k.platform,
+			strconv.Itoa(len(values)),
+			strconv.FormatFloat(values[0], 'f', 2, 64),
+			strconv.FormatFloat(median(values), 'f', 2, 64),
+			strconv.FormatFloat(values[len(values)-1], 'f', 2, 64),
+			strconv.FormatFloat(median(perJin[k]), 'f', 2, 64),
+		})
+	}
+	return rows
+}
+
+// median 计算中位数，空列表返回 0
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
@@ -0,0 +1,667 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	biliAPIHost  = "https://api.bilibili.com"
+	biliMallHost = "https://mall.bilibili.com"
+)
+
+func init() {
+	Register(PlatformInfo{
+		Name:         Bilibili,
+		DisplayName:  "哔哩哔哩",
+		LoginURL:     "https://www.bilibili.com",
+		LoginCookies: []string{"SESSDATA"},
+		Headers:      map[string]string{"Referer": "https://www.bilibili.com/", "Origin": "https://www.bilibili.com"},
+		Capabilities: []Capability{CapabilityReplies},
+		New: func(userAgent, cookies string) Scraper {
+			return NewBilibiliScraper(userAgent, cookies)
+		},
+		BrowserRoutes: []BrowserRoute{
+			{Path: "/x/space/wbi/acc/info", Page: "https://space.bilibili.com/{mid}"},
+			{Path: "/x/relation/stat", Page: "https://space.bilibili.com/{vmid}"},
+			{Path: "/x/space/wbi/arc/search", Page: "https://space.bilibili.com/{mid}/video", Cursor: "pn", FirstPage: "1"},
+			{Path: "/x/v2/reply/main", Capture: "/x/v2/reply/wbi/main", Page: "https://www.bilibili.com/video/av{oid}", Cursor: "next"},
+			{Path: "/mall-c/items/info", Page: "https://mall.bilibili.com/neul-next/detailuniversal/detail.html?itemsId={itemsId}"},
+		},
+	})
+}
+
+// BilibiliScraper B站平台爬虫实现，稿件对应 VideoData（以 BV 号为ID，不含弹幕数据），
+// 稿件评论对应 CommentData，会员购商品对应 ProductInfo
+type BilibiliScraper struct {
+	client    *http.Client
+	userAgent string
+	cookies   string
+	wbi       wbiSigner
+
+	// BV 号对应的 aid，获取评论时需要使用 aid
+	aids  map[string]int64
+	mutex sync.Mutex
+}
+
+// NewBilibiliScraper 创建B站爬虫实例
+func NewBilibiliScraper(userAgent, cookies string) *BilibiliScraper {
+	return &BilibiliScraper{
+		client: &http.Client{
+			Timeout: time.Second * 30,
+		},
+		userAgent: userAgent,
+		cookies:   cookies,
+		aids:      make(map[string]int64),
+	}
+}
+
+// httpClient 返回爬虫使用的 HTTP 客户端，浏览器后端通过它接管请求
+func (s *BilibiliScraper) httpClient() *http.Client {
+	return s.client
+}
+
+// Initialize 初始化爬虫，获取 WBI 签名密钥
+func (s *BilibiliScraper) Initialize() error {
+	return s.refreshWbiKeys()
+}
+
+// refreshWbiKeys 从 nav 接口获取 WBI 签名密钥，未登录时接口返回 -101 但仍包含密钥
+func (s *BilibiliScraper) refreshWbiKeys() error {
+	req, err := http.NewRequest("GET", biliAPIHost+"/x/web-interface/nav", nil)
+	if err != nil {
+		return err
+	}
+
+	setHeaders(req, Bilibili, s.userAgent, s.cookies)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("invalid cookies or blocked by anti-crawler")
+	}
+
+	var result struct {
+		Data struct {
+			WbiImg struct {
+				ImgURL string `json:"img_url"`
+				SubURL string `json:"sub_url"`
+			} `json:"wbi_img"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Data.WbiImg.ImgURL == "" || result.Data.WbiImg.SubURL == "" {
+		return errors.New("获取B站 WBI 签名密钥失败")
+	}
+
+	s.wbi.setKeys(result.Data.WbiImg.ImgURL, result.Data.WbiImg.SubURL)
+	return nil
+}
+
+// request 发送 GET 请求，校验通用响应结构并将 data 解析到 out，signed 为 true 时附加 WBI 签名
+func (s *BilibiliScraper) request(rawURL string, params url.Values, signed bool, out interface{}) error {
+	query := params.Encode()
+	if signed {
+		if s.wbi.expired() {
+			if err := s.refreshWbiKeys(); err != nil {
+				return err
+			}
+		}
+		query = s.wbi.sign(params, time.Now())
+	}
+	if query != "" {
+		rawURL += "?" + query
+	}
+
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	// 设置请求头
+	setHeaders(req, Bilibili, s.userAgent, s.cookies)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应内容
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// 412 表示请求被风控拦截
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("%w: B站接口返回状态码 %d", ErrChallenge, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("B站接口返回状态码 %d", resp.StatusCode)
+	}
+
+	// 解析JSON响应
+	var result struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return err
+	}
+	// -101 表示未登录，-352 表示 WBI 签名失效或触发风控
+	if result.Code == -101 {
+		return fmt.Errorf("%w: B站接口返回 %d %s", ErrAuthExpired, result.Code, result.Message)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("B站接口返回错误: %d %s", result.Code, result.Message)
+	}
+
+	return json.Unmarshal(result.Data, out)
+}
+
+// rememberAid 记录稿件的 aid
+func (s *BilibiliScraper) rememberAid(bvid string, aid int64) {
+	if bvid == "" || aid == 0 {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.aids[bvid] = aid
+}
+
+// aid 返回稿件的 aid，未缓存时通过稿件详情获取
+func (s *BilibiliScraper) aid(bvid string) (int64, error) {
+	s.mutex.Lock()
+	aid, ok := s.aids[bvid]
+	s.mutex.Unlock()
+	if ok {
+		return aid, nil
+	}
+
+	view, err := s.getView(bvid)
+	if err != nil {
+		return 0, err
+	}
+	return view.Aid, nil
+}
+
+// biliView 稿件详情
+type biliView struct {
+	Bvid  string `json:"bvid"`
+	Aid   int64  `json:"aid"`
+	Title string `json:"title"`
+	Desc  string `json:"desc"`
+	Tname string `json:"tname"`
+	Owner struct {
+		Mid int64 `json:"mid"`
+	} `json:"owner"`
+	Stat struct {
+		View  int `json:"view"`
+		Like  int `json:"like"`
+		Reply int `json:"reply"`
+		Share int `json:"share"`
+	} `json:"stat"`
+}
+
+// toVideoData 将稿件详情转换为通用的视频数据，弹幕数不纳入统计
+func (v *biliView) toVideoData() *VideoData {
+	video := &VideoData{
+		VideoID:     v.Bvid,
+		UserID:      strconv.FormatInt(v.Owner.Mid, 10),
+		Title:       v.Title,
+		Description: v.Desc,
+		Likes:       v.Stat.Like,
+		Comments:    v.Stat.Reply,
+		Shares:      v.Stat.Share,
+	}
+	if v.Tname != "" {
+		video.Tags = append(video.Tags, v.Tname)
+	}
+	return video
+}
+
+// getView 获取稿件详情
+func (s *BilibiliScraper) getView(bvid string) (*biliView, error) {
+	var result biliView
+	params := url.Values{"bvid": {bvid}}
+	if err := s.request(biliAPIHost+"/x/web-interface/view", params, false, &result); err != nil {
+		return nil, err
+	}
+
+	s.rememberAid(result.Bvid, result.Aid)
+	return &result, nil
+}
+
+// biliReply 稿件评论
+type biliReply struct {
+	Rpid    int64 `json:"rpid"`
+	Mid     int64 `json:"mid"`
+	Root    int64 `json:"root"`
+	Parent  int64 `json:"parent"`
+	Like    int   `json:"like"`
+	Rcount  int   `json:"rcount"`
+	Ctime   int64 `json:"ctime"` // 秒
+	Content struct {
+		Message string `json:"message"`
+	} `json:"content"`
+}
+
+// toCommentData 将稿件评论转换为通用的评论数据。
+// 回复楼中楼时 parent 为被回复的评论，其作者需要在同一页中查找
+func (r *biliReply) toCommentData(bvid string, authors map[int64]int64) *CommentData {
+	comment := &CommentData{
+		CommentID: strconv.FormatInt(r.Rpid, 10),
+		VideoID:   bvid,
+		UserID:    strconv.FormatInt(r.Mid, 10),
+		Content:   r.Content.Message,
+		Likes:     r.Like,
+		Replies:   r.Rcount,
+		Timestamp: r.Ctime,
+	}
+	if r.Root != 0 {
+		comment.ParentID = strconv.FormatInt(r.Root, 10)
+	}
+	if r.Parent != 0 && r.Parent != r.Root {
+		if mid, ok := authors[r.Parent]; ok {
+			comment.ReplyToUserID = strconv.FormatInt(mid, 10)
+		}
+	}
+	return comment
+}
+
+// GetUserInfo 获取用户信息
+func (s *BilibiliScraper) GetUserInfo(userID string) (*UserData, error) {
+	var info struct {
+		Name     string `json:"name"`
+		Sign     string `json:"sign"`
+		Official struct {
+			Title string `json:"title"`
+		} `json:"official"`
+		Tags []string `json:"tags"`
+	}
+	params := url.Values{"mid": {userID}}
+	if err := s.request(biliAPIHost+"/x/space/wbi/acc/info", params, true, &info); err != nil {
+		return nil, err
+	}
+
+	var stat struct {
+		Following int `json:"following"`
+		Follower  int `json:"follower"`
+	}
+	if err := s.request(biliAPIHost+"/x/relation/stat", url.Values{"vmid": {userID}}, false, &stat); err != nil {
+		return nil, err
+	}
+
+	user := &UserData{
+		UserID:      userID,
+		Nickname:    info.Name,
+		Followers:   stat.Follower,
+		Following:   stat.Following,
+		Description: info.Sign,
+		Tags:        info.Tags,
+	}
+	// 认证信息作为标签保留，便于识别机构和专业创作者
+	if info.Official.Title != "" {
+		user.Tags = append(user.Tags, info.Official.Title)
+	}
+
+	return user, nil
+}
+
+// GetFollowers 获取用户的粉丝列表，非本人仅能查看前 5 页
+func (s *BilibiliScraper) GetFollowers(userID string, cursor string) ([]*UserData, string, error) {
+	return s.getRelations("/x/relation/followers", userID, cursor)
+}
+
+// GetFollowing 获取用户的关注列表，非本人仅能查看前 5 页
+func (s *BilibiliScraper) GetFollowing(userID string, cursor string) ([]*UserData, string, error) {
+	return s.getRelations("/x/relation/followings", userID, cursor)
+}
+
+// getRelations 获取粉丝或关注列表，游标为页码
+func (s *BilibiliScraper) getRelations(path, userID, cursor string) ([]*UserData, string, error) {
+	page := 1
+	if cursor != "" {
+		page, _ = strconv.Atoi(cursor)
+	}
+
+	var result struct {
+		List []struct {
+			Mid   int64  `json:"mid"`
+			Uname string `json:"uname"`
+			Sign  string `json:"sign"`
+		} `json:"list"`
+		Total int `json:"total"`
+	}
+	params := url.Values{"vmid": {userID}, "pn": {strconv.Itoa(page)}, "ps": {"50"}}
+	if err := s.request(biliAPIHost+path, params, false, &result); err != nil {
+		return nil, "", err
+	}
+
+	users := make([]*UserData, 0, len(result.List))
+	for _, item := range result.List {
+		users = append(users, &UserData{
+			UserID:      strconv.FormatInt(item.Mid, 10),
+			Nickname:    item.Uname,
+			Description: item.Sign,
+		})
+	}
+
+	if len(result.List) == 0 || page*50 >= result.Total {
+		return users, "", nil
+	}
+	return users, strconv.Itoa(page + 1), nil
+}
+
+// GetUserVideos 获取用户投稿列表，游标为页码，并逐条获取稿件详情补全互动数据
+func (s *BilibiliScraper) GetUserVideos(userID string, cursor string) ([]*VideoData, string, error) {
+	page := 1
+	if cursor != "" {
+		page, _ = strconv.Atoi(cursor)
+	}
+
+	var result struct {
+		List struct {
+			Vlist []struct {
+				Bvid        string `json:"bvid"`
+				Aid         int64  `json:"aid"`
+				Title       string `json:"title"`
+				Description string `json:"description"`
+				Comment     int    `json:"comment"`
+			} `json:"vlist"`
+		} `json:"list"`
+		Page struct {
+			Pn    int `json:"pn"`
+			Ps    int `json:"ps"`
+			Count int `json:"count"`
+		} `json:"page"`
+	}
+	params := url.Values{
+		"mid":   {userID},
+		"pn":    {strconv.Itoa(page)},
+		"ps":    {"30"},
+		"order": {"pubdate"},
+	}
+	if err := s.request(biliAPIHost+"/x/space/wbi/arc/search", params, true, &result); err != nil {
+		return nil, "", err
+	}
+
+	videos := make([]*VideoData, 0, len(result.List.Vlist))
+	for _, item := range result.List.Vlist {
+		s.rememberAid(item.Bvid, item.Aid)
+
+		// 详情获取失败时保留列表中的简要信息
+		if view, err := s.getView(item.Bvid); err == nil {
+			videos = append(videos, view.toVideoData())
+			continue
+		}
+		videos = append(videos, &VideoData{
+			VideoID:     item.Bvid,
+			UserID:      userID,
+			Title:       item.Title,
+			Description: item.Description,
+			Comments:    item.Comment,
+		})
+	}
+
+	if len(result.List.Vlist) == 0 || result.Page.Pn*result.Page.Ps >= result.Page.Count {
+		return videos, "", nil
+	}
+	return videos, strconv.Itoa(page + 1), nil
+}
+
+// GetVideoComments 获取稿件评论，游标为接口返回的 next
+func (s *BilibiliScraper) GetVideoComments(videoID string, cursor string) ([]*CommentData, string, error) {
+	aid, err := s.aid(videoID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var result struct {
+		Replies []*biliReply `json:"replies"`
+		Cursor  struct {
+			IsEnd bool `json:"is_end"`
+			Next  int  `json:"next"`
+		} `json:"cursor"`
+	}
+	params := url.Values{
+		"oid":  {strconv.FormatInt(aid, 10)},
+		"type": {"1"},
+		"mode": {"3"},
+		"next": {cursor},
+	}
+	if err := s.request(biliAPIHost+"/x/v2/reply/main", params, false, &result); err != nil {
+		return nil, "", err
+	}
+
+	comments := make([]*CommentData, 0, len(result.Replies))
+	for _, reply := range result.Replies {
+		comments = append(comments, reply.toCommentData(videoID, nil))
+	}
+
+	if result.Cursor.IsEnd || len(result.Replies) == 0 {
+		return comments, "", nil
+	}
+	return comments, strconv.Itoa(result.Cursor.Next), nil
+}
+
+// GetCommentReplies 获取评论的回复列表，游标为页码
+func (s *BilibiliScraper) GetCommentReplies(videoID string, commentID string, cursor string) ([]*CommentData, string, error) {
+	aid, err := s.aid(videoID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	page := 1
+	if cursor != "" {
+		page, _ = strconv.Atoi(cursor)
+	}
+
+	var result struct {
+		Replies []*biliReply `json:"replies"`
+		Page    struct {
+			Num   int `json:"num"`
+			Size  int `json:"size"`
+			Count int `json:"count"`
+		} `json:"page"`
+	}
+	params := url.Values{
+		"oid":  {strconv.FormatInt(aid, 10)},
+		"type": {"1"},
+		"root": {commentID},
+		"pn":   {strconv.Itoa(page)},
+		"ps":   {"20"},
+	}
+	if err := s.request(biliAPIHost+"/x/v2/reply/reply", params, false, &result); err != nil {
+		return nil, "", err
+	}
+
+	authors := make(map[int64]int64, len(result.Replies))
+	for _, reply := range result.Replies {
+		authors[reply.Rpid] = reply.Mid
+	}
+
+	replies := make([]*CommentData, 0, len(result.Replies))
+	for _, reply := range result.Replies {
+		replies = append(replies, reply.toCommentData(videoID, authors))
+	}
+
+	if len(result.Replies) == 0 || result.Page.Num*result.Page.Size >= result.Page.Count {
+		return replies, "", nil
+	}
+	return replies, strconv.Itoa(page + 1), nil
+}
+
+// biliMallItem 会员购商品，价格单位为分
+type biliMallItem struct {
+	ItemsID       int64  `json:"itemsId"`
+	Name          string `json:"name"`
+	Brief         string `json:"brief"`
+	Price         int    `json:"price"`
+	MarketPrice   int    `json:"marketPrice"`
+	SaleCount     int    `json:"saleCount"`
+	ShopID        int64  `json:"shopId"`
+	DeliveryPlace string `json:"deliveryPlace"`
+	Status        int    `json:"status"` // 1 为在售
+	CategoryName  string `json:"categoryName"`
+	SkuList       []struct {
+		SkuID       int64  `json:"skuId"`
+		SpecValues  string `json:"specValues"`
+		Price       int    `json:"price"`
+		MarketPrice int    `json:"marketPrice"`
+		Stock       int    `json:"stock"`
+	} `json:"skuList"`
+}
+
+// toProductInfo 将会员购商品转换为通用的商品信息
+func (i *biliMallItem) toProductInfo() *ProductInfo {
+	product := &ProductInfo{
+		ProductID:     strconv.FormatInt(i.ItemsID, 10),
+		Name:          i.Name,
+		Price:         float64(i.Price) / 100,
+		OriginalPrice: float64(i.MarketPrice) / 100,
+		Category:      i.CategoryName,
+		Description:   i.Brief,
+		Sales:         i.SaleCount,
+		ShipFrom:      i.DeliveryPlace,
+		OffShelf:      i.Status != 1,
+	}
+	if i.ShopID != 0 {
+		product.ShopID = strconv.FormatInt(i.ShopID, 10)
+	}
+	for _, sku := range i.SkuList {
+		product.SKUs = append(product.SKUs, &ProductSKU{
+			SkuID:         strconv.FormatInt(sku.SkuID, 10),
+			Name:          sku.SpecValues,
+			Price:         float64(sku.Price) / 100,
+			OriginalPrice: float64(sku.MarketPrice) / 100,
+			Stock:         sku.Stock,
+		})
+	}
+	product.FillPriceRange()
+	return product
+}
+
+// GetProductInfo 获取会员购商品信息
+func (s *BilibiliScraper) GetProductInfo(productID string) (*ProductInfo, error) {
+	var result biliMallItem
+	params := url.Values{"itemsId": {productID}}
+	if err := s.request(biliMallHost+"/mall-c/items/info", params, false, &result); err != nil {
+		return nil, err
+	}
+
+	return result.toProductInfo(), nil
+}
+
+// GetProductReviews 获取会员购商品评价，游标为页码
+func (s *BilibiliScraper) GetProductReviews(productID string, cursor string) ([]*ProductReview, string, error) {
+	page := 1
+	if cursor != "" {
+		page, _ = strconv.Atoi(cursor)
+	}
+
+	var result struct {
+		List []struct {
+			CommentID int64  `json:"commentId"`
+			UID       int64  `json:"uid"`
+			Content   string `json:"content"`
+			Score     int    `json:"score"`
+			SkuSpec   string `json:"skuSpec"`
+			Ctime     int64  `json:"ctime"` // 毫秒
+		} `json:"list"`
+		HasNextPage bool `json:"hasNextPage"`
+	}
+	params := url.Values{"itemsId": {productID}, "pageNum": {strconv.Itoa(page)}, "pageSize": {"20"}}
+	if err := s.request(biliMallHost+"/mall-c/comment/list", params, false, &result); err != nil {
+		return nil, "", err
+	}
+
+	reviews := make([]*ProductReview, 0, len(result.List))
+	for _, item := range result.List {
+		reviews = append(reviews, &ProductReview{
+			ReviewID:  strconv.FormatInt(item.CommentID, 10),
+			ProductID: productID,
+			UserID:    strconv.FormatInt(item.UID, 10),
+			Content:   item.Content,
+			Rating:    item.Score,
+			SkuName:   item.SkuSpec,
+			Timestamp: item.Ctime / 1000,
+		})
+	}
+
+	if !result.HasNextPage {
+		return reviews, "", nil
+	}
+	return reviews, strconv.Itoa(page + 1), nil
+}
+
+// GetShopInfo 获取会员购店铺信息
+func (s *BilibiliScraper) GetShopInfo(shopID string) (*ShopData, error) {
+	var result struct {
+		ShopName  string  `json:"shopName"`
+		Score     float64 `json:"score"`
+		FansCount int     `json:"fansCount"`
+		Category  string  `json:"category"`
+		Location  string  `json:"location"`
+	}
+	params := url.Values{"shopId": {shopID}}
+	if err := s.request(biliMallHost+"/mall-c/shop/info", params, false, &result); err != nil {
+		return nil, err
+	}
+
+	return &ShopData{
+		ShopID:    shopID,
+		Name:      result.ShopName,
+		Rating:    result.Score,
+		Followers: result.FansCount,
+		Category:  result.Category,
+		Location:  result.Location,
+	}, nil
+}
+
+// GetShopProducts 获取会员购店铺的全部商品，游标为页码
+func (s *BilibiliScraper) GetShopProducts(shopID string, cursor string) ([]*ProductInfo, string, error) {
+	page := 1
+	if cursor != "" {
+		page, _ = strconv.Atoi(cursor)
+	}
+
+	var result struct {
+		List        []*biliMallItem `json:"list"`
+		HasNextPage bool            `json:"hasNextPage"`
+	}
+	params := url.Values{"shopId": {shopID}, "pageNum": {strconv.Itoa(page)}, "pageSize": {"20"}}
+	if err := s.request(biliMallHost+"/mall-c/shop/items", params, false, &result); err != nil {
+		return nil, "", err
+	}
+
+	products := make([]*ProductInfo, 0, len(result.List))
+	for _, item := range result.List {
+		product := item.toProductInfo()
+		if product.ShopID == "" {
+			product.ShopID = shopID
+		}
+		products = append(products, product)
+	}
+
+	if !result.HasNextPage {
+		return products, "", nil
+	}
+	return products, strconv.Itoa(page + 1), nil
+}
@@ -0,0 +1,202 @@
+package crawler
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 公开文档中的 WBI 示例密钥和对应的混淆密钥
+const (
+	testWbiImgKey   = "7cd084941338484aae1ad9425b84077c"
+	testWbiSubKey   = "4932caff0ff746eab6f01bf08b70ac45"
+	testWbiMixinKey = "ea1db124af3c7062474693fa704f4ff8"
+)
+
+// newTestBilibiliScraper 创建请求转发到测试服务器的B站爬虫，nav 接口返回示例密钥
+func newTestBilibiliScraper(t *testing.T, routes map[string]string) (*BilibiliScraper, *fixtureServer) {
+	routes["/x/web-interface/nav"] = "bili_nav.json"
+	server := newFixtureServer(t, routes)
+	s := NewBilibiliScraper("test-agent", "SESSDATA=test")
+	s.client = server.client()
+	if err := s.Initialize(); err != nil {
+		t.Fatalf("初始化失败: %v", err)
+	}
+	return s, server
+}
+
+func TestWbiMixinKey(t *testing.T) {
+	if got := wbiMixinKey(testWbiImgKey + testWbiSubKey); got != testWbiMixinKey {
+		t.Errorf("wbiMixinKey = %q，期望 %q", got, testWbiMixinKey)
+	}
+}
+
+func TestWbiSign(t *testing.T) {
+	var signer wbiSigner
+	signer.setKeys(
+		"https://i0.hdslb.com/bfs/wbi/"+testWbiImgKey+".png",
+		"https://i0.hdslb.com/bfs/wbi/"+testWbiSubKey+".png",
+	)
+
+	// 公开文档中的签名示例
+	params := url.Values{"foo": {"114"}, "bar": {"514"}, "zab": {"1919810"}}
+	got := signer.sign(params, time.Unix(1702204169, 0))
+	want := "bar=514&foo=114&wts=1702204169&zab=1919810&w_rid=8f6f2b5b3d485fe1886cec6a0be8c5d4"
+	if got != want {
+		t.Errorf("sign = %q，期望 %q", got, want)
+	}
+
+	// 参数值中的 !'()* 被去除，空格编码为 %20
+	got = signer.sign(url.Values{"keyword": {"赣南 脐橙(2024)!"}}, time.Unix(1702204169, 0))
+	if !strings.HasPrefix(got, "keyword=%E8%B5%A3%E5%8D%97%20%E8%84%90%E6%A9%992024&wts=1702204169&w_rid=") {
+		t.Errorf("sign = %q，期望去除特殊字符并将空格编码为 %%20", got)
+	}
+}
+
+// checkWbiSigned 检查请求携带了与混淆密钥匹配的 WBI 签名
+func checkWbiSigned(t *testing.T, server *fixtureServer, path string) {
+	t.Helper()
+
+	req := server.request(path)
+	if req == nil {
+		t.Fatalf("未请求 %s", path)
+	}
+	query, rid, ok := strings.Cut(req.URL.RawQuery, "&w_rid=")
+	if !ok || !strings.Contains(query, "wts=") {
+		t.Fatalf("%s 请求缺少 WBI 签名: %s", path, req.URL.RawQuery)
+	}
+	sum := md5.Sum([]byte(query + testWbiMixinKey))
+	if rid != hex.EncodeToString(sum[:]) {
+		t.Errorf("%s 的 w_rid = %s，期望 %x", path, rid, sum)
+	}
+}
+
+func TestBilibiliGetUserInfo(t *testing.T) {
+	s, server := newTestBilibiliScraper(t, map[string]string{
+		"/x/space/wbi/acc/info": "bili_acc_info.json",
+		"/x/relation/stat":      "bili_relation_stat.json",
+	})
+
+	user, err := s.GetUserInfo("2233")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UserID != "2233" || user.Nickname != "赣南脐橙老李" || user.Description != "果园直播，产地直发" {
+		t.Errorf("用户基本信息 %+v", user)
+	}
+	if user.Followers != 120300 || user.Following != 56 {
+		t.Errorf("粉丝数 %d 关注数 %d，期望 120300 和 56", user.Followers, user.Following)
+	}
+	if strings.Join(user.Tags, ",") != "三农,水果,赣州市农业合作社" {
+		t.Errorf("标签 %v，期望包含认证信息", user.Tags)
+	}
+	checkWbiSigned(t, server, "/x/space/wbi/acc/info")
+}
+
+func TestBilibiliGetUserVideos(t *testing.T) {
+	s, server := newTestBilibiliScraper(t, map[string]string{
+		"/x/space/wbi/arc/search": "bili_arc_search.json",
+		"/x/web-interface/view":   "bili_view.json",
+		"/x/v2/reply/main":        "bili_reply_main.json",
+	})
+
+	videos, cursor, err := s.GetUserVideos("2233", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor != "2" {
+		t.Errorf("游标 %q，期望下一页 2", cursor)
+	}
+	if len(videos) != 1 {
+		t.Fatalf("获取到 %d 个稿件，期望 1 个", len(videos))
+	}
+
+	video := videos[0]
+	if video.VideoID != "BV1xx411c7mD" || video.UserID != "2233" {
+		t.Errorf("稿件ID %q 用户ID %q", video.VideoID, video.UserID)
+	}
+	if video.Title != "赣南脐橙采摘季，带你看看果园" || video.Description != "赣州信丰脐橙，产地直发" {
+		t.Errorf("稿件标题 %q 描述 %q，期望使用详情中的内容", video.Title, video.Description)
+	}
+	if video.Likes != 5600 || video.Comments != 431 || video.Shares != 210 {
+		t.Errorf("点赞 %d 评论 %d 分享 %d", video.Likes, video.Comments, video.Shares)
+	}
+	if len(video.Tags) != 1 || video.Tags[0] != "三农" {
+		t.Errorf("标签 %v，期望为分区名称", video.Tags)
+	}
+	checkWbiSigned(t, server, "/x/space/wbi/arc/search")
+
+	// 投稿列表中的 aid 已缓存，获取评论时不再请求详情
+	if _, _, err := s.GetVideoComments("BV1xx411c7mD", ""); err != nil {
+		t.Fatal(err)
+	}
+	if oid := server.request("/x/v2/reply/main").URL.Query().Get("oid"); oid != "170001" {
+		t.Errorf("评论请求 oid = %q，期望稿件 aid 170001", oid)
+	}
+}
+
+func TestBilibiliGetVideoComments(t *testing.T) {
+	s, server := newTestBilibiliScraper(t, map[string]string{
+		"/x/web-interface/view": "bili_view.json",
+		"/x/v2/reply/main":      "bili_reply_main.json",
+	})
+
+	comments, cursor, err := s.GetVideoComments("BV1xx411c7mD", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor != "2" {
+		t.Errorf("游标 %q，期望接口返回的 next", cursor)
+	}
+	if server.request("/x/web-interface/view") == nil {
+		t.Error("未缓存 aid 时应通过稿件详情获取")
+	}
+	if len(comments) != 1 {
+		t.Fatalf("获取到 %d 条评论，期望 1 条", len(comments))
+	}
+
+	comment := comments[0]
+	if comment.CommentID != "9001" || comment.VideoID != "BV1xx411c7mD" || comment.UserID != "3001" ||
+		comment.Content != "去年买的很甜，今年还买" {
+		t.Errorf("评论 %+v", comment)
+	}
+	if comment.Likes != 88 || comment.Replies != 3 || comment.Timestamp != 1716600000 {
+		t.Errorf("点赞 %d 回复 %d 时间 %d", comment.Likes, comment.Replies, comment.Timestamp)
+	}
+	if comment.ParentID != "" || comment.ReplyToUserID != "" {
+		t.Errorf("一级评论 ParentID %q ReplyToUserID %q，期望为空", comment.ParentID, comment.ReplyToUserID)
+	}
+}
+
+func TestBilibiliGetCommentReplies(t *testing.T) {
+	s, _ := newTestBilibiliScraper(t, map[string]string{
+		"/x/v2/reply/reply": "bili_reply_reply.json",
+	})
+	s.rememberAid("BV1xx411c7mD", 170001)
+
+	replies, cursor, err := s.GetCommentReplies("BV1xx411c7mD", "9001", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor != "" {
+		t.Errorf("最后一页游标 %q，期望为空", cursor)
+	}
+	if len(replies) != 2 {
+		t.Fatalf("获取到 %d 条回复，期望 2 条", len(replies))
+	}
+	for _, reply := range replies {
+		if reply.ParentID != "9001" {
+			t.Errorf("回复 %s 的 ParentID %q，期望根评论 9001", reply.CommentID, reply.ParentID)
+		}
+	}
+	// 直接回复根评论时不记录被回复用户，楼中楼回复记录同页中被回复评论的作者
+	if replies[0].ReplyToUserID != "" {
+		t.Errorf("直接回复根评论时 ReplyToUserID = %q，期望为空", replies[0].ReplyToUserID)
+	}
+	if replies[1].ReplyToUserID != "3002" {
+		t.Errorf("楼中楼回复 ReplyToUserID = %q，期望 3002", replies[1].ReplyToUserID)
+	}
+}
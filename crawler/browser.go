@@ -0,0 +1,612 @@
+package crawler
+
+import (
+	"Crawler/utils/browserpool"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// BrowserRoute 浏览器后端的接口路由，描述 API 请求对应的页面以及页面自身加载的接口
+type BrowserRoute struct {
+	Path      string // API 爬虫请求的接口路径
+	Operation string // GraphQL 查询的根字段，非空时还需匹配请求体
+	Capture   string // 页面实际请求的接口路径，为空时与 Path 相同
+	Page      string // 页面地址模板，{参数名} 替换为请求参数
+	Cursor    string // 翻页参数名，翻页请求在已打开的页面中滚动加载
+	FirstPage string // 第一页的翻页参数值，为空时空字符串和 "0" 表示第一页
+}
+
+// capturePath 返回页面实际请求的接口路径
+func (r *BrowserRoute) capturePath() string {
+	if r.Capture != "" {
+		return r.Capture
+	}
+	return r.Path
+}
+
+var pageParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// pageURL 用请求参数填充页面地址模板，缺少参数时返回空字符串
+func (r *BrowserRoute) pageURL(params url.Values) string {
+	missing := false
+	page := pageParamPattern.ReplaceAllStringFunc(r.Page, func(match string) string {
+		value := params.Get(match[1 : len(match)-1])
+		if value == "" {
+			missing = true
+		}
+		return url.QueryEscape(value)
+	})
+	if missing {
+		return ""
+	}
+	return page
+}
+
+// nextPage 判断请求是否为第一页之后的翻页请求
+func (r *BrowserRoute) nextPage(params url.Values) bool {
+	if r.Cursor == "" {
+		return false
+	}
+	cursor := params.Get(r.Cursor)
+	if r.FirstPage != "" {
+		return cursor != "" && cursor != r.FirstPage
+	}
+	return cursor != "" && cursor != "0"
+}
+
+// BrowserConfig 浏览器后端配置
+type BrowserConfig struct {
+	UserAgent string            // 页面 UA，为空时使用 Chrome 默认值
+	Proxy     string            // 页面使用的代理，为空时直连
+	Headless  bool              // 是否以无头模式运行，仅在未指定 Pool 时生效
+	Timeout   time.Duration     // 等待页面加载接口的超时时间，默认 30 秒
+	MaxTabs   int               // 同时打开的最大页面数，默认 4
+	Pool      *browserpool.Pool // 共用的浏览器池，为空时使用独立的浏览器
+}
+
+// BrowserTransport 浏览器后端的 http.RoundTripper。
+// 有路由的 API 请求改为在浏览器中打开对应页面，截获页面自身加载的同一接口的响应返回给爬虫，
+// 因此解析逻辑与 API 爬虫完全相同；没有路由或缺少页面参数的请求仍直接发送
+type BrowserTransport struct {
+	routes   []BrowserRoute
+	config   BrowserConfig
+	fallback http.RoundTripper
+	pool     *browserpool.Pool
+	ownPool  bool
+
+	mutex   sync.Mutex
+	tabs    map[string]*browserTab
+	opening int        // 正在租用的页面数，与已打开的页面一起计入 MaxTabs
+	idle    *sync.Cond // 页面空闲或租用结束时通知等待的请求
+}
+
+// browserTab 从浏览器池租用的页面，翻页时复用
+type browserTab struct {
+	lease    *browserpool.Tab
+	ctx      context.Context
+	page     string
+	loaded   bool
+	users    int // 正在使用页面的请求数，由 BrowserTransport 的锁保护
+	lastUsed time.Time
+	cookies  map[string]string // 各域名已写入的 Cookie
+	busy     sync.Mutex        // 同一页面的 Cookie 写入、导航、滚动和等待串行执行
+
+	mutex    sync.Mutex
+	loader   cdp.LoaderID // 当前导航的文档加载ID，之前导航发起的请求不再使用
+	scrolls  int          // 当前导航后的滚动次数
+	pending  map[network.RequestID]pageRequest
+	captured map[int][]capturedBody // 按路由缓存的接口响应
+	signal   chan struct{}
+}
+
+// pageRequest 匹配路由的页面请求，记录发起请求的文档和滚动次数
+type pageRequest struct {
+	index  int
+	loader cdp.LoaderID
+	scroll int
+}
+
+// capturedBody 截获的接口响应
+type capturedBody struct {
+	data   []byte
+	loader cdp.LoaderID
+	scroll int
+}
+
+// httpClientHolder 支持浏览器后端的爬虫，浏览器后端替换其 HTTP 客户端的 Transport
+type httpClientHolder interface {
+	httpClient() *http.Client
+}
+
+// NewBrowserScraper 创建使用浏览器后端的爬虫实例。
+// 返回的 BrowserTransport 持有租用的页面，爬虫结束后需要调用 Close
+func NewBrowserScraper(name, userAgent, cookies string, config BrowserConfig) (Scraper, *BrowserTransport, error) {
+	scraper, err := NewScraper(name, userAgent, cookies)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, _ := Lookup(name)
+	holder, ok := scraper.(httpClientHolder)
+	if !ok || len(info.BrowserRoutes) == 0 {
+		return nil, nil, fmt.Errorf("平台 %s 不支持浏览器后端", name)
+	}
+
+	if config.UserAgent == "" {
+		config.UserAgent = userAgent
+	}
+	client := holder.httpClient()
+	transport := NewBrowserTransport(info.BrowserRoutes, config, client.Transport)
+	client.Transport = transport
+	return scraper, transport, nil
+}
+
+// NewBrowserTransport 创建浏览器后端，页面在首次请求时从浏览器池租用。fallback 为空时使用 http.DefaultTransport
+func NewBrowserTransport(routes []BrowserRoute, config BrowserConfig, fallback http.RoundTripper) *BrowserTransport {
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.MaxTabs <= 0 {
+		config.MaxTabs = 4
+	}
+	if fallback == nil {
+		fallback = http.DefaultTransport
+	}
+
+	transport := &BrowserTransport{
+		routes:   routes,
+		config:   config,
+		fallback: fallback,
+		pool:     config.Pool,
+		tabs:     make(map[string]*browserTab),
+	}
+	transport.idle = sync.NewCond(&transport.mutex)
+	if transport.pool == nil {
+		transport.pool = browserpool.New(browserpool.Config{Size: 1, TabsPerBrowser: config.MaxTabs, Headless: config.Headless})
+		transport.ownPool = true
+	}
+	return transport
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *BrowserTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	params, operation := browserRequestParams(req.URL, body)
+	index := -1
+	for i := range t.routes {
+		route := &t.routes[i]
+		if route.Path == req.URL.Path && (route.Operation == "" || route.Operation == operation) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return t.fallback.RoundTrip(req)
+	}
+
+	route := &t.routes[index]
+	page := route.pageURL(params)
+	if page == "" {
+		return t.fallback.RoundTrip(req)
+	}
+
+	tab, err := t.tab(page)
+	if err != nil {
+		return nil, err
+	}
+	defer t.release(tab)
+
+	data, err := tab.fetch(index, route.nextPage(params), req.Header.Get("Cookie"), t.config.Timeout)
+	if err != nil {
+		// 页面可能随浏览器重启失效，下次请求时重新打开
+		if tab.ctx.Err() != nil {
+			t.closeTab(tab)
+		}
+		return nil, fmt.Errorf("页面 %s 未加载接口 %s: %v", page, route.capturePath(), err)
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+var (
+	graphqlFieldPattern = regexp.MustCompile(`(\w+)\s*\(([^)]*)\)`)
+	graphqlArgPattern   = regexp.MustCompile(`(\w+)\s*:\s*"?([^",\s]*)"?`)
+)
+
+// browserRequestParams 提取请求参数：查询参数、JSON 请求体的顶层字段，
+// 以及 GraphQL 查询根字段的参数。operation 为 GraphQL 查询的根字段
+func browserRequestParams(u *url.URL, body []byte) (url.Values, string) {
+	params := u.Query()
+	if len(body) == 0 {
+		return params, ""
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return params, ""
+	}
+	for key, value := range payload {
+		switch value.(type) {
+		case string, float64, bool:
+			params.Set(key, fmt.Sprint(value))
+		}
+	}
+
+	query, _ := payload["query"].(string)
+	match := graphqlFieldPattern.FindStringSubmatch(query)
+	if match == nil {
+		return params, ""
+	}
+	for _, arg := range graphqlArgPattern.FindAllStringSubmatch(match[2], -1) {
+		params.Set(arg[1], arg[2])
+	}
+	return params, match[1]
+}
+
+// tab 返回已打开的页面，没有时从浏览器池租用。返回的页面使用完毕后需要调用 release
+func (t *BrowserTransport) tab(page string) (*browserTab, error) {
+	tab, evicted := t.reserve(page)
+	if evicted != nil {
+		evicted.lease.Discard()
+	}
+	if tab != nil {
+		return tab, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.config.Timeout)
+	defer cancel()
+	lease, err := t.pool.Acquire(ctx, browserpool.TabOptions{Proxy: t.config.Proxy, UserAgent: t.config.UserAgent})
+	if err != nil {
+		t.cancelOpening()
+		return nil, fmt.Errorf("租用浏览器页面失败: %v", err)
+	}
+
+	tab = newBrowserTab(lease, page)
+	chromedp.ListenTarget(tab.ctx, func(ev interface{}) {
+		t.handleEvent(tab, ev)
+	})
+	if err := chromedp.Run(tab.ctx, network.Enable()); err != nil {
+		lease.Discard()
+		t.cancelOpening()
+		return nil, fmt.Errorf("打开页面失败: %v", err)
+	}
+
+	// 并发请求可能已打开同一页面
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.opening--
+	if existing, ok := t.tabs[page]; ok {
+		lease.Discard()
+		existing.users++
+		t.idle.Broadcast()
+		return existing, nil
+	}
+	t.tabs[page] = tab
+	return tab, nil
+}
+
+// newBrowserTab 创建页面状态，使用者计数为 1
+func newBrowserTab(lease *browserpool.Tab, page string) *browserTab {
+	tab := &browserTab{
+		lease:    lease,
+		page:     page,
+		users:    1,
+		lastUsed: time.Now(),
+		cookies:  make(map[string]string),
+		pending:  make(map[network.RequestID]pageRequest),
+		captured: make(map[int][]capturedBody),
+		signal:   make(chan struct{}, 1),
+	}
+	if lease != nil {
+		tab.ctx = lease.Context()
+	}
+	return tab
+}
+
+// reserve 返回已打开的页面并计入使用者；没有时占用一个页面名额，由调用方租用新页面。
+// 页面数达到 MaxTabs 时关闭最久未使用的空闲页面，全部页面都在使用时等待。
+// evicted 为被淘汰的页面，调用方在锁外关闭
+func (t *BrowserTransport) reserve(page string) (tab *browserTab, evicted *browserTab) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for {
+		if tab, ok := t.tabs[page]; ok {
+			tab.users++
+			tab.lastUsed = time.Now()
+			return tab, nil
+		}
+		if len(t.tabs)+t.opening < t.config.MaxTabs {
+			t.opening++
+			return nil, nil
+		}
+
+		var oldest *browserTab
+		for _, tab := range t.tabs {
+			if tab.users == 0 && (oldest == nil || tab.lastUsed.Before(oldest.lastUsed)) {
+				oldest = tab
+			}
+		}
+		if oldest != nil {
+			delete(t.tabs, oldest.page)
+			t.opening++
+			return nil, oldest
+		}
+		t.idle.Wait()
+	}
+}
+
+// cancelOpening 租用页面失败时归还占用的名额
+func (t *BrowserTransport) cancelOpening() {
+	t.mutex.Lock()
+	t.opening--
+	t.idle.Broadcast()
+	t.mutex.Unlock()
+}
+
+// release 结束对页面的使用，页面变为空闲后可被淘汰
+func (t *BrowserTransport) release(tab *browserTab) {
+	t.mutex.Lock()
+	tab.users--
+	tab.lastUsed = time.Now()
+	t.idle.Broadcast()
+	t.mutex.Unlock()
+}
+
+// closeTab 关闭页面并归还浏览器池
+func (t *BrowserTransport) closeTab(tab *browserTab) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.tabs[tab.page] == tab {
+		delete(t.tabs, tab.page)
+		tab.lease.Discard()
+		t.idle.Broadcast()
+	}
+}
+
+// handleEvent 记录匹配路由的页面请求，请求完成后读取响应内容
+func (t *BrowserTransport) handleEvent(tab *browserTab, ev interface{}) {
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		if index := t.match(ev.Request); index >= 0 {
+			tab.mutex.Lock()
+			tab.pending[ev.RequestID] = pageRequest{index: index, loader: ev.LoaderID, scroll: tab.scrolls}
+			tab.mutex.Unlock()
+		}
+
+	case *network.EventLoadingFinished:
+		tab.mutex.Lock()
+		request, ok := tab.pending[ev.RequestID]
+		delete(tab.pending, ev.RequestID)
+		tab.mutex.Unlock()
+
+		// 事件回调中不能直接执行 CDP 命令
+		if ok {
+			go tab.readBody(ev.RequestID, request)
+		}
+
+	case *network.EventLoadingFailed:
+		tab.mutex.Lock()
+		delete(tab.pending, ev.RequestID)
+		tab.mutex.Unlock()
+	}
+}
+
+// match 返回页面请求匹配的路由序号，不匹配时返回 -1
+func (t *BrowserTransport) match(request *network.Request) int {
+	u, err := url.Parse(request.URL)
+	if err != nil {
+		return -1
+	}
+	for i := range t.routes {
+		route := &t.routes[i]
+		if route.capturePath() != u.Path {
+			continue
+		}
+		if route.Operation == "" || strings.Contains(request.PostData, route.Operation+"(") {
+			return i
+		}
+	}
+	return -1
+}
+
+// setCookies 将 Cookie 字符串写入页面所在的主域名
+func (tab *browserTab) setCookies(ctx context.Context, cookies string) error {
+	u, err := url.Parse(tab.page)
+	if err != nil {
+		return fmt.Errorf("解析页面地址失败: %v", err)
+	}
+	domain := u.Hostname()
+	if labels := strings.Split(domain, "."); len(labels) > 2 {
+		domain = strings.Join(labels[len(labels)-2:], ".")
+	}
+	if cookies == "" || tab.cookies[domain] == cookies {
+		return nil
+	}
+
+	err = chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		for _, part := range strings.Split(cookies, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok || name == "" {
+				continue
+			}
+			if err := network.SetCookie(name, value).WithDomain("." + domain).WithPath("/").Do(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	if err != nil {
+		return fmt.Errorf("写入浏览器Cookie失败: %v", err)
+	}
+
+	tab.cookies[domain] = cookies
+	return nil
+}
+
+// readBody 读取页面请求的响应内容并加入缓存
+func (tab *browserTab) readBody(requestID network.RequestID, request pageRequest) {
+	var body []byte
+	err := chromedp.Run(tab.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		body, err = network.GetResponseBody(requestID).Do(ctx)
+		return err
+	}))
+	if err != nil || len(body) == 0 {
+		return
+	}
+
+	tab.capture(request, body)
+}
+
+// capture 缓存接口响应并通知等待的请求
+func (tab *browserTab) capture(request pageRequest, body []byte) {
+	tab.mutex.Lock()
+	tab.captured[request.index] = append(tab.captured[request.index], capturedBody{data: body, loader: request.loader, scroll: request.scroll})
+	tab.mutex.Unlock()
+
+	select {
+	case tab.signal <- struct{}{}:
+	default:
+	}
+}
+
+// take 取出一个当前导航加载的接口响应，之前导航的响应直接丢弃。
+// 第一页请求只使用页面打开时加载的响应，翻页请求只使用滚动后加载的响应
+func (tab *browserTab) take(index int, next bool) []byte {
+	tab.mutex.Lock()
+	defer tab.mutex.Unlock()
+
+	bodies := tab.captured[index]
+	for i := 0; i < len(bodies); i++ {
+		body := bodies[i]
+		if body.loader != tab.loader {
+			bodies = append(bodies[:i], bodies[i+1:]...)
+			i--
+			continue
+		}
+		if (body.scroll > 0) == next {
+			tab.captured[index] = append(bodies[:i], bodies[i+1:]...)
+			return body.data
+		}
+	}
+	tab.captured[index] = bodies
+	return nil
+}
+
+// fetch 返回页面加载的指定路由接口响应。
+// 优先使用当前导航已缓存的响应（同一页面通常同时加载多个接口）；第一页请求重新打开页面，翻页请求滚动到底部触发加载
+func (tab *browserTab) fetch(index int, next bool, cookies string, timeout time.Duration) ([]byte, error) {
+	tab.busy.Lock()
+	defer tab.busy.Unlock()
+
+	// 页面尚未打开时翻页请求也需要打开页面，返回页面打开时加载的响应
+	next = next && tab.loaded
+	if body := tab.take(index, next); body != nil {
+		return body, nil
+	}
+
+	ctx, cancel := context.WithTimeout(tab.ctx, timeout)
+	defer cancel()
+
+	if err := tab.setCookies(ctx, cookies); err != nil {
+		return nil, err
+	}
+
+	if next {
+		tab.mutex.Lock()
+		tab.scrolls++
+		tab.mutex.Unlock()
+
+		if err := chromedp.Run(ctx, chromedp.Evaluate(`window.scrollTo(0, document.documentElement.scrollHeight)`, nil)); err != nil {
+			return nil, fmt.Errorf("滚动页面失败: %v", err)
+		}
+	} else {
+		tab.mutex.Lock()
+		tab.scrolls = 0
+		tab.pending = make(map[network.RequestID]pageRequest)
+		tab.captured = make(map[int][]capturedBody)
+		tab.mutex.Unlock()
+
+		// 记录本次导航的文档加载ID，只接受新文档发起的接口请求
+		err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			_, loader, errorText, err := page.Navigate(tab.page).Do(ctx)
+			if err != nil {
+				return err
+			}
+			if errorText != "" {
+				return errors.New(errorText)
+			}
+			tab.mutex.Lock()
+			tab.loader = loader
+			tab.mutex.Unlock()
+			return nil
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("打开页面失败: %v", err)
+		}
+		tab.loaded = true
+	}
+
+	for {
+		if body := tab.take(index, next); body != nil {
+			return body, nil
+		}
+		select {
+		case <-tab.signal:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close 归还全部页面，使用独立浏览器时关闭浏览器
+func (t *BrowserTransport) Close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for page, tab := range t.tabs {
+		tab.lease.Discard()
+		delete(t.tabs, page)
+	}
+	t.idle.Broadcast()
+	if t.ownPool {
+		t.pool.Close()
+	}
+	return nil
+}
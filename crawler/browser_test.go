@@ -0,0 +1,151 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+)
+
+// newTestBrowserTransport 创建不启动浏览器的浏览器后端，页面直接写入 tabs
+func newTestBrowserTransport(maxTabs int) *BrowserTransport {
+	return NewBrowserTransport(nil, BrowserConfig{MaxTabs: maxTabs}, nil)
+}
+
+func TestBrowserTransportReserve(t *testing.T) {
+	transport := newTestBrowserTransport(2)
+	defer transport.pool.Close()
+
+	busy := newBrowserTab(nil, "https://example.com/busy")
+	idle := newBrowserTab(nil, "https://example.com/idle")
+	idle.users = 0
+	idle.lastUsed = time.Now().Add(-time.Minute)
+	transport.tabs[busy.page] = busy
+	transport.tabs[idle.page] = idle
+
+	// 已打开的页面直接复用并计入使用者
+	if tab, evicted := transport.reserve(busy.page); tab != busy || evicted != nil || busy.users != 2 {
+		t.Fatalf("复用已打开的页面失败: %v %v users=%d", tab, evicted, busy.users)
+	}
+	transport.release(busy)
+
+	// 达到上限时只淘汰空闲页面
+	tab, evicted := transport.reserve("https://example.com/new")
+	if tab != nil || evicted != idle {
+		t.Fatalf("期望淘汰空闲页面，实际返回 %v 淘汰 %v", tab, evicted)
+	}
+	if _, ok := transport.tabs[busy.page]; !ok {
+		t.Fatal("使用中的页面不应被淘汰")
+	}
+
+	// 全部页面都在使用时等待，直到有页面空闲
+	reserved := make(chan *browserTab, 1)
+	go func() {
+		_, evicted := transport.reserve("https://example.com/other")
+		reserved <- evicted
+	}()
+	select {
+	case <-reserved:
+		t.Fatal("页面数达到上限且没有空闲页面时不应打开新页面")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	transport.release(busy)
+	select {
+	case evicted := <-reserved:
+		if evicted != busy {
+			t.Errorf("页面空闲后淘汰 %v，期望 %v", evicted, busy)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("页面空闲后仍在等待")
+	}
+
+	// 已打开和正在租用的页面数不超过上限
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	if total := len(transport.tabs) + transport.opening; total > 2 {
+		t.Errorf("页面数 %d 超过上限 2", total)
+	}
+}
+
+func TestBrowserTabTake(t *testing.T) {
+	tab := newBrowserTab(nil, "https://example.com/user")
+	tab.loader = cdp.LoaderID("old")
+	tab.capture(pageRequest{index: 0, loader: "old"}, []byte("old"))
+
+	// 重新导航后之前导航的响应被丢弃
+	tab.loader = cdp.LoaderID("new")
+	if body := tab.take(0, false); body != nil {
+		t.Fatalf("返回了之前导航的响应 %s", body)
+	}
+	if len(tab.captured[0]) != 0 {
+		t.Errorf("之前导航的响应未丢弃: %d 条", len(tab.captured[0]))
+	}
+
+	// 第一页只使用页面打开时加载的响应，翻页只使用滚动后加载的响应
+	tab.capture(pageRequest{index: 0, loader: "new", scroll: 1}, []byte("page2"))
+	tab.capture(pageRequest{index: 0, loader: "new"}, []byte("page1"))
+	if body := tab.take(0, false); string(body) != "page1" {
+		t.Errorf("第一页请求返回 %q，期望 page1", body)
+	}
+	if body := tab.take(0, false); body != nil {
+		t.Errorf("第一页响应已取出，仍返回 %q", body)
+	}
+	if body := tab.take(0, true); string(body) != "page2" {
+		t.Errorf("翻页请求返回 %q，期望 page2", body)
+	}
+}
+
+func TestBrowserRoutes(t *testing.T) {
+	tests := []struct {
+		platform Platform
+		path     string
+		params   url.Values
+		want     string
+	}{
+		{Douyin, "/aweme/v1/web/promotion/product/detail/", url.Values{"product_id": {"3600000000"}}, "https://haohuo.jinritemai.com/ecommerce/trade/detail/index.html?id=3600000000"},
+		{Kuaishou, "/graphql", url.Values{"photoId": {"3xabc"}}, "https://www.kuaishou.com/short-video/3xabc"},
+		{Xiaohongshu, "/api/store/item/detail", url.Values{"item_id": {"64f1c0de"}}, "https://www.xiaohongshu.com/goods-detail/64f1c0de"},
+		{Bilibili, "/mall-c/items/info", url.Values{"itemsId": {"10086"}}, "https://mall.bilibili.com/neul-next/detailuniversal/detail.html?itemsId=10086"},
+	}
+	for _, tt := range tests {
+		info, ok := Lookup(string(tt.platform))
+		if !ok {
+			t.Fatalf("平台 %s 未注册", tt.platform)
+		}
+		found := false
+		for _, route := range info.BrowserRoutes {
+			if route.Path != tt.path {
+				continue
+			}
+			if page := route.pageURL(tt.params); page == tt.want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s 没有 %s 对应 %s 的页面路由", tt.platform, tt.path, tt.want)
+		}
+	}
+}
+
+func TestKuaishouBrowserRouteMatch(t *testing.T) {
+	info, _ := Lookup(string(Kuaishou))
+
+	// GraphQL 请求按查询的根字段匹配路由，参数取自查询
+	body := []byte(`{"query":"{\n\t\tphotoCommentList(photoId: \"3xabc\", pcursor: \"\") {\n\t\t\tpcursor\n\t\t}\n\t}"}`)
+	params, operation := browserRequestParams(&url.URL{Path: "/graphql"}, body)
+	if operation != "photoCommentList" || params.Get("photoId") != "3xabc" {
+		t.Fatalf("解析出根字段 %q 参数 %v", operation, params)
+	}
+	for _, route := range info.BrowserRoutes {
+		if route.Operation == operation {
+			if route.nextPage(params) {
+				t.Error("空 pcursor 应视为第一页")
+			}
+			return
+		}
+	}
+	t.Error("快手评论请求没有匹配的路由")
+}
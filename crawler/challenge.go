@@ -0,0 +1,276 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Challenge 风控验证信息
+type Challenge struct {
+	Platform  Platform `json:"platform"`
+	Account   string   `json:"account,omitempty"`    // 触发验证的账号，由调用方填写
+	URL       string   `json:"url"`                  // 验证页面地址，未知时为平台首页
+	Reason    string   `json:"reason"`               // 识别为验证的依据
+	UserAgent string   `json:"user_agent,omitempty"` // 触发验证的请求使用的 UA，由调用方填写
+	Cookies   string   `json:"cookies,omitempty"`    // 触发验证的会话Cookie，由调用方填写
+}
+
+// ChallengeError 请求触发了风控验证，errors.Is(err, ErrChallenge) 为 true
+type ChallengeError struct {
+	Challenge
+}
+
+// Error 实现 error
+func (e *ChallengeError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrChallenge, e.Reason)
+}
+
+// Unwrap 返回 ErrChallenge
+func (e *ChallengeError) Unwrap() error {
+	return ErrChallenge
+}
+
+// ChallengeSolver 处理风控验证，返回验证通过后需要写入会话的Cookie，验证失败返回错误
+type ChallengeSolver interface {
+	Solve(challenge *Challenge) ([]*http.Cookie, error)
+}
+
+// urlPattern 匹配文本中的网址，包括 JSON 中转义为 \/ 的网址
+var urlPattern = regexp.MustCompile(`https?:(?:\\?/){2}[^"'\s<>]+`)
+
+// findChallengeURL 返回文本中的第一个验证页面地址：verify. 或 captcha. 开头的域名，或路径中包含 captcha、verifycenter
+func findChallengeURL(text string) string {
+	for _, match := range urlPattern.FindAllString(text, -1) {
+		link := strings.ReplaceAll(match, `\/`, "/")
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		host, path := strings.ToLower(u.Hostname()), strings.ToLower(u.Path)
+		if strings.HasPrefix(host, "verify.") || strings.HasPrefix(host, "captcha.") ||
+			strings.Contains(path, "captcha") || strings.Contains(path, "verifycenter") {
+			return link
+		}
+	}
+	return ""
+}
+
+// challengeStatusCodes 表示触发风控验证的接口状态码
+var challengeStatusCodes = map[int]string{
+	461: "触发验证码",
+	412: "请求被风控拦截",
+}
+
+// kuaishouCaptchaResult 快手接口要求滑块验证时返回的 result
+const kuaishouCaptchaResult = 400002
+
+// challengeTransport 识别风控验证响应的 http.RoundTripper，识别到验证时返回 *ChallengeError。
+// 只识别已知的验证响应：跳转到验证页面、字节系接口的验证响应头、验证状态码、
+// 接口 JSON 中的 verify_* 字段或快手的验证 result，以及接口返回空响应或验证页面而非 JSON。
+// 正常 JSON 内容中出现的验证页面地址（如评论或商品描述中的链接）不视为验证
+type challengeTransport struct {
+	next     http.RoundTripper
+	platform Platform
+	apiPaths []string // 接口路径特征，只检查接口请求的响应内容
+}
+
+// newChallengeTransport 创建识别风控验证的 Transport
+func newChallengeTransport(platform Platform, apiPaths ...string) *challengeTransport {
+	return &challengeTransport{next: http.DefaultTransport, platform: platform, apiPaths: apiPaths}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *challengeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 跳转到验证页面
+	if location := findChallengeURL(resp.Header.Get("Location")); location != "" {
+		resp.Body.Close()
+		return nil, t.challenge(location, fmt.Sprintf("请求被重定向到验证页面 %s", location))
+	}
+
+	// 字节系接口通过响应头下发滑块验证参数
+	for _, header := range []string{"X-Vc-Bdturing-Parameters", "Bdturing-Verify"} {
+		if value := resp.Header.Get(header); value != "" {
+			resp.Body.Close()
+			return nil, t.challenge(verifyURL(value), fmt.Sprintf("响应头 %s 要求验证", header))
+		}
+	}
+
+	if !t.isAPI(req.URL) {
+		return resp, nil
+	}
+
+	if reason, ok := challengeStatusCodes[resp.StatusCode]; ok {
+		resp.Body.Close()
+		return nil, t.challenge("", fmt.Sprintf("接口返回状态码 %d，%s", resp.StatusCode, reason))
+	}
+
+	// 读取响应内容检查后放回，供爬虫继续解析
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// 触发风控时接口常返回状态码 200 的空响应
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, t.challenge("", fmt.Sprintf("接口返回空响应，状态码 %d", resp.StatusCode))
+	}
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		if link, reason, ok := jsonChallenge(trimmed); ok {
+			return nil, t.challenge(link, reason)
+		}
+		return resp, nil
+	}
+
+	// 接口返回 HTML 验证页面
+	lower := strings.ToLower(string(trimmed))
+	if strings.Contains(lower, "captcha") || strings.Contains(lower, "verify") || strings.Contains(lower, "验证") {
+		return nil, t.challenge(findChallengeURL(string(trimmed)), fmt.Sprintf("接口返回验证页面而非 JSON，状态码 %d", resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// jsonChallenge 识别 JSON 格式的验证响应：顶层或 data 中的 verify_* 字段（字节系接口），
+// 或 result 为快手滑块验证的响应。link 为响应中的验证页面地址
+func jsonChallenge(body []byte) (link, reason string, ok bool) {
+	var top map[string]json.RawMessage
+	if json.Unmarshal(body, &top) != nil {
+		return "", "", false
+	}
+	var data map[string]json.RawMessage
+	json.Unmarshal(top["data"], &data)
+
+	for _, fields := range []map[string]json.RawMessage{top, data} {
+		for key, value := range fields {
+			if strings.HasPrefix(key, "verify_") {
+				return challengeLink(fields, string(value)), fmt.Sprintf("接口返回验证字段 %s", key), true
+			}
+		}
+	}
+
+	var result int
+	if json.Unmarshal(top["result"], &result) == nil && result == kuaishouCaptchaResult {
+		link := challengeLink(top, "")
+		if link == "" {
+			link = challengeLink(data, "")
+		}
+		return link, fmt.Sprintf("接口返回 result %d，要求滑块验证", result), true
+	}
+	return "", "", false
+}
+
+// challengeLink 返回验证响应中的验证页面地址，依次读取 url、verify_url 字段和 text 中的地址
+func challengeLink(fields map[string]json.RawMessage, text string) string {
+	for _, key := range []string{"url", "verify_url"} {
+		var link string
+		if json.Unmarshal(fields[key], &link) == nil && link != "" {
+			return link
+		}
+	}
+	return findChallengeURL(text)
+}
+
+// isAPI 判断请求是否为接口请求
+func (t *challengeTransport) isAPI(u *url.URL) bool {
+	for _, path := range t.apiPaths {
+		if strings.Contains(u.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// challenge 创建验证错误，未知验证页面时使用平台首页
+func (t *challengeTransport) challenge(link, reason string) *ChallengeError {
+	if link == "" {
+		if info, ok := Lookup(string(t.platform)); ok {
+			link = info.LoginURL
+		}
+	}
+	return &ChallengeError{Challenge{Platform: t.platform, URL: link, Reason: reason}}
+}
+
+// verifyURL 从验证响应头中提取验证页面地址，响应头为 JSON 时读取其中的 url 字段
+func verifyURL(header string) string {
+	var params struct {
+		URL string `json:"url"`
+	}
+	if json.Unmarshal([]byte(header), &params) == nil && params.URL != "" {
+		return params.URL
+	}
+	return findChallengeURL(header)
+}
+
+// HTTPSolver 调用外部验证服务处理风控验证的 ChallengeSolver。
+// 以 JSON 格式 POST Challenge，服务返回 {"success": true, "cookies": "name=value; ..."} 表示验证通过，
+// success 为 false 时 message 为失败原因
+type HTTPSolver struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewHTTPSolver 创建调用外部验证服务的 ChallengeSolver，timeout 为 0 时默认 120 秒
+func NewHTTPSolver(endpoint string, timeout time.Duration) *HTTPSolver {
+	if timeout <= 0 {
+		timeout = 120 * time.Second
+	}
+	return &HTTPSolver{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Solve 实现 ChallengeSolver
+func (s *HTTPSolver) Solve(challenge *Challenge) ([]*http.Cookie, error) {
+	payload, err := json.Marshal(challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("请求验证服务失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("验证服务返回状态码 %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Success bool   `json:"success"`
+		Cookies string `json:"cookies"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析验证服务响应失败: %v", err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("验证服务未能完成验证: %s", result.Message)
+	}
+
+	// 解析请求头格式的Cookie
+	var cookies []*http.Cookie
+	for _, part := range strings.Split(result.Cookies, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && name != "" {
+			cookies = append(cookies, &http.Cookie{Name: name, Value: value})
+		}
+	}
+	return cookies, nil
+}
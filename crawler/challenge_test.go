@@ -0,0 +1,150 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChallengeTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		header    map[string]string
+		body      string
+		challenge bool
+		url       string
+	}{
+		{"正常响应", 200, nil, `{"status_code":0,"user":{"nickname":"砀山梨园"}}`, false, ""},
+		{"内容中的验证页面链接", 200, nil, `{"status_code":0,"comments":[{"text":"https://verify.example.com/captcha 是钓鱼链接"}]}`, false, ""},
+		{"字节系 verify 字段", 200, nil, `{"code":10000,"verify_data":"{}","verify_url":"https://verify.snssdk.com/view?aid=6383"}`, true, "https://verify.snssdk.com/view?aid=6383"},
+		{"data 中的 verify 字段", 200, nil, `{"status_code":0,"data":{"verify_center_decision_conf":"{\"type\":\"slide\"}"}}`, true, "https://www.douyin.com/"},
+		{"快手滑块验证", 200, nil, `{"result":400002,"url":"https://captcha.zt.kuaishou.com/iframe/index.html?captchaSession=abc"}`, true, "https://captcha.zt.kuaishou.com/iframe/index.html?captchaSession=abc"},
+		{"快手其他错误", 200, nil, `{"result":2,"error_msg":"参数错误"}`, false, ""},
+		{"验证状态码", 461, nil, `{}`, true, "https://www.douyin.com/"},
+		{"验证响应头", 200, map[string]string{"X-Vc-Bdturing-Parameters": `{"url":"https://verify.snssdk.com/v"}`}, `{}`, true, "https://verify.snssdk.com/v"},
+		{"空响应", 200, nil, "  ", true, "https://www.douyin.com/"},
+		{"HTML 验证页面", 200, nil, `<html><title>验证码中间页</title></html>`, true, "https://www.douyin.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.header {
+					w.Header().Set(key, value)
+				}
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: newChallengeTransport(Douyin, "/aweme/")}
+			resp, err := client.Get(server.URL + "/aweme/v1/web/user/profile/other/")
+
+			var challenge *ChallengeError
+			if !tt.challenge {
+				if err != nil {
+					t.Fatalf("不应识别为验证: %v", err)
+				}
+				defer resp.Body.Close()
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != tt.body {
+					t.Errorf("响应内容 %q，期望原样返回", body)
+				}
+				return
+			}
+			if !errors.As(err, &challenge) || !errors.Is(err, ErrChallenge) {
+				t.Fatalf("期望返回 *ChallengeError，实际 %v", err)
+			}
+			if challenge.Platform != Douyin || challenge.URL != tt.url {
+				t.Errorf("验证平台 %s 地址 %q，期望 %q", challenge.Platform, challenge.URL, tt.url)
+			}
+		})
+	}
+}
+
+func TestChallengeTransportNonAPI(t *testing.T) {
+	// 非接口请求不检查响应内容
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(461)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newChallengeTransport(Douyin, "/aweme/")}
+	resp, err := client.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("首页请求不应识别为验证: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestDouyinChallengePaths(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(461)
+	}))
+	defer server.Close()
+
+	// 抖音爬虫的 Transport 将全部请求转发到返回验证状态码的测试服务器
+	client := NewDouyinScraper("test-agent", "").client
+	transport := client.Transport.(*challengeTransport)
+	transport.next = (&fixtureServer{Server: server}).client().Transport
+
+	for _, link := range []string{
+		"https://www.douyin.com/aweme/v1/web/user/profile/other/",
+		"https://live.douyin.com/webcast/room/info_by_user/",
+		"https://ec.snssdk.com/product/comment/list",
+		"https://ec.snssdk.com/shop/shop_info",
+		"https://ec.snssdk.com/shop/goods/list",
+	} {
+		_, err := client.Get(link)
+		if !errors.Is(err, ErrChallenge) {
+			t.Errorf("%s 期望识别为验证，实际 %v", link, err)
+		}
+	}
+}
+
+func TestHTTPSolver(t *testing.T) {
+	var received Challenge
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("解析验证请求失败: %v", err)
+		}
+		switch received.Account {
+		case "ok":
+			io.WriteString(w, `{"success":true,"cookies":"s_v_web_id=verify_abc; ttwid=t1; invalid"}`)
+		case "failed":
+			io.WriteString(w, `{"success":false,"message":"滑块识别失败"}`)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	solver := NewHTTPSolver(server.URL, 0)
+	challenge := &Challenge{Platform: Douyin, Account: "ok", URL: "https://verify.snssdk.com/view", Reason: "测试", Cookies: "sessionid=abc"}
+	cookies, err := solver.Solve(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != *challenge {
+		t.Errorf("验证服务收到 %+v，期望 %+v", received, *challenge)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q，期望 application/json", contentType)
+	}
+	if len(cookies) != 2 || cookies[0].Name != "s_v_web_id" || cookies[0].Value != "verify_abc" || cookies[1].Name != "ttwid" {
+		t.Errorf("返回的Cookie %v", cookies)
+	}
+
+	for _, account := range []string{"failed", "error"} {
+		challenge.Account = account
+		if _, err := solver.Solve(challenge); err == nil {
+			t.Errorf("账号 %s 的验证应返回错误", account)
+		}
+	}
+}
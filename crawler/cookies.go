@@ -0,0 +1,26 @@
+package crawler
+
+import "strings"
+
+// cookieValue 从 Cookie 字符串中取出指定名称的值
+func cookieValue(cookies, name string) string {
+	for _, part := range strings.Split(cookies, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && key == name {
+			return value
+		}
+	}
+	return ""
+}
+
+// setCookieValue 设置 Cookie 字符串中指定名称的值，已有同名 Cookie 时替换
+func setCookieValue(cookies, name, value string) string {
+	var parts []string
+	for _, part := range strings.Split(cookies, ";") {
+		part = strings.TrimSpace(part)
+		if key, _, _ := strings.Cut(part, "="); key != "" && key != name {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(append(parts, name+"="+value), "; ")
+}
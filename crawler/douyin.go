@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -15,17 +17,60 @@ type DouyinScraper struct {
 	client    *http.Client
 	userAgent string
 	cookies   string
+	signer    DouyinSigner
+	device    *douyinDevice
 }
 
-// NewDouyinScraper 创建抖音爬虫实例
+func init() {
+	Register(PlatformInfo{
+		Name:         Douyin,
+		DisplayName:  "抖音",
+		LoginURL:     "https://www.douyin.com/",
+		LoginCookies: []string{"sessionid"},
+		Headers:      map[string]string{"Referer": "https://www.douyin.com/"},
+		Capabilities: []Capability{CapabilityReplies, CapabilityLive},
+		New: func(userAgent, cookies string) Scraper {
+			return NewDouyinScraper(userAgent, cookies)
+		},
+		BrowserRoutes: []BrowserRoute{
+			{Path: "/aweme/v1/web/user/profile/other/", Page: "https://www.douyin.com/user/{user_id}"},
+			{Path: "/aweme/v1/web/aweme/post/", Page: "https://www.douyin.com/user/{user_id}", Cursor: "cursor"},
+			{Path: "/aweme/v2/web/comment/list/", Capture: "/aweme/v1/web/comment/list/", Page: "https://www.douyin.com/video/{aweme_id}", Cursor: "cursor"},
+			{Path: "/aweme/v1/web/promotion/product/detail/", Page: "https://haohuo.jinritemai.com/ecommerce/trade/detail/index.html?id={product_id}"},
+		},
+	})
+}
+
+// NewDouyinScraper 创建抖音爬虫实例，默认使用纯 Go 的 X-Bogus 签名。
+// 接口触发滑块等风控验证时返回 *ChallengeError
 func NewDouyinScraper(userAgent, cookies string) *DouyinScraper {
 	return &DouyinScraper{
 		client: &http.Client{
-			Timeout: time.Second * 30,
+			Timeout:   time.Second * 30,
+			Transport: newChallengeTransport(Douyin, "/aweme/", "/webcast/", "/product/", "/shop/"),
 		},
 		userAgent: userAgent,
 		cookies:   cookies,
+		signer:    XBogusSigner{},
+	}
+}
+
+// httpClient 返回爬虫使用的 HTTP 客户端，浏览器后端通过它接管请求
+func (s *DouyinScraper) httpClient() *http.Client {
+	return s.client
+}
+
+// SetSigner 替换请求签名器，需在 Initialize 之前调用
+func (s *DouyinScraper) SetSigner(signer DouyinSigner) {
+	s.signer = signer
+}
+
+// Close 释放签名器占用的资源
+func (s *DouyinScraper) Close() error {
+	if closer, ok := s.signer.(io.Closer); ok {
+		return closer.Close()
 	}
+	return nil
 }
 
 // Initialize 初始化爬虫
@@ -36,8 +81,7 @@ func (s *DouyinScraper) Initialize() error {
 		return err
 	}
 
-	req.Header.Set("User-Agent", s.userAgent)
-	req.Header.Set("Cookie", s.cookies)
+	setHeaders(req, Douyin, s.userAgent, s.cookies)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -49,50 +93,151 @@ func (s *DouyinScraper) Initialize() error {
 		return errors.New("invalid cookies or blocked by anti-crawler")
 	}
 
+	// 生成请求需要携带的设备指纹
+	s.device = newDouyinDevice(s.client, s.userAgent, s.cookies)
+
 	return nil
 }
 
-// GetUserInfo 获取用户信息
-func (s *DouyinScraper) GetUserInfo(userID string) (*UserData, error) {
-	// 构建API请求URL
-	apiURL := fmt.Sprintf("https://www.douyin.com/aweme/v1/web/user/profile/other/?user_id=%s", url.QueryEscape(userID))
+// douyinWebHost 抖音 Web 端接口的域名，仅该域名的请求携带公共参数、设备指纹参数和签名
+const douyinWebHost = "www.douyin.com"
+
+// douyinWebParams Web 端接口的公共查询参数，浏览器信息需与 User-Agent 一致
+var douyinWebParams = url.Values{
+	"device_platform":  {"webapp"},
+	"aid":              {"6383"},
+	"channel":          {"channel_pc_web"},
+	"pc_client_type":   {"1"},
+	"version_code":     {"190500"},
+	"version_name":     {"19.5.0"},
+	"cookie_enabled":   {"true"},
+	"screen_width":     {"1920"},
+	"screen_height":    {"1080"},
+	"browser_language": {"zh-CN"},
+	"browser_platform": {"MacIntel"},
+	"browser_name":     {"Chrome"},
+	"browser_version":  {"122.0.0.0"},
+	"browser_online":   {"true"},
+	"engine_name":      {"Blink"},
+	"engine_version":   {"122.0.0.0"},
+	"os_name":          {"Mac OS"},
+	"os_version":       {"10.15.7"},
+	"cpu_core_num":     {"8"},
+	"device_memory":    {"8"},
+	"platform":         {"PC"},
+	"downlink":         {"10"},
+	"effective_type":   {"4g"},
+	"round_trip_time":  {"50"},
+}
+
+// request 发送 GET 请求并将响应解析到 out；referer 为空时使用平台默认值。
+// Web 端接口的查询参数依次为接口参数、公共参数和设备指纹，最后追加签名；
+// 电商和直播接口只携带接口参数
+func (s *DouyinScraper) request(apiURL string, params url.Values, referer string, out interface{}) error {
+	target, err := url.Parse(apiURL)
+	if err != nil {
+		return fmt.Errorf("解析请求地址失败: %v", err)
+	}
+	webAPI := target.Host == douyinWebHost
+
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+
+	cookies := s.cookies
+	if s.device != nil {
+		cookies = s.device.cookies(cookies)
+	}
+
+	if webAPI {
+		for key, values := range douyinWebParams {
+			if _, ok := query[key]; !ok {
+				query[key] = values
+			}
+		}
+		if s.device != nil {
+			for key, values := range s.device.params() {
+				query[key] = values
+			}
+		}
+	}
+
+	// 对编码后的查询字符串签名
+	encoded := query.Encode()
+	if webAPI {
+		signature, err := s.signer.Sign(encoded, s.userAgent)
+		if err != nil {
+			return fmt.Errorf("请求签名失败: %v", err)
+		}
+		if len(signature) > 0 {
+			encoded += "&" + signature.Encode()
+		}
+	}
 
 	// 发送请求
-	req, err := http.NewRequest("GET", apiURL, nil)
+	req, err := http.NewRequest("GET", apiURL+"?"+encoded, nil)
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %v", err)
+		return fmt.Errorf("创建请求失败: %v", err)
 	}
 
 	// 设置请求头
-	req.Header.Set("User-Agent", s.userAgent)
-	req.Header.Set("Cookie", s.cookies)
-	req.Header.Set("Referer", "https://www.douyin.com/")
+	setHeaders(req, Douyin, s.userAgent, cookies)
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态码
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: 状态码 %d", ErrNotFound, resp.StatusCode)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API请求失败，状态码: %d", resp.StatusCode)
+		return fmt.Errorf("API请求失败，状态码: %d", resp.StatusCode)
 	}
 
 	// 读取响应内容
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("读取响应内容失败: %v", err)
+		return fmt.Errorf("读取响应内容失败: %v", err)
+	}
+
+	// 签名无效时接口返回空内容
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return errors.New("API返回空内容，签名或设备指纹可能已失效")
+	}
+
+	// 状态码 8 表示未登录或登录已失效
+	var status struct {
+		StatusCode int    `json:"status_code"`
+		StatusMsg  string `json:"status_msg"`
+	}
+	if json.Unmarshal(body, &status) == nil && status.StatusCode == 8 {
+		return fmt.Errorf("%w: 抖音接口返回 %d %s", ErrAuthExpired, status.StatusCode, status.StatusMsg)
 	}
 
 	// 解析JSON响应
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析JSON响应失败: %v", err)
+	}
+	return nil
+}
+
+// GetUserInfo 获取用户信息
+func (s *DouyinScraper) GetUserInfo(userID string) (*UserData, error) {
 	var result struct {
 		UserInfo   UserData `json:"user_info"`
 		StatusCode int      `json:"status_code"`
 		StatusMsg  string   `json:"status_msg"`
 	}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("解析JSON响应失败: %v", err)
+	params := url.Values{"user_id": {userID}}
+	if err := s.request("https://www.douyin.com/aweme/v1/web/user/profile/other/", params, "", &result); err != nil {
+		return nil, err
 	}
 
 	// 检查API响应状态
@@ -103,42 +248,43 @@ func (s *DouyinScraper) GetUserInfo(userID string) (*UserData, error) {
 	return &result.UserInfo, nil
 }
 
-// GetUserVideos 获取用户视频列表
-func (s *DouyinScraper) GetUserVideos(userID string, cursor string) ([]*VideoData, string, error) {
-	// 构建API请求URL
-	apiURL := fmt.Sprintf("https://www.douyin.com/aweme/v1/web/aweme/post/?user_id=%s&count=20&cursor=%s",
-		url.QueryEscape(userID), cursor)
-
-	// 发送请求
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return nil, "", err
-	}
+// GetFollowers 获取用户的粉丝列表
+func (s *DouyinScraper) GetFollowers(userID string, cursor string) ([]*UserData, string, error) {
+	return s.getUserRelations("follower", userID, cursor)
+}
 
-	// 设置请求头
-	req.Header.Set("User-Agent", s.userAgent)
-	req.Header.Set("Cookie", s.cookies)
-	req.Header.Set("Referer", "https://www.douyin.com/")
+// GetFollowing 获取用户的关注列表
+func (s *DouyinScraper) GetFollowing(userID string, cursor string) ([]*UserData, string, error) {
+	return s.getUserRelations("following", userID, cursor)
+}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, "", err
+// getUserRelations 获取用户关系列表，relation 为 follower 或 following
+func (s *DouyinScraper) getUserRelations(relation, userID, cursor string) ([]*UserData, string, error) {
+	// 粉丝和关注列表的字段名不同
+	var result struct {
+		Followers  []*UserData `json:"followers"`
+		Followings []*UserData `json:"followings"`
+		HasMore    int         `json:"has_more"`
+		MinTime    string      `json:"min_time"`
 	}
-	defer resp.Body.Close()
-
-	// 读取响应内容
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
+	params := url.Values{"user_id": {userID}, "max_time": {cursor}, "count": {"20"}}
+	apiURL := fmt.Sprintf("https://www.douyin.com/aweme/v1/web/user/%s/list/", relation)
+	if err := s.request(apiURL, params, "", &result); err != nil {
 		return nil, "", err
 	}
 
-	// 解析JSON响应
+	return append(result.Followers, result.Followings...), result.MinTime, nil
+}
+
+// GetUserVideos 获取用户视频列表
+func (s *DouyinScraper) GetUserVideos(userID string, cursor string) ([]*VideoData, string, error) {
 	var result struct {
 		AwemeList []*VideoData `json:"aweme_list"`
 		HasMore   int          `json:"has_more"`
 		Cursor    string       `json:"cursor"`
 	}
-	if err := json.Unmarshal(body, &result); err != nil {
+	params := url.Values{"user_id": {userID}, "count": {"20"}, "cursor": {cursor}}
+	if err := s.request("https://www.douyin.com/aweme/v1/web/aweme/post/", params, "", &result); err != nil {
 		return nil, "", err
 	}
 
@@ -147,82 +293,211 @@ func (s *DouyinScraper) GetUserVideos(userID string, cursor string) ([]*VideoDat
 
 // GetVideoComments 获取视频评论
 func (s *DouyinScraper) GetVideoComments(videoID string, cursor string) ([]*CommentData, string, error) {
-	// 构建API请求URL
-	apiURL := fmt.Sprintf("https://www.douyin.com/aweme/v2/web/comment/list/?aweme_id=%s&cursor=%s&count=20",
-		url.QueryEscape(videoID), cursor)
-
-	// 发送请求
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return nil, "", err
+	var result struct {
+		Comments []*CommentData `json:"comments"`
+		HasMore  int            `json:"has_more"`
+		Cursor   string         `json:"cursor"`
 	}
-
-	// 设置请求头
-	req.Header.Set("User-Agent", s.userAgent)
-	req.Header.Set("Cookie", s.cookies)
-	req.Header.Set("Referer", "https://www.douyin.com/")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
+	params := url.Values{"aweme_id": {videoID}, "cursor": {cursor}, "count": {"20"}}
+	if err := s.request("https://www.douyin.com/aweme/v2/web/comment/list/", params, "", &result); err != nil {
 		return nil, "", err
 	}
-	defer resp.Body.Close()
 
-	// 读取响应内容
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, "", err
-	}
+	return result.Comments, result.Cursor, nil
+}
 
-	// 解析JSON响应
+// GetCommentReplies 获取评论的回复列表
+func (s *DouyinScraper) GetCommentReplies(videoID string, commentID string, cursor string) ([]*CommentData, string, error) {
 	var result struct {
 		Comments []*CommentData `json:"comments"`
 		HasMore  int            `json:"has_more"`
 		Cursor   string         `json:"cursor"`
 	}
-	if err := json.Unmarshal(body, &result); err != nil {
+	params := url.Values{"item_id": {videoID}, "comment_id": {commentID}, "cursor": {cursor}, "count": {"20"}}
+	if err := s.request("https://www.douyin.com/aweme/v1/web/comment/list/reply/", params, "", &result); err != nil {
 		return nil, "", err
 	}
 
+	// 补全回复所属的视频和一级评论
+	for _, reply := range result.Comments {
+		reply.VideoID = videoID
+		reply.ParentID = commentID
+	}
+
 	return result.Comments, result.Cursor, nil
 }
 
 // GetProductInfo 获取商品信息
 func (s *DouyinScraper) GetProductInfo(productID string) (*ProductInfo, error) {
-	// 构建API请求URL
-	apiURL := fmt.Sprintf("https://www.douyin.com/aweme/v1/web/promotion/product/detail/?product_id=%s",
-		url.QueryEscape(productID))
-
-	// 发送请求
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
+	var result struct {
+		ProductInfo struct {
+			ProductInfo
+			Status *int `json:"status"` // 商品状态：0 在售，1 下架，2 已删除
+		} `json:"product_info"`
+	}
+	params := url.Values{"product_id": {productID}}
+	if err := s.request("https://www.douyin.com/aweme/v1/web/promotion/product/detail/", params, "", &result); err != nil {
 		return nil, err
 	}
 
-	// 设置请求头
-	req.Header.Set("User-Agent", s.userAgent)
-	req.Header.Set("Cookie", s.cookies)
-	req.Header.Set("Referer", "https://www.douyin.com/")
+	// 已删除的商品返回空的商品信息
+	product := &result.ProductInfo.ProductInfo
+	if product.ProductID == "" && product.Name == "" {
+		return nil, fmt.Errorf("%w: 抖音商品 %s", ErrNotFound, productID)
+	}
+	if status := result.ProductInfo.Status; status != nil && *status != 0 {
+		product.OffShelf = true
+	}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
+	product.FillPriceRange()
+	return product, nil
+}
+
+// GetProductReviews 获取商品评价
+func (s *DouyinScraper) GetProductReviews(productID string, cursor string) ([]*ProductReview, string, error) {
+	var result struct {
+		Data struct {
+			Comments []*ProductReview `json:"comments"`
+			HasMore  int              `json:"has_more"`
+			Cursor   string           `json:"cursor"`
+		} `json:"data"`
+	}
+	params := url.Values{"product_id": {productID}, "cursor": {cursor}, "page_size": {"20"}}
+	if err := s.request("https://ec.snssdk.com/product/comment/list", params, "https://haohuo.jinritemai.com/", &result); err != nil {
+		return nil, "", err
+	}
+
+	// 补全评价所属商品
+	for _, review := range result.Data.Comments {
+		review.ProductID = productID
+	}
+
+	return result.Data.Comments, result.Data.Cursor, nil
+}
+
+// GetShopInfo 获取店铺信息
+func (s *DouyinScraper) GetShopInfo(shopID string) (*ShopData, error) {
+	var result struct {
+		Data struct {
+			ShopID      string  `json:"shop_id"`
+			ShopName    string  `json:"shop_name"`
+			ShopScore   float64 `json:"shop_score"`
+			FansCount   int     `json:"fans_count"`
+			MainCate    string  `json:"main_cate"`
+			ShopAddress string  `json:"shop_address"`
+		} `json:"data"`
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	params := url.Values{"shop_id": {shopID}}
+	if err := s.request("https://ec.snssdk.com/shop/shop_info", params, "https://haohuo.jinritemai.com/", &result); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	// 读取响应内容
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
+	// 检查API响应状态
+	if result.Code != 0 {
+		return nil, fmt.Errorf("API返回错误: %s", result.Msg)
+	}
+
+	return &ShopData{
+		ShopID:    shopID,
+		Name:      result.Data.ShopName,
+		Rating:    result.Data.ShopScore,
+		Followers: result.Data.FansCount,
+		Category:  result.Data.MainCate,
+		Location:  result.Data.ShopAddress,
+	}, nil
+}
+
+// GetShopProducts 获取店铺的全部商品
+func (s *DouyinScraper) GetShopProducts(shopID string, cursor string) ([]*ProductInfo, string, error) {
+	var result struct {
+		Data struct {
+			List    []*ProductInfo `json:"list"`
+			HasMore int            `json:"has_more"`
+			Cursor  string         `json:"cursor"`
+		} `json:"data"`
+	}
+	params := url.Values{"shop_id": {shopID}, "cursor": {cursor}, "size": {"20"}}
+	if err := s.request("https://ec.snssdk.com/shop/goods/list", params, "https://haohuo.jinritemai.com/", &result); err != nil {
+		return nil, "", err
+	}
+
+	// 补全商品所属店铺
+	for _, product := range result.Data.List {
+		product.ShopID = shopID
+	}
+
+	return result.Data.List, result.Data.Cursor, nil
+}
+
+// GetLiveRoom 获取用户当前的直播间信息
+func (s *DouyinScraper) GetLiveRoom(userID string) (*LiveRoom, error) {
+	var result struct {
+		Data struct {
+			Room struct {
+				IDStr      string `json:"id_str"`
+				Title      string `json:"title"`
+				Status     int    `json:"status"`
+				UserCount  int    `json:"user_count"`
+				CreateTime int64  `json:"create_time"`
+				Stats      struct {
+					TotalUser int `json:"total_user"`
+					LikeCount int `json:"like_count"`
+				} `json:"stats"`
+			} `json:"room"`
+		} `json:"data"`
+		StatusCode int `json:"status_code"`
+	}
+	params := url.Values{"user_id": {userID}}
+	if err := s.request("https://live.douyin.com/webcast/room/info_by_user/", params, "https://live.douyin.com/", &result); err != nil {
 		return nil, err
 	}
 
-	// 解析JSON响应
+	// 检查API响应状态
+	if result.StatusCode != 0 {
+		return nil, fmt.Errorf("API返回错误，状态码: %d", result.StatusCode)
+	}
+
+	// 直播间状态为2表示正在直播
+	room := result.Data.Room
+	return &LiveRoom{
+		RoomID:       room.IDStr,
+		UserID:       userID,
+		Title:        room.Title,
+		IsLive:       room.Status == 2,
+		ViewerCount:  room.UserCount,
+		TotalViewers: room.Stats.TotalUser,
+		Likes:        room.Stats.LikeCount,
+		StartTime:    room.CreateTime,
+	}, nil
+}
+
+// GetLiveProducts 获取直播间购物车中的商品
+func (s *DouyinScraper) GetLiveProducts(roomID string) ([]*ProductInfo, error) {
+	// 价格单位为分
 	var result struct {
-		ProductInfo ProductInfo `json:"product_info"`
+		Promotions []struct {
+			ProductID string `json:"product_id"`
+			Title     string `json:"title"`
+			Price     int    `json:"price"`
+			Sales     int    `json:"sales"`
+		} `json:"promotions"`
 	}
-	if err := json.Unmarshal(body, &result); err != nil {
+	params := url.Values{"room_id": {roomID}}
+	if err := s.request("https://live.douyin.com/webcast/ecom/room/promotions/", params, "https://live.douyin.com/", &result); err != nil {
 		return nil, err
 	}
 
-	return &result.ProductInfo, nil
+	products := make([]*ProductInfo, 0, len(result.Promotions))
+	for _, promotion := range result.Promotions {
+		products = append(products, &ProductInfo{
+			ProductID: promotion.ProductID,
+			Name:      promotion.Title,
+			Price:     float64(promotion.Price) / 100,
+			Sales:     promotion.Sales,
+		})
+	}
+
+	return products, nil
 }
@@ -0,0 +1,358 @@
+package crawler
+
+import (
+	"Crawler/utils/browserpool"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rc4"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// DouyinSigner 抖音请求签名器，为 Web 接口的查询字符串生成 X-Bogus 或 a_bogus 签名参数。
+// 签名算法随网页端版本变化，可使用纯 Go 实现或在浏览器中执行网页端脚本
+type DouyinSigner interface {
+	// Sign 返回需要追加到查询字符串的签名参数
+	Sign(query, userAgent string) (url.Values, error)
+}
+
+// XBogusSigner 纯 Go 实现的 X-Bogus 签名
+type XBogusSigner struct{}
+
+// X-Bogus 编码使用的自定义 Base64 字母表
+const xBogusAlphabet = "Dkdpgh4ZKsQB80/Mfvw36XI1R25-WUAlEi7NLboqYTOPuzmFjJnryx9HVGcaStCe="
+
+// Sign 计算 X-Bogus 签名
+func (XBogusSigner) Sign(query, userAgent string) (url.Values, error) {
+	return url.Values{"X-Bogus": {xBogus(query, userAgent, time.Now())}}, nil
+}
+
+// xBogus 计算 X-Bogus：查询字符串、空请求体和 UA 的摘要末两字节与时间戳、固定常量拼接，
+// 按奇偶位重排后经 RC4 加密，再用自定义字母表编码
+func xBogus(query, userAgent string, now time.Time) string {
+	// UA 经 RC4 加密并 Base64 编码后取 MD5
+	uaCipher := rc4Encrypt([]byte{0, 1, 12}, []byte(userAgent))
+	uaHash := md5.Sum([]byte(base64.StdEncoding.EncodeToString(uaCipher)))
+
+	// 空请求体和查询字符串各取两次 MD5
+	emptyHash := md5.Sum([]byte{})
+	bodyHash := md5.Sum(emptyHash[:])
+	queryHash := md5.Sum([]byte(query))
+	queryHash = md5.Sum(queryHash[:])
+
+	timestamp := uint32(now.Unix())
+	canvas := uint32(536919696)
+	values := []byte{
+		64, 0, 1, 12,
+		queryHash[14], queryHash[15],
+		bodyHash[14], bodyHash[15],
+		uaHash[14], uaHash[15],
+		byte(timestamp >> 24), byte(timestamp >> 16), byte(timestamp >> 8), byte(timestamp),
+		byte(canvas >> 24), byte(canvas >> 16), byte(canvas >> 8), byte(canvas),
+	}
+	var checksum byte
+	for _, value := range values {
+		checksum ^= value
+	}
+	values = append(values, checksum)
+
+	// 先取偶数位再取奇数位，然后按网页端的顺序交错排列
+	var merged []byte
+	for i := 0; i < len(values); i += 2 {
+		merged = append(merged, values[i])
+	}
+	for i := 1; i < len(values); i += 2 {
+		merged = append(merged, values[i])
+	}
+	order := []int{0, 10, 1, 11, 2, 12, 3, 13, 4, 14, 5, 15, 6, 16, 7, 17, 8, 18, 9}
+	shuffled := make([]byte, 0, len(order))
+	for _, index := range order {
+		shuffled = append(shuffled, merged[index])
+	}
+
+	garbled := append([]byte{2, 255}, rc4Encrypt([]byte{255}, shuffled)...)
+	return customBase64(garbled, xBogusAlphabet, '=')
+}
+
+// rc4Encrypt 使用 RC4 加密数据
+func rc4Encrypt(key, data []byte) []byte {
+	cipher, _ := rc4.NewCipher(key)
+	out := make([]byte, len(data))
+	cipher.XORKeyStream(out, data)
+	return out
+}
+
+// BrowserSignerConfig 浏览器签名器配置
+type BrowserSignerConfig struct {
+	PageURL   string // 加载签名脚本的页面，默认为抖音首页
+	Script    string // 计算签名的 JS 函数，参数为查询字符串和 UA，返回签名参数对象
+	UserAgent string // 浏览器 UA，需与请求使用的 UA 一致
+	Headless  bool
+	Pool      *browserpool.Pool // 共用的浏览器池，为空时使用独立的浏览器
+}
+
+// 默认使用网页端 acrawler 脚本计算 X-Bogus，a_bogus 等新版签名可通过 Script 替换
+const defaultSignScript = `(query, userAgent) => window.byted_acrawler.frontierSign(query)`
+
+// BrowserSigner 在浏览器池租用的页面中执行网页端签名脚本，页面在首次签名时打开并一直持有
+type BrowserSigner struct {
+	config  BrowserSignerConfig
+	pool    *browserpool.Pool
+	ownPool bool
+	tab     *browserpool.Tab
+	mutex   sync.Mutex
+}
+
+// NewBrowserSigner 创建浏览器签名器
+func NewBrowserSigner(config BrowserSignerConfig) *BrowserSigner {
+	if config.PageURL == "" {
+		config.PageURL = "https://www.douyin.com/"
+	}
+	if config.Script == "" {
+		config.Script = defaultSignScript
+	}
+
+	signer := &BrowserSigner{config: config, pool: config.Pool}
+	if signer.pool == nil {
+		signer.pool = browserpool.New(browserpool.Config{Size: 1, TabsPerBrowser: 1, Headless: config.Headless})
+		signer.ownPool = true
+	}
+	return signer
+}
+
+// start 租用页面并加载签名页面
+func (b *BrowserSigner) start() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	tab, err := b.pool.Acquire(ctx, browserpool.TabOptions{UserAgent: b.config.UserAgent})
+	if err != nil {
+		return fmt.Errorf("租用浏览器页面失败: %v", err)
+	}
+
+	// 等待页面中的签名脚本加载完成
+	loadCtx, cancelLoad := context.WithTimeout(tab.Context(), 60*time.Second)
+	defer cancelLoad()
+	if err := chromedp.Run(loadCtx, chromedp.Navigate(b.config.PageURL), chromedp.Sleep(5*time.Second)); err != nil {
+		tab.Discard()
+		return fmt.Errorf("加载签名页面失败: %v", err)
+	}
+
+	b.tab = tab
+	return nil
+}
+
+// Sign 在浏览器中执行签名脚本
+func (b *BrowserSigner) Sign(query, userAgent string) (url.Values, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.tab == nil {
+		if err := b.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	arguments, _ := json.Marshal([]string{query, userAgent})
+	expression := fmt.Sprintf("(%s)(...%s)", b.config.Script, arguments)
+
+	ctx, cancel := context.WithTimeout(b.tab.Context(), 10*time.Second)
+	defer cancel()
+
+	var result map[string]string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(expression, &result)); err != nil {
+		// 页面可能随浏览器重启失效，下次签名时重新打开
+		b.tab.Discard()
+		b.tab = nil
+		return nil, fmt.Errorf("执行签名脚本失败: %v", err)
+	}
+	if len(result) == 0 {
+		return nil, errors.New("签名脚本未返回签名参数")
+	}
+
+	params := url.Values{}
+	for key, value := range result {
+		params.Set(key, value)
+	}
+	return params, nil
+}
+
+// Close 归还签名页面，使用独立浏览器时关闭浏览器
+func (b *BrowserSigner) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.tab != nil {
+		b.tab.Discard()
+		b.tab = nil
+	}
+	if b.ownPool {
+		b.pool.Close()
+	}
+	return nil
+}
+
+// douyinDevice 抖音 Web 端的设备指纹，请求时分别以 Cookie 和查询参数携带
+type douyinDevice struct {
+	MsToken  string
+	Ttwid    string
+	WebID    string
+	VerifyFp string // 同时作为 Cookie s_v_web_id 和查询参数 verifyFp、fp
+}
+
+// cookies 将设备指纹合并到 Cookie 中，用户提供的同名 Cookie 优先
+func (d *douyinDevice) cookies(base string) string {
+	for name, value := range map[string]string{
+		"msToken":    d.MsToken,
+		"ttwid":      d.Ttwid,
+		"s_v_web_id": d.VerifyFp,
+	} {
+		if value != "" && cookieValue(base, name) == "" {
+			base = setCookieValue(base, name, value)
+		}
+	}
+	return base
+}
+
+// params 设备指纹对应的查询参数，未获取到的 webid 不携带
+func (d *douyinDevice) params() url.Values {
+	params := url.Values{
+		"msToken":  {d.MsToken},
+		"verifyFp": {d.VerifyFp},
+		"fp":       {d.VerifyFp},
+	}
+	if d.WebID != "" {
+		params.Set("webid", d.WebID)
+	}
+	return params
+}
+
+// newDouyinDevice 生成设备指纹，Cookie 中已有的值直接复用，ttwid 和 webid 需要向注册接口申请。
+// 注册接口不可用时仅记录警告，请求只携带用户 Cookie 中已有的指纹
+func newDouyinDevice(client *http.Client, userAgent, cookies string) *douyinDevice {
+	device := &douyinDevice{
+		MsToken:  cookieValue(cookies, "msToken"),
+		Ttwid:    cookieValue(cookies, "ttwid"),
+		VerifyFp: cookieValue(cookies, "s_v_web_id"),
+	}
+	if device.MsToken == "" {
+		device.MsToken = randomString(107, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789")
+	}
+	if device.VerifyFp == "" {
+		device.VerifyFp = generateVerifyFp(time.Now())
+	}
+
+	var err error
+	if device.Ttwid == "" {
+		if device.Ttwid, err = registerTtwid(client, userAgent); err != nil {
+			log.Printf("警告: 获取抖音 ttwid 失败，仅使用 Cookie 中的设备信息: %v", err)
+		}
+	}
+	if device.WebID, err = registerWebID(client, userAgent); err != nil {
+		log.Printf("警告: 获取抖音 webid 失败，请求将不携带 webid: %v", err)
+	}
+
+	return device
+}
+
+// registerTtwid 向 ttwid 注册接口申请 ttwid，结果在响应的 Set-Cookie 中
+func registerTtwid(client *http.Client, userAgent string) (string, error) {
+	payload := `{"region":"cn","aid":1768,"needFid":false,"service":"www.ixigua.com","migrate_info":{"ticket":"","source":"node"},"cbUrlProtocol":"https","union":true}`
+	req, err := http.NewRequest("POST", "https://ttwid.bytedance.com/ttwid/union/register/", strings.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "ttwid" {
+			return cookie.Value, nil
+		}
+	}
+	return "", fmt.Errorf("响应中没有 ttwid，状态码: %d", resp.StatusCode)
+}
+
+// registerWebID 向 webid 注册接口申请 webid
+func registerWebID(client *http.Client, userAgent string) (string, error) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"app_id":         6383,
+		"url":            "https://www.douyin.com/",
+		"user_agent":     userAgent,
+		"referer":        "https://www.douyin.com/",
+		"user_unique_id": "",
+	})
+	req, err := http.NewRequest("POST", "https://mcs.zijieapi.com/webid", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		WebID string `json:"web_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.WebID == "" {
+		return "", fmt.Errorf("响应中没有 web_id，状态码: %d", resp.StatusCode)
+	}
+	return result.WebID, nil
+}
+
+// generateVerifyFp 按网页端规则生成 verifyFp：verify_ 加毫秒时间戳的 36 进制，再加 UUID 形式的随机串
+func generateVerifyFp(now time.Time) string {
+	const chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	random := make([]byte, 36)
+	for i := range random {
+		switch i {
+		case 8, 13, 18, 23:
+			random[i] = '_'
+		case 14:
+			random[i] = '4'
+		default:
+			n := rand.Intn(len(chars))
+			if i == 19 {
+				n = n&3 | 8
+			}
+			random[i] = chars[n]
+		}
+	}
+	return "verify_" + strconv.FormatInt(now.UnixMilli(), 36) + "_" + string(random)
+}
+
+// randomString 生成指定长度的随机字符串
+func randomString(length int, chars string) string {
+	result := make([]byte, length)
+	for i := range result {
+		result[i] = chars[rand.Intn(len(chars))]
+	}
+	return string(result)
+}
@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+const testDouyinUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36"
+
+func TestXBogus(t *testing.T) {
+	const query = "aweme_id=7372484719365098802&aid=6383"
+	now := time.Unix(1716600000, 0)
+
+	got := xBogus(query, testDouyinUserAgent, now)
+	if want := "DFSzswVY1giANc2EtA1VPe9WX7nk"; got != want {
+		t.Errorf("xBogus = %q，期望 %q", got, want)
+	}
+
+	// 解码并解密后还原出查询字符串摘要、时间戳和校验位
+	encoding := base64.NewEncoding(xBogusAlphabet[:64]).WithPadding(rune(xBogusAlphabet[64]))
+	garbled, err := encoding.DecodeString(got)
+	if err != nil {
+		t.Fatalf("解码 X-Bogus 失败: %v", err)
+	}
+	if len(garbled) != 21 || garbled[0] != 2 || garbled[1] != 255 {
+		t.Fatalf("X-Bogus 解码后为 %v，期望以 2 255 开头的 21 字节", garbled)
+	}
+	shuffled := rc4Encrypt([]byte{255}, garbled[2:])
+
+	order := []int{0, 10, 1, 11, 2, 12, 3, 13, 4, 14, 5, 15, 6, 16, 7, 17, 8, 18, 9}
+	merged := make([]byte, len(order))
+	for i, index := range order {
+		merged[index] = shuffled[i]
+	}
+	values := make([]byte, len(merged))
+	for i := range merged {
+		if i < 10 {
+			values[i*2] = merged[i]
+		} else {
+			values[(i-10)*2+1] = merged[i]
+		}
+	}
+
+	queryHash := md5.Sum([]byte(query))
+	queryHash = md5.Sum(queryHash[:])
+	if values[0] != 64 || values[3] != 12 || values[4] != queryHash[14] || values[5] != queryHash[15] {
+		t.Errorf("X-Bogus 头部或查询字符串摘要不匹配: %v", values[:6])
+	}
+	timestamp := uint32(values[10])<<24 | uint32(values[11])<<16 | uint32(values[12])<<8 | uint32(values[13])
+	if int64(timestamp) != now.Unix() {
+		t.Errorf("X-Bogus 中的时间戳 %d，期望 %d", timestamp, now.Unix())
+	}
+	var checksum byte
+	for _, value := range values[:18] {
+		checksum ^= value
+	}
+	if checksum != values[18] {
+		t.Errorf("校验位 %d，期望 %d", values[18], checksum)
+	}
+
+	// 查询字符串变化时签名随之变化
+	if xBogus(query+"&cursor=1", testDouyinUserAgent, now) == got {
+		t.Error("不同查询字符串的签名不应相同")
+	}
+}
+
+func TestNewDouyinDeviceFallback(t *testing.T) {
+	// 注册接口全部返回 404 时不中断初始化，仅使用 Cookie 中已有的指纹
+	server := newFixtureServer(t, nil)
+
+	device := newDouyinDevice(server.client(), testDouyinUserAgent, "sessionid=abc; msToken=token1")
+	if device.Ttwid != "" || device.WebID != "" {
+		t.Errorf("注册失败时 ttwid %q webid %q，期望为空", device.Ttwid, device.WebID)
+	}
+	if device.MsToken != "token1" || device.VerifyFp == "" {
+		t.Errorf("msToken %q verifyFp %q，期望复用 Cookie 并生成 verifyFp", device.MsToken, device.VerifyFp)
+	}
+	if _, ok := device.params()["webid"]; ok {
+		t.Error("未获取到 webid 时不应携带 webid 参数")
+	}
+	if cookies := device.cookies("sessionid=abc"); cookieValue(cookies, "ttwid") != "" {
+		t.Errorf("未获取到 ttwid 时 Cookie 为 %q，不应包含 ttwid", cookies)
+	}
+	if server.request("/ttwid/union/register/") == nil || server.request("/webid") == nil {
+		t.Error("Cookie 中没有 ttwid 时应请求注册接口")
+	}
+}
+
+func TestDouyinRequestParams(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{
+		"/aweme/v1/web/user/profile/other/": "douyin_user_profile.json",
+		"/shop/shop_info":                   "douyin_shop_info.json",
+	})
+	s := NewDouyinScraper(testDouyinUserAgent, "sessionid=abc")
+	s.client = server.client()
+	s.device = &douyinDevice{MsToken: "token1", Ttwid: "ttwid1", WebID: "7300000000000000000", VerifyFp: "verify_test"}
+
+	user, err := s.GetUserInfo("MS4wLjABAAAAtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Nickname != "砀山梨园" || user.Followers != 8600 {
+		t.Errorf("用户信息 %+v", user)
+	}
+
+	// Web 端接口携带公共参数、设备指纹和签名
+	web := server.request("/aweme/v1/web/user/profile/other/")
+	query := web.URL.Query()
+	for _, key := range []string{"aid", "device_platform", "webid", "msToken", "verifyFp", "X-Bogus"} {
+		if query.Get(key) == "" {
+			t.Errorf("Web 端接口缺少参数 %s", key)
+		}
+	}
+	if cookieValue(web.Header.Get("Cookie"), "ttwid") != "ttwid1" {
+		t.Errorf("Cookie %q 缺少设备指纹", web.Header.Get("Cookie"))
+	}
+
+	shop, err := s.GetShopInfo("12345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shop.Name != "砀山梨园直营店" || shop.Rating != 4.7 || shop.Location != "安徽宿州" {
+		t.Errorf("店铺信息 %+v", shop)
+	}
+
+	// 电商接口只携带接口参数
+	ec := server.request("/shop/shop_info")
+	if raw := ec.URL.RawQuery; raw != "shop_id=12345" {
+		t.Errorf("电商接口查询参数 %q，期望只有 shop_id", raw)
+	}
+	if ec.Header.Get("Referer") != "https://haohuo.jinritemai.com/" {
+		t.Errorf("电商接口 Referer %q", ec.Header.Get("Referer"))
+	}
+}
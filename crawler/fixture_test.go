@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// fixtureServer 按请求路径返回 testdata 中响应文件的测试服务器，记录收到的请求
+type fixtureServer struct {
+	*httptest.Server
+	routes   map[string]string // 请求路径到 testdata 文件名
+	requests []*http.Request
+	mutex    sync.Mutex
+}
+
+// newFixtureServer 创建测试服务器，未配置的路径返回 404
+func newFixtureServer(t *testing.T, routes map[string]string) *fixtureServer {
+	t.Helper()
+
+	s := &fixtureServer{routes: routes}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		s.requests = append(s.requests, r)
+		s.mutex.Unlock()
+
+		file, ok := s.routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Errorf("读取测试数据 %s 失败: %v", file, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// client 返回将全部请求转发到测试服务器的 HTTP 客户端，保留原请求的路径、查询参数和请求头
+func (s *fixtureServer) client() *http.Client {
+	target, _ := url.Parse(s.URL)
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.URL.Scheme = target.Scheme
+		req.URL.Host = target.Host
+		req.Host = target.Host
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+}
+
+// request 返回最后一个请求路径为 path 的请求
+func (s *fixtureServer) request(path string) *http.Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := len(s.requests) - 1; i >= 0; i-- {
+		if s.requests[i].URL.Path == path {
+			return s.requests[i]
+		}
+	}
+	return nil
+}
+
+// roundTripFunc 函数形式的 http.RoundTripper
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -18,17 +18,43 @@ type KuaishouScraper struct {
 	cookies   string
 }
 
-// NewKuaishouScraper 创建快手爬虫实例
+func init() {
+	Register(PlatformInfo{
+		Name:         Kuaishou,
+		DisplayName:  "快手",
+		LoginURL:     "https://www.kuaishou.com/",
+		LoginCookies: []string{"kuaishou.server.web_st"},
+		Headers:      map[string]string{"Referer": "https://www.kuaishou.com/"},
+		Capabilities: []Capability{CapabilityReplies, CapabilityLive},
+		New: func(userAgent, cookies string) Scraper {
+			return NewKuaishouScraper(userAgent, cookies)
+		},
+		BrowserRoutes: []BrowserRoute{
+			{Path: "/graphql", Operation: "visionProfile", Page: "https://www.kuaishou.com/profile/{userId}"},
+			{Path: "/graphql", Operation: "visionProfilePhotoList", Page: "https://www.kuaishou.com/profile/{userId}", Cursor: "pcursor"},
+			{Path: "/graphql", Operation: "photoCommentList", Page: "https://www.kuaishou.com/short-video/{photoId}", Cursor: "pcursor"},
+			{Path: "/graphql", Operation: "productInfo", Page: "https://app.kwaixiaodian.com/page/kwaixiaodian/goods/detail?id={productId}"},
+		},
+	})
+}
+
+// NewKuaishouScraper 创建快手爬虫实例，接口触发风控验证时返回 *ChallengeError
 func NewKuaishouScraper(userAgent, cookies string) *KuaishouScraper {
 	return &KuaishouScraper{
 		client: &http.Client{
-			Timeout: time.Second * 30,
+			Timeout:   time.Second * 30,
+			Transport: newChallengeTransport(Kuaishou, "graphql", "/rest/"),
 		},
 		userAgent: userAgent,
 		cookies:   cookies,
 	}
 }
 
+// httpClient 返回爬虫使用的 HTTP 客户端，浏览器后端通过它接管请求
+func (s *KuaishouScraper) httpClient() *http.Client {
+	return s.client
+}
+
 // Initialize 初始化爬虫
 func (s *KuaishouScraper) Initialize() error {
 	// 验证cookies是否有效
@@ -37,8 +63,7 @@ func (s *KuaishouScraper) Initialize() error {
 		return err
 	}
 
-	req.Header.Set("User-Agent", s.userAgent)
-	req.Header.Set("Cookie", s.cookies)
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -88,10 +113,8 @@ func (s *KuaishouScraper) GetUserInfo(userID string) (*UserData, error) {
 	}
 
 	// 设置请求头
-	req.Header.Set("User-Agent", s.userAgent)
-	req.Header.Set("Cookie", s.cookies)
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Referer", "https://www.kuaishou.com/")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -120,6 +143,82 @@ func (s *KuaishouScraper) GetUserInfo(userID string) (*UserData, error) {
 	return &result.Data.VisionProfile.User, nil
 }
 
+// GetFollowers 获取用户的粉丝列表
+func (s *KuaishouScraper) GetFollowers(userID string, cursor string) ([]*UserData, string, error) {
+	return s.getUserRelations(2, userID, cursor)
+}
+
+// GetFollowing 获取用户的关注列表
+func (s *KuaishouScraper) GetFollowing(userID string, cursor string) ([]*UserData, string, error) {
+	return s.getUserRelations(1, userID, cursor)
+}
+
+// getUserRelations 获取用户关系列表，ftype 为 1 表示关注，2 表示粉丝
+func (s *KuaishouScraper) getUserRelations(ftype int, userID, cursor string) ([]*UserData, string, error) {
+	// 构建API请求URL
+	apiURL := "https://www.kuaishou.com/graphql"
+
+	// 构建GraphQL查询
+	query := fmt.Sprintf(`{
+		visionProfileUserList(userId: "%s", pcursor: "%s", ftype: %d) {
+			pcursor
+			fols {
+				id
+				name
+				followersCount
+				followingCount
+				description
+			}
+		}
+	}`, userID, cursor, ftype)
+
+	// 构建请求体
+	requestBody := map[string]interface{}{
+		"query": query,
+	}
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 发送请求
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 设置请求头
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应内容
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 解析JSON响应
+	var result struct {
+		Data struct {
+			VisionProfileUserList struct {
+				Pcursor string      `json:"pcursor"`
+				Fols    []*UserData `json:"fols"`
+			} `json:"visionProfileUserList"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, "", err
+	}
+
+	return result.Data.VisionProfileUserList.Fols, result.Data.VisionProfileUserList.Pcursor, nil
+}
+
 // GetUserVideos 获取用户视频列表
 func (s *KuaishouScraper) GetUserVideos(userID string, cursor string) ([]*VideoData, string, error) {
 	// 构建API请求URL
@@ -164,10 +263,8 @@ func (s *KuaishouScraper) GetUserVideos(userID string, cursor string) ([]*VideoD
 	}
 
 	// 设置请求头
-	req.Header.Set("User-Agent", s.userAgent)
-	req.Header.Set("Cookie", s.cookies)
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Referer", "https://www.kuaishou.com/")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -234,10 +331,8 @@ func (s *KuaishouScraper) GetVideoComments(videoID string, cursor string) ([]*Co
 	}
 
 	// 设置请求头
-	req.Header.Set("User-Agent", s.userAgent)
-	req.Header.Set("Cookie", s.cookies)
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Referer", "https://www.kuaishou.com/")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -267,6 +362,81 @@ func (s *KuaishouScraper) GetVideoComments(videoID string, cursor string) ([]*Co
 	return result.Data.PhotoCommentList.Comments, result.Data.PhotoCommentList.Pcursor, nil
 }
 
+// GetCommentReplies 获取评论的回复列表
+func (s *KuaishouScraper) GetCommentReplies(videoID string, commentID string, cursor string) ([]*CommentData, string, error) {
+	// 构建API请求URL
+	apiURL := "https://www.kuaishou.com/graphql"
+
+	// 构建GraphQL查询
+	query := fmt.Sprintf(`{
+		visionSubCommentList(photoId: "%s", rootCommentId: "%s", pcursor: "%s") {
+			pcursor
+			subComments {
+				id
+				photoId
+				authorId
+				replyToUserId
+				content
+				likeCount
+				createTime
+			}
+		}
+	}`, videoID, commentID, cursor)
+
+	// 构建请求体
+	requestBody := map[string]interface{}{
+		"query": query,
+	}
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 发送请求
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 设置请求头
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应内容
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 解析JSON响应
+	var result struct {
+		Data struct {
+			VisionSubCommentList struct {
+				Pcursor     string         `json:"pcursor"`
+				SubComments []*CommentData `json:"subComments"`
+			} `json:"visionSubCommentList"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, "", err
+	}
+
+	// 补全回复所属的视频和一级评论
+	replies := result.Data.VisionSubCommentList.SubComments
+	for _, reply := range replies {
+		reply.VideoID = videoID
+		reply.ParentID = commentID
+	}
+
+	return replies, result.Data.VisionSubCommentList.Pcursor, nil
+}
+
 // GetProductInfo 获取商品信息
 func (s *KuaishouScraper) GetProductInfo(productID string) (*ProductInfo, error) {
 	// 构建API请求URL
@@ -278,9 +448,30 @@ func (s *KuaishouScraper) GetProductInfo(productID string) (*ProductInfo, error)
 			id
 			name
 			price
+			originalPrice
+			minPrice
+			maxPrice
 			category
 			description
 			sales
+			shopId
+			shipFrom
+			offShelf
+			skus {
+				skuId
+				name
+				weight
+				grade
+				origin
+				price
+				originalPrice
+				stock
+			}
+			rating {
+				score
+				reviewCount
+				goodRate
+			}
 		}
 	}`, productID)
 
@@ -300,10 +491,373 @@ func (s *KuaishouScraper) GetProductInfo(productID string) (*ProductInfo, error)
 	}
 
 	// 设置请求头
-	req.Header.Set("User-Agent", s.userAgent)
-	req.Header.Set("Cookie", s.cookies)
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: 状态码 %d", ErrNotFound, resp.StatusCode)
+	}
+
+	// 读取响应内容
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析JSON响应，已删除的商品 productInfo 为 null
+	var result struct {
+		Data struct {
+			ProductInfo *ProductInfo `json:"productInfo"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	if result.Data.ProductInfo == nil {
+		return nil, fmt.Errorf("%w: 快手商品 %s", ErrNotFound, productID)
+	}
+
+	result.Data.ProductInfo.FillPriceRange()
+	return result.Data.ProductInfo, nil
+}
+
+// GetProductReviews 获取商品评价
+func (s *KuaishouScraper) GetProductReviews(productID string, cursor string) ([]*ProductReview, string, error) {
+	// 构建API请求URL
+	apiURL := "https://www.kuaishou.com/graphql"
+
+	// 构建GraphQL查询
+	query := fmt.Sprintf(`{
+		productCommentList(productId: "%s", pcursor: "%s") {
+			pcursor
+			comments {
+				id
+				authorId
+				content
+				score
+				skuDesc
+				createTime
+			}
+		}
+	}`, productID, cursor)
+
+	// 构建请求体
+	requestBody := map[string]interface{}{
+		"query": query,
+	}
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 发送请求
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 设置请求头
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应内容
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 解析JSON响应
+	var result struct {
+		Data struct {
+			ProductCommentList struct {
+				Pcursor  string           `json:"pcursor"`
+				Comments []*ProductReview `json:"comments"`
+			} `json:"productCommentList"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, "", err
+	}
+
+	// 补全评价所属商品
+	reviews := result.Data.ProductCommentList.Comments
+	for _, review := range reviews {
+		review.ProductID = productID
+	}
+
+	return reviews, result.Data.ProductCommentList.Pcursor, nil
+}
+
+// GetShopInfo 获取店铺信息
+func (s *KuaishouScraper) GetShopInfo(shopID string) (*ShopData, error) {
+	// 构建API请求URL
+	apiURL := "https://www.kuaishou.com/graphql"
+
+	// 构建GraphQL查询
+	query := fmt.Sprintf(`{
+		shopInfo(shopId: "%s") {
+			id
+			name
+			rating
+			followersCount
+			category
+			location
+		}
+	}`, shopID)
+
+	// 构建请求体
+	requestBody := map[string]interface{}{
+		"query": query,
+	}
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发送请求
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	// 设置请求头
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应内容
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析JSON响应
+	var result struct {
+		Data struct {
+			ShopInfo ShopData `json:"shopInfo"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	result.Data.ShopInfo.ShopID = shopID
+	return &result.Data.ShopInfo, nil
+}
+
+// GetShopProducts 获取店铺的全部商品
+func (s *KuaishouScraper) GetShopProducts(shopID string, cursor string) ([]*ProductInfo, string, error) {
+	// 构建API请求URL
+	apiURL := "https://www.kuaishou.com/graphql"
+
+	// 构建GraphQL查询
+	query := fmt.Sprintf(`{
+		shopProductList(shopId: "%s", pcursor: "%s") {
+			pcursor
+			products {
+				id
+				name
+				price
+				category
+				description
+				sales
+			}
+		}
+	}`, shopID, cursor)
+
+	// 构建请求体
+	requestBody := map[string]interface{}{
+		"query": query,
+	}
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 发送请求
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 设置请求头
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应内容
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 解析JSON响应
+	var result struct {
+		Data struct {
+			ShopProductList struct {
+				Pcursor  string         `json:"pcursor"`
+				Products []*ProductInfo `json:"products"`
+			} `json:"shopProductList"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, "", err
+	}
+
+	// 补全商品所属店铺
+	products := result.Data.ShopProductList.Products
+	for _, product := range products {
+		product.ShopID = shopID
+	}
+
+	return products, result.Data.ShopProductList.Pcursor, nil
+}
+
+// GetLiveRoom 获取用户当前的直播间信息
+func (s *KuaishouScraper) GetLiveRoom(userID string) (*LiveRoom, error) {
+	// 构建API请求URL
+	apiURL := "https://live.kuaishou.com/live_graphql"
+
+	// 构建GraphQL查询
+	query := fmt.Sprintf(`{
+		visionLiveDetail(principalId: "%s") {
+			isLiving
+			liveStream {
+				id
+				caption
+				watchingCount
+				totalWatchCount
+				likeCount
+				startTime
+			}
+		}
+	}`, userID)
+
+	// 构建请求体
+	requestBody := map[string]interface{}{
+		"query": query,
+	}
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发送请求
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	// 设置请求头
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Referer", "https://live.kuaishou.com/")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应内容
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析JSON响应
+	var result struct {
+		Data struct {
+			VisionLiveDetail struct {
+				IsLiving   bool `json:"isLiving"`
+				LiveStream struct {
+					ID              string `json:"id"`
+					Caption         string `json:"caption"`
+					WatchingCount   int    `json:"watchingCount"`
+					TotalWatchCount int    `json:"totalWatchCount"`
+					LikeCount       int    `json:"likeCount"`
+					StartTime       int64  `json:"startTime"`
+				} `json:"liveStream"`
+			} `json:"visionLiveDetail"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	detail := result.Data.VisionLiveDetail
+	return &LiveRoom{
+		RoomID:       detail.LiveStream.ID,
+		UserID:       userID,
+		Title:        detail.LiveStream.Caption,
+		IsLive:       detail.IsLiving,
+		ViewerCount:  detail.LiveStream.WatchingCount,
+		TotalViewers: detail.LiveStream.TotalWatchCount,
+		Likes:        detail.LiveStream.LikeCount,
+		StartTime:    detail.LiveStream.StartTime,
+	}, nil
+}
+
+// GetLiveProducts 获取直播间购物车中的商品
+func (s *KuaishouScraper) GetLiveProducts(roomID string) ([]*ProductInfo, error) {
+	// 构建API请求URL
+	apiURL := "https://live.kuaishou.com/live_graphql"
+
+	// 构建GraphQL查询
+	query := fmt.Sprintf(`{
+		liveShopCar(liveStreamId: "%s") {
+			items {
+				id
+				name
+				price
+				category
+				description
+				sales
+			}
+		}
+	}`, roomID)
+
+	// 构建请求体
+	requestBody := map[string]interface{}{
+		"query": query,
+	}
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发送请求
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	// 设置请求头
+	setHeaders(req, Kuaishou, s.userAgent, s.cookies)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Referer", "https://www.kuaishou.com/")
+	req.Header.Set("Referer", "https://live.kuaishou.com/")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -320,12 +874,14 @@ func (s *KuaishouScraper) GetProductInfo(productID string) (*ProductInfo, error)
 	// 解析JSON响应
 	var result struct {
 		Data struct {
-			ProductInfo ProductInfo `json:"productInfo"`
+			LiveShopCar struct {
+				Items []*ProductInfo `json:"items"`
+			} `json:"liveShopCar"`
 		} `json:"data"`
 	}
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, err
 	}
 
-	return &result.Data.ProductInfo, nil
+	return result.Data.LiveShopCar.Items, nil
 }
@@ -0,0 +1,242 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const pddHost = "https://mobile.yangkeduo.com"
+
+func init() {
+	Register(PlatformInfo{
+		Name:         Pinduoduo,
+		DisplayName:  "拼多多",
+		LoginURL:     "https://mobile.yangkeduo.com/login.html",
+		LoginCookies: []string{"PDDAccessToken"},
+		Headers:      map[string]string{"Referer": "https://mobile.yangkeduo.com/", "Origin": "https://mobile.yangkeduo.com"},
+		Capabilities: []Capability{CapabilityProducts},
+		NewProducts: func(userAgent, cookies string) ProductScraper {
+			return NewPinduoduoScraper(userAgent, cookies)
+		},
+	})
+}
+
+// PinduoduoScraper 拼多多商品爬虫，通过移动端网页搜索商品和获取商品详情，价格取拼团价
+type PinduoduoScraper struct {
+	client    *http.Client
+	userAgent string
+	cookies   string
+}
+
+// NewPinduoduoScraper 创建拼多多商品爬虫实例
+func NewPinduoduoScraper(userAgent, cookies string) *PinduoduoScraper {
+	return &PinduoduoScraper{
+		client: &http.Client{
+			Timeout: time.Second * 30,
+		},
+		userAgent: userAgent,
+		cookies:   cookies,
+	}
+}
+
+// Initialize 初始化爬虫，拼多多接口需要登录后的 PDDAccessToken
+func (s *PinduoduoScraper) Initialize() error {
+	if cookieValue(s.cookies, "PDDAccessToken") == "" {
+		return errors.New("拼多多 Cookie 中缺少 PDDAccessToken，请使用登录后的完整 Cookie")
+	}
+	return nil
+}
+
+// get 发送 GET 请求并返回响应内容
+func (s *PinduoduoScraper) get(path string, params url.Values) ([]byte, error) {
+	req, err := http.NewRequest("GET", pddHost+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	// 设置请求头
+	setHeaders(req, Pinduoduo, s.userAgent, s.cookies)
+	req.Header.Set("AccessToken", cookieValue(s.cookies, "PDDAccessToken"))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应内容
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 401 表示登录失效，403 和 429 表示触发了风控
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("%w: 拼多多接口返回状态码 %d", ErrAuthExpired, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("拼多多接口返回状态码 %d", resp.StatusCode)
+	}
+
+	return body, nil
+}
+
+// pddGoods 搜索结果中的商品，价格单位为分
+type pddGoods struct {
+	GoodsID     int64  `json:"goods_id"`
+	GoodsName   string `json:"goods_name"`
+	Price       int    `json:"price"`        // 拼团价
+	NormalPrice int    `json:"normal_price"` // 单买价
+	SalesTip    string `json:"sales_tip"`
+	MallID      int64  `json:"mall_id"`
+}
+
+// SearchProducts 按商品名称搜索商品，游标为页码
+func (s *PinduoduoScraper) SearchProducts(keyword string, cursor string) ([]*ProductInfo, string, error) {
+	page := 1
+	if cursor != "" {
+		page, _ = strconv.Atoi(cursor)
+	}
+
+	params := url.Values{
+		"source":     {"search"},
+		"search_met": {"manual"},
+		"q":          {keyword},
+		"page":       {strconv.Itoa(page)},
+		"size":       {"20"},
+		"sort":       {"default"},
+	}
+	body, err := s.get("/proxy/api/search", params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var result struct {
+		ErrorCode int    `json:"error_code"`
+		ErrorMsg  string `json:"error_msg"`
+		Items     []struct {
+			GoodsModel pddGoods `json:"goods_model"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, "", err
+	}
+	// 40001 表示登录失效
+	if result.ErrorCode == 40001 {
+		return nil, "", fmt.Errorf("%w: 拼多多接口返回 %d %s", ErrAuthExpired, result.ErrorCode, result.ErrorMsg)
+	}
+	if result.ErrorCode != 0 {
+		return nil, "", fmt.Errorf("拼多多接口返回错误: %d %s", result.ErrorCode, result.ErrorMsg)
+	}
+
+	products := make([]*ProductInfo, 0, len(result.Items))
+	for _, item := range result.Items {
+		goods := item.GoodsModel
+		if goods.GoodsID == 0 {
+			continue
+		}
+
+		product := &ProductInfo{
+			ProductID:     strconv.FormatInt(goods.GoodsID, 10),
+			Name:          goods.GoodsName,
+			Price:         float64(goods.Price) / 100,
+			OriginalPrice: float64(goods.NormalPrice) / 100,
+			Sales:         parseSales(goods.SalesTip),
+		}
+		if goods.MallID != 0 {
+			product.ShopID = strconv.FormatInt(goods.MallID, 10)
+		}
+		product.FillPriceRange()
+		products = append(products, product)
+	}
+
+	if len(products) == 0 {
+		return products, "", nil
+	}
+	return products, strconv.Itoa(page + 1), nil
+}
+
+// 商品详情页中嵌入的页面数据
+var pddRawDataPattern = regexp.MustCompile(`(?s)window\.rawData\s*=\s*(\{.*?\});\s*</script>`)
+
+// GetProductInfo 获取商品详情，从商品页嵌入的 window.rawData 中解析
+func (s *PinduoduoScraper) GetProductInfo(productID string) (*ProductInfo, error) {
+	body, err := s.get("/goods.html", url.Values{"goods_id": {productID}})
+	if err != nil {
+		return nil, err
+	}
+
+	match := pddRawDataPattern.FindSubmatch(body)
+	if match == nil {
+		return nil, fmt.Errorf("拼多多商品 %s 页面中未找到商品数据，可能需要重新登录", productID)
+	}
+
+	// 价格单位为分
+	var rawData struct {
+		Store struct {
+			InitDataObj struct {
+				Goods struct {
+					GoodsName            string `json:"goodsName"`
+					GoodsDesc            string `json:"goodsDesc"`
+					MinOnSaleGroupPrice  int    `json:"minOnSaleGroupPrice"`
+					MaxOnSaleNormalPrice int    `json:"maxOnSaleNormalPrice"`
+					SideSalesTip         string `json:"sideSalesTip"`
+					IsOnSale             *bool  `json:"isOnSale"`
+					Skus                 []struct {
+						SkuID int64 `json:"skuId"`
+						Specs []struct {
+							SpecValue string `json:"spec_value"`
+						} `json:"specs"`
+						GroupPrice  int `json:"groupPrice"`
+						NormalPrice int `json:"normalPrice"`
+						Quantity    int `json:"quantity"`
+					} `json:"skus"`
+				} `json:"goods"`
+				Mall struct {
+					MallID int64 `json:"mallID"`
+				} `json:"mall"`
+			} `json:"initDataObj"`
+		} `json:"store"`
+	}
+	if err := json.Unmarshal(match[1], &rawData); err != nil {
+		return nil, fmt.Errorf("解析拼多多商品数据失败: %v", err)
+	}
+
+	goods := rawData.Store.InitDataObj.Goods
+	product := &ProductInfo{
+		ProductID:     productID,
+		Name:          goods.GoodsName,
+		Price:         float64(goods.MinOnSaleGroupPrice) / 100,
+		OriginalPrice: float64(goods.MaxOnSaleNormalPrice) / 100,
+		Description:   goods.GoodsDesc,
+		Sales:         parseSales(goods.SideSalesTip),
+		OffShelf:      goods.IsOnSale != nil && !*goods.IsOnSale,
+	}
+	if mallID := rawData.Store.InitDataObj.Mall.MallID; mallID != 0 {
+		product.ShopID = strconv.FormatInt(mallID, 10)
+	}
+	for _, sku := range goods.Skus {
+		var specs []string
+		for _, spec := range sku.Specs {
+			specs = append(specs, spec.SpecValue)
+		}
+		product.SKUs = append(product.SKUs, &ProductSKU{
+			SkuID:         strconv.FormatInt(sku.SkuID, 10),
+			Name:          strings.Join(specs, " "),
+			Price:         float64(sku.GroupPrice) / 100,
+			OriginalPrice: float64(sku.NormalPrice) / 100,
+			Stock:         sku.Quantity,
+		})
+	}
+
+	product.FillPriceRange()
+	return product, nil
+}
@@ -0,0 +1,174 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Capability 平台支持的可选功能，对应 Scraper 之外的可选接口
+type Capability string
+
+const (
+	CapabilityReplies Capability = "replies" // ReplyScraper：评论回复
+	CapabilityLive    Capability = "live"    // LiveScraper：直播带货
+
+	// CapabilityProducts 只采集商品的电商平台，实现 ProductScraper 而非 Scraper
+	CapabilityProducts Capability = "products"
+)
+
+// ErrNotSupported 平台不提供该数据，调用方无需重试
+var ErrNotSupported = errors.New("平台不支持该功能")
+
+// ErrAuthExpired 登录状态已失效，调用方应刷新 Cookie 后重试
+var ErrAuthExpired = errors.New("登录状态已失效")
+
+// ErrNotFound 请求的数据不存在，如商品已被删除
+var ErrNotFound = errors.New("数据不存在")
+
+// ErrChallenge 请求被风控拦截并要求验证，继续使用同一账号请求通常会失败
+var ErrChallenge = errors.New("触发了风控验证")
+
+// PlatformInfo 平台注册信息
+type PlatformInfo struct {
+	Name         Platform
+	DisplayName  string
+	LoginURL     string            // 登录获取Cookie的页面
+	LoginCookies []string          // 表示登录状态的 Cookie，其有效期即会话有效期
+	Headers      map[string]string // 默认请求头
+	Capabilities []Capability
+	New          func(userAgent, cookies string) Scraper
+
+	// NewProducts 电商平台的商品爬虫构造函数，只采集商品的平台不提供 New
+	NewProducts func(userAgent, cookies string) ProductScraper
+
+	// BrowserRoutes 浏览器后端的接口路由，为空表示不支持浏览器后端
+	BrowserRoutes []BrowserRoute
+}
+
+// Supports 判断平台是否支持指定的可选功能
+func (p *PlatformInfo) Supports(capability Capability) bool {
+	for _, c := range p.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	registry      = make(map[Platform]*PlatformInfo)
+	registryMutex sync.RWMutex
+)
+
+// Register 注册平台，通常在平台实现文件的 init 中调用。
+// 重复注册或声明的功能与实现的接口不一致时 panic
+func Register(info PlatformInfo) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if info.Name == "" || (info.New == nil && info.NewProducts == nil) {
+		panic("crawler: 注册平台时必须提供名称和构造函数")
+	}
+	if _, ok := registry[info.Name]; ok {
+		panic(fmt.Sprintf("crawler: 平台 %s 重复注册", info.Name))
+	}
+
+	// 声明的功能必须与实现的可选接口一致
+	var implemented []Capability
+	if info.New != nil {
+		implemented = Capabilities(info.New("", ""))
+	}
+	if info.NewProducts != nil {
+		implemented = sortCapabilities(append(implemented, CapabilityProducts))
+	}
+	if fmt.Sprint(implemented) != fmt.Sprint(sortCapabilities(info.Capabilities)) {
+		panic(fmt.Sprintf("crawler: 平台 %s 声明的功能 %v 与实现的接口 %v 不一致", info.Name, info.Capabilities, implemented))
+	}
+
+	info.Capabilities = implemented
+	registry[info.Name] = &info
+}
+
+// Lookup 查找已注册的平台
+func Lookup(name string) (*PlatformInfo, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	info, ok := registry[Platform(name)]
+	return info, ok
+}
+
+// Platforms 返回全部已注册的平台，按名称排序
+func Platforms() []*PlatformInfo {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	platforms := make([]*PlatformInfo, 0, len(registry))
+	for _, info := range registry {
+		platforms = append(platforms, info)
+	}
+	sort.Slice(platforms, func(i, j int) bool {
+		return platforms[i].Name < platforms[j].Name
+	})
+	return platforms
+}
+
+// NewScraper 创建指定平台的爬虫实例
+func NewScraper(name, userAgent, cookies string) (Scraper, error) {
+	info, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("不支持的平台: %s", name)
+	}
+	if info.New == nil {
+		return nil, fmt.Errorf("平台 %s 只支持商品比价，请使用 benchmark 子命令", name)
+	}
+	return info.New(userAgent, cookies), nil
+}
+
+// NewProductScraper 创建指定电商平台的商品爬虫实例
+func NewProductScraper(name, userAgent, cookies string) (ProductScraper, error) {
+	info, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("不支持的平台: %s", name)
+	}
+	if info.NewProducts == nil {
+		return nil, fmt.Errorf("平台 %s 不支持商品比价", name)
+	}
+	return info.NewProducts(userAgent, cookies), nil
+}
+
+// Capabilities 通过接口断言返回爬虫实现的可选功能
+func Capabilities(scraper Scraper) []Capability {
+	var capabilities []Capability
+	if _, ok := scraper.(ReplyScraper); ok {
+		capabilities = append(capabilities, CapabilityReplies)
+	}
+	if _, ok := scraper.(LiveScraper); ok {
+		capabilities = append(capabilities, CapabilityLive)
+	}
+	return sortCapabilities(capabilities)
+}
+
+// sortCapabilities 按名称排序功能列表
+func sortCapabilities(capabilities []Capability) []Capability {
+	sorted := append([]Capability(nil), capabilities...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+// setHeaders 设置 User-Agent、Cookie 和平台的默认请求头
+func setHeaders(req *http.Request, platform Platform, userAgent, cookies string) {
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Cookie", cookies)
+
+	if info, ok := Lookup(string(platform)); ok {
+		for key, value := range info.Headers {
+			req.Header.Set(key, value)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// UseSession 让爬虫在每次请求时合并 cookies 返回的最新会话 Cookie。
+// 登录状态 Cookie（PlatformInfo.LoginCookies）总是使用会话中的值，以便刷新后的登录状态立即生效；
+// 其余 Cookie 只补充请求中缺少的，爬虫自身维护的设备指纹和签名令牌等 Cookie 优先
+func UseSession(name string, scraper Scraper, cookies func() string) error {
+	return useCookies(name, scraper, cookies, false)
+}
+
+// UseCookies 让爬虫在每次请求时使用 cookies 返回的 Cookie，同名 Cookie 覆盖请求中的值。
+// 用于没有会话存储时应用风控验证通过后得到的 Cookie
+func UseCookies(name string, scraper Scraper, cookies func() string) error {
+	return useCookies(name, scraper, cookies, true)
+}
+
+// useCookies 为爬虫的 HTTP 客户端设置合并 Cookie 的 Transport，override 为 true 时全部 Cookie 覆盖请求中的值
+func useCookies(name string, scraper Scraper, cookies func() string, override bool) error {
+	info, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("不支持的平台: %s", name)
+	}
+	holder, ok := scraper.(httpClientHolder)
+	if !ok {
+		return fmt.Errorf("平台 %s 不支持会话刷新", name)
+	}
+
+	client := holder.httpClient()
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = &sessionTransport{next: next, loginCookies: info.LoginCookies, cookies: cookies, override: override}
+	return nil
+}
+
+// sessionTransport 合并会话 Cookie 的 http.RoundTripper
+type sessionTransport struct {
+	next         http.RoundTripper
+	loginCookies []string
+	cookies      func() string
+	override     bool // 全部 Cookie 覆盖请求中的值
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *sessionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	session := t.cookies()
+	if session == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	header := req.Header.Get("Cookie")
+	for _, part := range strings.Split(session, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || name == "" {
+			continue
+		}
+		if t.override || cookieValue(header, name) == "" || t.isLoginCookie(name) {
+			header = setCookieValue(header, name, value)
+		}
+	}
+
+	// RoundTripper 不能修改原请求
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Cookie", header)
+	return t.next.RoundTrip(clone)
+}
+
+// isLoginCookie 判断是否为登录状态 Cookie
+func (t *sessionTransport) isLoginCookie(name string) bool {
+	for _, loginCookie := range t.loginCookies {
+		if name == loginCookie {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUseSession(t *testing.T) {
+	tests := []struct {
+		name string
+		use  func(name string, scraper Scraper, cookies func() string) error
+		want string
+	}{
+		// 登录状态 Cookie 使用会话中的值，其余 Cookie 只补充请求中缺少的
+		{"会话", UseSession, "s_v_web_id=old; sessionid=new; ttwid=t1"},
+		// 全部 Cookie 覆盖请求中的值
+		{"验证后的Cookie", UseCookies, "sessionid=new; s_v_web_id=verify_new; ttwid=t1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			scraper := NewDouyinScraper("test-agent", "")
+			scraper.client.Transport.(*challengeTransport).next = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				got = req.Header.Get("Cookie")
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+			})
+			err := tt.use(string(Douyin), scraper, func() string {
+				return "sessionid=new; s_v_web_id=verify_new; ttwid=t1"
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, _ := http.NewRequest("GET", "https://www.douyin.com/", nil)
+			req.Header.Set("Cookie", "sessionid=old; s_v_web_id=old")
+			resp, err := scraper.client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if got != tt.want {
+				t.Errorf("请求Cookie %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package crawler
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	taobaoAPIHost = "https://h5api.m.taobao.com"
+	taobaoAppKey  = "12574478"
+)
+
+func init() {
+	Register(PlatformInfo{
+		Name:         Taobao,
+		DisplayName:  "淘宝",
+		LoginURL:     "https://login.taobao.com",
+		LoginCookies: []string{"cookie2"},
+		Headers:      map[string]string{"Referer": "https://s.taobao.com/", "Origin": "https://s.taobao.com"},
+		Capabilities: []Capability{CapabilityProducts},
+		NewProducts: func(userAgent, cookies string) ProductScraper {
+			return NewTaobaoScraper(userAgent, cookies)
+		},
+	})
+}
+
+// TaobaoScraper 淘宝商品爬虫，通过 H5 mtop 接口搜索商品和获取商品详情
+type TaobaoScraper struct {
+	client    *http.Client
+	userAgent string
+	cookies   string
+	mutex     sync.Mutex
+}
+
+// NewTaobaoScraper 创建淘宝商品爬虫实例
+func NewTaobaoScraper(userAgent, cookies string) *TaobaoScraper {
+	return &TaobaoScraper{
+		client: &http.Client{
+			Timeout: time.Second * 30,
+		},
+		userAgent: userAgent,
+		cookies:   cookies,
+	}
+}
+
+// Initialize 初始化爬虫，Cookie 中缺少 _m_h5_tk 时发送一次请求获取接口令牌
+func (s *TaobaoScraper) Initialize() error {
+	if cookieValue(s.cookies, "_m_h5_tk") != "" {
+		return nil
+	}
+
+	if _, _, err := s.call("mtop.taobao.detail.getdetail", "6.0", `{"itemNumId":"0"}`); err != nil {
+		return err
+	}
+	if cookieValue(s.cookies, "_m_h5_tk") == "" {
+		return fmt.Errorf("获取淘宝接口令牌失败，请检查Cookie或网络")
+	}
+	return nil
+}
+
+// request 调用 mtop 接口并将 data 解析到 out。
+// 签名为 MD5(token&t&appKey&data)，token 取自 Cookie 中的 _m_h5_tk，
+// 令牌为空或过期时接口会通过 Set-Cookie 下发新令牌，更新后重试一次
+func (s *TaobaoScraper) request(api, version string, payload interface{}, out interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		ret, body, err := s.call(api, version, string(data))
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(ret, "SUCCESS") {
+			return json.Unmarshal(body, out)
+		}
+		if attempt == 0 && strings.HasPrefix(ret, "FAIL_SYS_TOKEN") {
+			continue
+		}
+		if strings.HasPrefix(ret, "FAIL_SYS_SESSION_EXPIRED") {
+			return fmt.Errorf("%w: 淘宝接口返回 %s", ErrAuthExpired, ret)
+		}
+		return fmt.Errorf("淘宝接口返回错误: %s", ret)
+	}
+}
+
+// call 发送一次签名请求，返回接口的 ret 和 data
+func (s *TaobaoScraper) call(api, version, data string) (string, json.RawMessage, error) {
+	s.mutex.Lock()
+	cookies := s.cookies
+	s.mutex.Unlock()
+
+	token, _, _ := strings.Cut(cookieValue(cookies, "_m_h5_tk"), "_")
+	t := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	sum := md5.Sum([]byte(token + "&" + t + "&" + taobaoAppKey + "&" + data))
+
+	params := url.Values{
+		"jsv":      {"2.7.2"},
+		"appKey":   {taobaoAppKey},
+		"t":        {t},
+		"sign":     {hex.EncodeToString(sum[:])},
+		"api":      {api},
+		"v":        {version},
+		"type":     {"originaljson"},
+		"dataType": {"json"},
+		"data":     {data},
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/h5/%s/%s/?%s", taobaoAPIHost, strings.ToLower(api), version, params.Encode()), nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	// 设置请求头
+	setHeaders(req, Taobao, s.userAgent, cookies)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	// 保存接口下发的令牌
+	s.updateCookies(resp.Cookies())
+
+	// 读取响应内容
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("淘宝接口返回状态码 %d", resp.StatusCode)
+	}
+
+	// 解析JSON响应
+	var result struct {
+		Ret  []string        `json:"ret"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", nil, err
+	}
+	if len(result.Ret) == 0 {
+		return "", nil, fmt.Errorf("淘宝接口返回格式错误")
+	}
+
+	return result.Ret[0], result.Data, nil
+}
+
+// updateCookies 将响应中的 _m_h5_tk 和 _m_h5_tk_enc 合并到 Cookie 中
+func (s *TaobaoScraper) updateCookies(cookies []*http.Cookie) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, cookie := range cookies {
+		if cookie.Name == "_m_h5_tk" || cookie.Name == "_m_h5_tk_enc" {
+			s.cookies = setCookieValue(s.cookies, cookie.Name, cookie.Value)
+		}
+	}
+}
+
+// 搜索结果标题中用于高亮关键词的标签
+var taobaoHighlightPattern = regexp.MustCompile(`<[^>]+>`)
+
+// SearchProducts 按商品名称搜索商品，游标为页码
+func (s *TaobaoScraper) SearchProducts(keyword string, cursor string) ([]*ProductInfo, string, error) {
+	page := 1
+	if cursor != "" {
+		page, _ = strconv.Atoi(cursor)
+	}
+
+	params, _ := json.Marshal(map[string]interface{}{
+		"q":                keyword,
+		"page":             page,
+		"n":                20,
+		"sort":             "_coefp",
+		"tab":              "all",
+		"search_action":    "initiative",
+		"sourceS":          "0",
+		"isBeta":           "false",
+		"pageSource":       "a2141.7631564.sortbar",
+		"hasPreposeFilter": "false",
+	})
+	var result struct {
+		ItemsArray []struct {
+			ItemID    string `json:"item_id"`
+			Title     string `json:"title"`
+			Price     string `json:"price"`
+			PriceShow struct {
+				Price string `json:"price"`
+			} `json:"priceShow"`
+			RealSales string `json:"realSales"`
+			Procity   string `json:"procity"`
+			UserID    string `json:"userId"` // 卖家ID，作为店铺ID
+		} `json:"itemsArray"`
+		MainInfo struct {
+			TotalPage string `json:"totalPage"`
+		} `json:"mainInfo"`
+	}
+	payload := map[string]string{"appId": "34385", "params": string(params)}
+	if err := s.request("mtop.relationrecommend.WirelessRecommend.recommend", "2.0", payload, &result); err != nil {
+		return nil, "", err
+	}
+
+	products := make([]*ProductInfo, 0, len(result.ItemsArray))
+	for _, item := range result.ItemsArray {
+		price := item.PriceShow.Price
+		if price == "" {
+			price = item.Price
+		}
+		product := &ProductInfo{
+			ProductID: item.ItemID,
+			Name:      taobaoHighlightPattern.ReplaceAllString(item.Title, ""),
+			Sales:     parseSales(item.RealSales),
+			ShopID:    item.UserID,
+			ShipFrom:  item.Procity,
+		}
+		product.Price, _ = strconv.ParseFloat(price, 64)
+		product.FillPriceRange()
+		products = append(products, product)
+	}
+
+	totalPages, _ := strconv.Atoi(result.MainInfo.TotalPage)
+	if len(products) == 0 || (totalPages > 0 && page >= totalPages) {
+		return products, "", nil
+	}
+	return products, strconv.Itoa(page + 1), nil
+}
+
+// taobaoDetailStack 商品详情中 apiStack 的动态数据，包括价格、销量、库存和发货地
+type taobaoDetailStack struct {
+	Price struct {
+		Price struct {
+			PriceText string `json:"priceText"`
+		} `json:"price"`
+		ExtraPrices []struct {
+			PriceText string `json:"priceText"`
+		} `json:"extraPrices"`
+	} `json:"price"`
+	Item struct {
+		SellCount string `json:"sellCount"`
+	} `json:"item"`
+	Delivery struct {
+		From string `json:"from"`
+	} `json:"delivery"`
+	Trade struct {
+		BuyEnable string `json:"buyEnable"`
+	} `json:"trade"`
+	SkuCore struct {
+		Sku2Info map[string]struct {
+			Price struct {
+				PriceText string `json:"priceText"`
+			} `json:"price"`
+			Quantity string `json:"quantity"`
+		} `json:"sku2info"`
+	} `json:"skuCore"`
+}
+
+// GetProductInfo 获取商品详情
+func (s *TaobaoScraper) GetProductInfo(productID string) (*ProductInfo, error) {
+	var result struct {
+		Item struct {
+			ItemID   string `json:"itemId"`
+			Title    string `json:"title"`
+			Subtitle string `json:"subtitle"`
+		} `json:"item"`
+		Seller struct {
+			ShopID   string `json:"shopId"`
+			ShopName string `json:"shopName"`
+		} `json:"seller"`
+		SkuBase struct {
+			Skus []struct {
+				SkuID    string `json:"skuId"`
+				PropPath string `json:"propPath"` // 形如 pid:vid;pid:vid
+			} `json:"skus"`
+			Props []struct {
+				Pid    string `json:"pid"`
+				Values []struct {
+					Vid  string `json:"vid"`
+					Name string `json:"name"`
+				} `json:"values"`
+			} `json:"props"`
+		} `json:"skuBase"`
+		APIStack []struct {
+			Value string `json:"value"` // JSON 字符串
+		} `json:"apiStack"`
+	}
+	payload := map[string]string{"itemNumId": productID}
+	if err := s.request("mtop.taobao.detail.getdetail", "6.0", payload, &result); err != nil {
+		return nil, err
+	}
+
+	var stack taobaoDetailStack
+	if len(result.APIStack) > 0 {
+		if err := json.Unmarshal([]byte(result.APIStack[0].Value), &stack); err != nil {
+			return nil, fmt.Errorf("解析淘宝商品动态数据失败: %v", err)
+		}
+	}
+
+	product := &ProductInfo{
+		ProductID:   productID,
+		Name:        result.Item.Title,
+		Description: result.Item.Subtitle,
+		Sales:       parseSales(stack.Item.SellCount),
+		ShopID:      result.Seller.ShopID,
+		ShipFrom:    stack.Delivery.From,
+		OffShelf:    stack.Trade.BuyEnable == "false",
+	}
+	product.Price = parsePriceText(stack.Price.Price.PriceText)
+	if len(stack.Price.ExtraPrices) > 0 {
+		// 有促销价时 price 为促销价，extraPrices 为原价
+		product.OriginalPrice = parsePriceText(stack.Price.ExtraPrices[0].PriceText)
+	}
+
+	// 规格名称由属性值拼接
+	values := make(map[string]string)
+	for _, prop := range result.SkuBase.Props {
+		for _, value := range prop.Values {
+			values[prop.Pid+":"+value.Vid] = value.Name
+		}
+	}
+	for _, sku := range result.SkuBase.Skus {
+		var names []string
+		for _, path := range strings.Split(sku.PropPath, ";") {
+			if name, ok := values[path]; ok {
+				names = append(names, name)
+			}
+		}
+
+		info := stack.SkuCore.Sku2Info[sku.SkuID]
+		stock, _ := strconv.Atoi(info.Quantity)
+		product.SKUs = append(product.SKUs, &ProductSKU{
+			SkuID: sku.SkuID,
+			Name:  strings.Join(names, " "),
+			Price: parsePriceText(info.Price.PriceText),
+			Stock: stock,
+		})
+	}
+
+	product.FillPriceRange()
+	return product, nil
+}
+
+// parsePriceText 解析价格文本，区间价格如 "19.9-39.9" 取最低价
+func parsePriceText(text string) float64 {
+	low, _, _ := strings.Cut(strings.TrimSpace(text), "-")
+	price, _ := strconv.ParseFloat(strings.TrimPrefix(low, "¥"), 64)
+	return price
+}
+
+// parseSales 解析 "1万+人付款"、"已售 300+"、"已拼1.2万件" 形式的销量
+func parseSales(text string) int {
+	start := strings.IndexAny(text, "0123456789")
+	if start < 0 {
+		return 0
+	}
+
+	text = text[start:]
+	end := strings.IndexFunc(text, func(r rune) bool {
+		return !strings.ContainsRune("0123456789.,万wW+", r)
+	})
+	if end >= 0 {
+		text = text[:end]
+	}
+	return parseCount(text)
+}
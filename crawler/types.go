@@ -4,13 +4,18 @@ package crawler
 type Platform string
 
 const (
-	Douyin  Platform = "douyin"
-	Kuaishou Platform = "kuaishou"
+	Douyin      Platform = "douyin"
+	Kuaishou    Platform = "kuaishou"
+	Xiaohongshu Platform = "xiaohongshu"
+	Bilibili    Platform = "bilibili"
+	Taobao      Platform = "taobao"
+	Pinduoduo   Platform = "pinduoduo"
 )
 
 // UserData 用户数据结构
 type UserData struct {
 	UserID      string   `json:"user_id"`
+	Platform    string   `json:"platform,omitempty"` // 所属平台，与ID一起唯一确定一条记录
 	Nickname    string   `json:"nickname"`
 	Followers   int      `json:"followers"`
 	Following   int      `json:"following"`
@@ -18,9 +23,17 @@ type UserData struct {
 	Tags        []string `json:"tags"`
 }
 
+// UserEdge 用户关注关系，FromUserID 关注了 ToUserID
+type UserEdge struct {
+	FromUserID string `json:"from_user_id"`
+	ToUserID   string `json:"to_user_id"`
+	Platform   string `json:"platform,omitempty"`
+}
+
 // VideoData 视频数据结构
 type VideoData struct {
 	VideoID     string   `json:"video_id"`
+	Platform    string   `json:"platform,omitempty"`
 	UserID      string   `json:"user_id"`
 	Title       string   `json:"title"`
 	Description string   `json:"description"`
@@ -29,27 +42,247 @@ type VideoData struct {
 	Shares      int      `json:"shares"`
 	Tags        []string `json:"tags"`
 	ProductInfo *ProductInfo `json:"product_info,omitempty"`
+	Agri        *AgriClass   `json:"agri,omitempty"`
+	Origin      *OriginInfo  `json:"origin,omitempty"`
+}
+
+// AgriClass 农产品分类结果
+type AgriClass struct {
+	IsAgricultural bool     `json:"is_agricultural"`
+	Category       string   `json:"category,omitempty"` // 大类，如 fruit、aquatic
+	Crop           string   `json:"crop,omitempty"`     // 具体品类，如 apple、crab
+	Confidence     float64  `json:"confidence"`
+	Keywords       []string `json:"keywords,omitempty"` // 命中的关键词
 }
 
+// OriginInfo 产地信息
+type OriginInfo struct {
+	Province  string `json:"province,omitempty"`
+	City      string `json:"city,omitempty"`
+	County    string `json:"county,omitempty"`
+	GIProduct string `json:"gi_product,omitempty"` // 地理标志产品，如 烟台苹果
+	Matched   string `json:"matched,omitempty"`    // 命中的原文
+}
+
+// 商品数据来源
+const (
+	SourceShelf  = "shelf"  // 短视频平台的橱窗、视频和直播带货商品
+	SourceSearch = "search" // 电商平台搜索页的比价商品
+)
+
 // ProductInfo 商品信息结构
 type ProductInfo struct {
-	ProductID   string  `json:"product_id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Category    string  `json:"category"`
-	Description string  `json:"description"`
-	Sales       int     `json:"sales"`
+	ProductID     string         `json:"product_id"`
+	Platform      string         `json:"platform,omitempty"`
+	Source        string         `json:"source,omitempty"`     // 数据来源，见 SourceShelf、SourceSearch
+	MatchName     string         `json:"match_name,omitempty"` // 比价时使用的归一化商品名称
+	Name          string         `json:"name"`
+	Price         float64        `json:"price"`          // 当前售价（折后价）
+	OriginalPrice float64        `json:"original_price"` // 原价
+	MinPrice      float64        `json:"min_price"`      // 规格最低价
+	MaxPrice      float64        `json:"max_price"`      // 规格最高价
+	Category      string         `json:"category"`
+	Description   string         `json:"description"`
+	Sales         int            `json:"sales"`
+	ShopID        string         `json:"shop_id,omitempty"`
+	ShipFrom      string         `json:"ship_from,omitempty"` // 发货地
+	OffShelf      bool           `json:"off_shelf,omitempty"` // 是否已下架
+	SKUs          []*ProductSKU  `json:"skus,omitempty"`
+	Rating        *RatingSummary `json:"rating,omitempty"`
+	Agri          *AgriClass     `json:"agri,omitempty"`
+	Origin        *OriginInfo    `json:"origin,omitempty"`
+	UnitPrice     *UnitPrice     `json:"unit_price,omitempty"`
+}
+
+// FillPriceRange 价格区间缺失时根据规格价格补全
+func (p *ProductInfo) FillPriceRange() {
+	if p.MinPrice > 0 && p.MaxPrice > 0 {
+		return
+	}
+
+	for _, sku := range p.SKUs {
+		if sku.Price <= 0 {
+			continue
+		}
+		if p.MinPrice == 0 || sku.Price < p.MinPrice {
+			p.MinPrice = sku.Price
+		}
+		if sku.Price > p.MaxPrice {
+			p.MaxPrice = sku.Price
+		}
+	}
+
+	// 没有规格时价格区间即为售价
+	if p.MinPrice == 0 && p.MaxPrice == 0 {
+		p.MinPrice = p.Price
+		p.MaxPrice = p.Price
+	}
+}
+
+// ProductSKU 商品规格，农产品通常按重量、等级、产地区分
+type ProductSKU struct {
+	SkuID         string     `json:"sku_id"`
+	Name          string     `json:"name"`
+	Weight        string     `json:"weight,omitempty"`
+	Grade         string     `json:"grade,omitempty"`
+	Origin        string     `json:"origin,omitempty"`
+	Price         float64    `json:"price"`
+	OriginalPrice float64    `json:"original_price"`
+	Stock         int        `json:"stock"`
+	UnitPrice     *UnitPrice `json:"unit_price,omitempty"`
+}
+
+// UnitPrice 归一化单价，按重量计价时换算为每斤和每公斤价格，按件计价时给出每件价格
+type UnitPrice struct {
+	PerJin     float64 `json:"per_jin,omitempty"`   // 元/斤
+	PerKg      float64 `json:"per_kg,omitempty"`    // 元/kg
+	WeightKg   float64 `json:"weight_kg,omitempty"` // 总重量（公斤）
+	Count      int     `json:"count,omitempty"`     // 件数
+	Unit       string  `json:"unit,omitempty"`      // 计件单位，如 枚、箱
+	PerUnit    float64 `json:"per_unit,omitempty"`  // 元/件
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source,omitempty"` // 解析依据的原文
+}
+
+// RatingSummary 商品评价汇总
+type RatingSummary struct {
+	Score       float64 `json:"score"`
+	ReviewCount int     `json:"review_count"`
+	GoodRate    float64 `json:"good_rate"` // 好评率，取值0到1
+}
+
+// ProductReview 商品评价
+type ProductReview struct {
+	ReviewID  string           `json:"review_id"`
+	Platform  string           `json:"platform,omitempty"`
+	ProductID string           `json:"product_id"`
+	UserID    string           `json:"user_id"`
+	Content   string           `json:"content"`
+	Rating    int              `json:"rating"` // 评分，1到5星
+	SkuName   string           `json:"sku_name,omitempty"`
+	Timestamp int64            `json:"timestamp"`
+	Analysis  *CommentAnalysis `json:"analysis,omitempty"`
+}
+
+// ProductEventType 商品变化事件类型
+type ProductEventType string
+
+const (
+	EventPriceDrop   ProductEventType = "price_drop"    // 降价
+	EventPriceRise   ProductEventType = "price_rise"    // 涨价
+	EventSalesJump   ProductEventType = "sales_jump"    // 销量激增
+	EventOffShelf    ProductEventType = "off_shelf"     // 下架
+	EventBackOnShelf ProductEventType = "back_on_shelf" // 重新上架
+)
+
+// ProductEvent 两次采集之间商品发生的显著变化
+type ProductEvent struct {
+	Type          ProductEventType `json:"type"`
+	ProductID     string           `json:"product_id"`
+	Name          string           `json:"name"`
+	Platform      string           `json:"platform"`
+	OldValue      float64          `json:"old_value"`
+	NewValue      float64          `json:"new_value"`
+	ChangePercent float64          `json:"change_percent"` // 变化幅度（百分比），上下架事件为 0
+	Timestamp     int64            `json:"timestamp"`
+}
+
+// ShopData 店铺数据结构
+type ShopData struct {
+	ShopID    string  `json:"shop_id"`
+	Platform  string  `json:"platform,omitempty"`
+	Name      string  `json:"name"`
+	Rating    float64 `json:"rating"`
+	Followers int     `json:"followers"`
+	Category  string  `json:"category"`
+	Location  string  `json:"location"`
 }
 
 // CommentData 评论数据结构
 type CommentData struct {
-	CommentID string `json:"comment_id"`
-	VideoID   string `json:"video_id"`
-	UserID    string `json:"user_id"`
-	Content   string `json:"content"`
-	Likes     int    `json:"likes"`
-	Replies   int    `json:"replies"`
-	Timestamp int64  `json:"timestamp"`
+	CommentID     string `json:"comment_id"`
+	Platform      string `json:"platform,omitempty"`
+	VideoID       string `json:"video_id"`
+	UserID        string `json:"user_id"`
+	ParentID      string `json:"parent_id,omitempty"`        // 所属一级评论ID，一级评论为空
+	ReplyToUserID string `json:"reply_to_user_id,omitempty"` // 被回复用户ID
+	Content       string `json:"content"`
+	Likes         int    `json:"likes"`
+	Replies       int    `json:"replies"`
+	Timestamp     int64  `json:"timestamp"`
+
+	Analysis    *CommentAnalysis `json:"analysis,omitempty"`
+	SpamScore   float64          `json:"spam_score,omitempty"`   // 垃圾评论得分，0 到 1
+	SpamReasons []string         `json:"spam_reasons,omitempty"` // 判定原因，如 duplicate、link
+}
+
+// CommentAnalysis 评论文本分析结果
+type CommentAnalysis struct {
+	Sentiment  float64  `json:"sentiment"`            // 情感得分，-1（负面）到 1（正面）
+	Label      string   `json:"label"`                // positive、neutral 或 negative
+	Complaints []string `json:"complaints,omitempty"` // 投诉类别，如 freshness、packaging
+	Keywords   []string `json:"keywords,omitempty"`
+}
+
+// FeedbackSummary 视频评论或商品评价的汇总分析结果
+type FeedbackSummary struct {
+	TargetType   string         `json:"target_type"` // video 或 product
+	TargetID     string         `json:"target_id"`
+	Total        int            `json:"total"`
+	Positive     int            `json:"positive"`
+	Neutral      int            `json:"neutral"`
+	Negative     int            `json:"negative"`
+	AvgSentiment float64        `json:"avg_sentiment"`
+	Complaints   map[string]int `json:"complaints,omitempty"` // 投诉类别 -> 条数
+	TopKeywords  []KeywordCount `json:"top_keywords,omitempty"`
+}
+
+// KeywordCount 关键词及其出现次数
+type KeywordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// EntityMember 实体在某个平台上的记录
+type EntityMember struct {
+	Platform string `json:"platform"`
+	ID       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+}
+
+// EntityCluster 跨平台识别为同一创作者或同一商品的实体簇
+type EntityCluster struct {
+	ClusterID  string          `json:"cluster_id"`
+	EntityType string          `json:"entity_type"` // creator 或 product
+	Members    []*EntityMember `json:"members"`
+	Confidence float64         `json:"confidence"` // 簇内最弱关联的置信度
+	Reasons    []string        `json:"reasons,omitempty"`
+	Manual     bool            `json:"manual,omitempty"` // 是否包含人工指定的关联
+}
+
+// LiveRoom 直播间信息
+type LiveRoom struct {
+	RoomID       string `json:"room_id"`
+	Platform     string `json:"platform,omitempty"`
+	UserID       string `json:"user_id"`
+	Title        string `json:"title"`
+	IsLive       bool   `json:"is_live"`
+	ViewerCount  int    `json:"viewer_count"`  // 当前在线人数
+	TotalViewers int    `json:"total_viewers"` // 累计观看人数
+	Likes        int    `json:"likes"`
+	StartTime    int64  `json:"start_time"`
+}
+
+// LiveSample 直播间定时采样数据
+type LiveSample struct {
+	RoomID       string         `json:"room_id"`
+	Platform     string         `json:"platform,omitempty"`
+	UserID       string         `json:"user_id"`
+	Timestamp    int64          `json:"timestamp"`
+	ViewerCount  int            `json:"viewer_count"`
+	TotalViewers int            `json:"total_viewers"`
+	Likes        int            `json:"likes"`
+	Products     []*ProductInfo `json:"products"` // 采样时的购物车商品
 }
 
 // Scraper 爬虫接口定义
@@ -60,6 +293,12 @@ type Scraper interface {
 	// GetUserInfo 获取用户信息
 	GetUserInfo(userID string) (*UserData, error)
 	
+	// GetFollowers 获取用户的粉丝列表
+	GetFollowers(userID string, cursor string) ([]*UserData, string, error)
+	
+	// GetFollowing 获取用户的关注列表
+	GetFollowing(userID string, cursor string) ([]*UserData, string, error)
+	
 	// GetUserVideos 获取用户视频列表
 	GetUserVideos(userID string, cursor string) ([]*VideoData, string, error)
 	
@@ -68,4 +307,40 @@ type Scraper interface {
 	
 	// GetProductInfo 获取商品信息
 	GetProductInfo(productID string) (*ProductInfo, error)
-}
\ No newline at end of file
+	
+	// GetProductReviews 获取商品评价
+	GetProductReviews(productID string, cursor string) ([]*ProductReview, string, error)
+	
+	// GetShopInfo 获取店铺信息
+	GetShopInfo(shopID string) (*ShopData, error)
+	
+	// GetShopProducts 获取店铺的全部商品
+	GetShopProducts(shopID string, cursor string) ([]*ProductInfo, string, error)
+}
+
+// ReplyScraper 评论回复爬虫接口，支持楼中楼回复的平台可选实现
+type ReplyScraper interface {
+	// GetCommentReplies 获取评论的回复列表
+	GetCommentReplies(videoID string, commentID string, cursor string) ([]*CommentData, string, error)
+}
+
+// ProductScraper 电商商品爬虫接口，只采集商品详情和价格，用于与带货商品比价
+type ProductScraper interface {
+	// Initialize 初始化爬虫
+	Initialize() error
+
+	// SearchProducts 按商品名称搜索商品
+	SearchProducts(keyword string, cursor string) ([]*ProductInfo, string, error)
+
+	// GetProductInfo 获取商品详情
+	GetProductInfo(productID string) (*ProductInfo, error)
+}
+
+// LiveScraper 直播带货数据爬虫接口，支持直播的平台可选实现
+type LiveScraper interface {
+	// GetLiveRoom 获取用户当前的直播间信息，未开播时 IsLive 为 false
+	GetLiveRoom(userID string) (*LiveRoom, error)
+
+	// GetLiveProducts 获取直播间购物车中的商品
+	GetLiveProducts(roomID string) ([]*ProductInfo, error)
+}
@@ -0,0 +1,650 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const xhsAPIHost = "https://edith.xiaohongshu.com"
+
+func init() {
+	Register(PlatformInfo{
+		Name:         Xiaohongshu,
+		DisplayName:  "小红书",
+		LoginURL:     "https://www.xiaohongshu.com/explore",
+		LoginCookies: []string{"web_session"},
+		Headers:      map[string]string{"Referer": "https://www.xiaohongshu.com/", "Origin": "https://www.xiaohongshu.com"},
+		Capabilities: []Capability{CapabilityReplies},
+		New: func(userAgent, cookies string) Scraper {
+			return NewXiaohongshuScraper(userAgent, cookies)
+		},
+		BrowserRoutes: []BrowserRoute{
+			{Path: "/api/sns/web/v1/user/otherinfo", Page: "https://www.xiaohongshu.com/user/profile/{target_user_id}"},
+			{Path: "/api/sns/web/v1/user_posted", Page: "https://www.xiaohongshu.com/user/profile/{user_id}", Cursor: "cursor"},
+			{Path: "/api/sns/web/v2/comment/page", Page: "https://www.xiaohongshu.com/explore/{note_id}?xsec_token={xsec_token}", Cursor: "cursor"},
+			{Path: "/api/store/item/detail", Page: "https://www.xiaohongshu.com/goods-detail/{item_id}"},
+		},
+	})
+}
+
+// XiaohongshuScraper 小红书平台爬虫实现，笔记对应 VideoData，笔记评论对应 CommentData，笔记关联的商品对应 ProductInfo
+type XiaohongshuScraper struct {
+	client    *http.Client
+	userAgent string
+	cookies   string
+
+	// 笔记的 xsec_token，获取笔记详情和评论时需要携带
+	tokens map[string]string
+	mutex  sync.Mutex
+}
+
+// NewXiaohongshuScraper 创建小红书爬虫实例
+func NewXiaohongshuScraper(userAgent, cookies string) *XiaohongshuScraper {
+	return &XiaohongshuScraper{
+		client: &http.Client{
+			Timeout: time.Second * 30,
+		},
+		userAgent: userAgent,
+		cookies:   cookies,
+		tokens:    make(map[string]string),
+	}
+}
+
+// httpClient 返回爬虫使用的 HTTP 客户端，浏览器后端通过它接管请求
+func (s *XiaohongshuScraper) httpClient() *http.Client {
+	return s.client
+}
+
+// Initialize 初始化爬虫
+func (s *XiaohongshuScraper) Initialize() error {
+	// 请求签名依赖 Cookie 中的 a1
+	if cookieValue(s.cookies, "a1") == "" {
+		return errors.New("小红书 Cookie 中缺少 a1，请使用登录后的完整 Cookie")
+	}
+
+	req, err := http.NewRequest("GET", "https://www.xiaohongshu.com/explore", nil)
+	if err != nil {
+		return err
+	}
+
+	setHeaders(req, Xiaohongshu, s.userAgent, s.cookies)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("invalid cookies or blocked by anti-crawler")
+	}
+
+	return nil
+}
+
+// xhsCount 小红书接口中的计数，可能是数字或 "1.2万"、"10w+" 形式的字符串
+type xhsCount int
+
+// UnmarshalJSON 解析数字或字符串形式的计数
+func (c *xhsCount) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	*c = xhsCount(parseCount(text))
+	return nil
+}
+
+// parseCount 解析带“万”“w”等单位的计数
+func parseCount(text string) int {
+	text = strings.TrimSuffix(strings.ReplaceAll(strings.TrimSpace(text), ",", ""), "+")
+
+	multiplier := 1.0
+	for _, unit := range []string{"万", "w", "W"} {
+		if strings.HasSuffix(text, unit) {
+			text = strings.TrimSuffix(text, unit)
+			multiplier = 10000
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0
+	}
+	return int(value * multiplier)
+}
+
+// xhsUser 小红书接口中的用户简要信息
+type xhsUser struct {
+	UserID   string `json:"user_id"`
+	Nickname string `json:"nickname"`
+}
+
+// xhsInteract 笔记互动数据
+type xhsInteract struct {
+	LikedCount     xhsCount `json:"liked_count"`
+	CollectedCount xhsCount `json:"collected_count"`
+	CommentCount   xhsCount `json:"comment_count"`
+	ShareCount     xhsCount `json:"share_count"`
+}
+
+// xhsGoods 笔记中关联的商品
+type xhsGoods struct {
+	GoodsID  string  `json:"goods_id"`
+	Title    string  `json:"title"`
+	Desc     string  `json:"desc"`
+	Price    float64 `json:"price"`
+	SellerID string  `json:"seller_id"`
+}
+
+// xhsNote 笔记信息，列表和详情接口共用
+type xhsNote struct {
+	NoteID       string      `json:"note_id"`
+	DisplayTitle string      `json:"display_title"`
+	Title        string      `json:"title"`
+	Desc         string      `json:"desc"`
+	XsecToken    string      `json:"xsec_token"`
+	User         xhsUser     `json:"user"`
+	InteractInfo xhsInteract `json:"interact_info"`
+	TagList      []struct {
+		Name string `json:"name"`
+	} `json:"tag_list"`
+	Goods []xhsGoods `json:"goods"`
+}
+
+// toVideoData 将笔记转换为通用的视频数据
+func (n *xhsNote) toVideoData() *VideoData {
+	video := &VideoData{
+		VideoID:     n.NoteID,
+		UserID:      n.User.UserID,
+		Title:       n.Title,
+		Description: n.Desc,
+		Likes:       int(n.InteractInfo.LikedCount),
+		Comments:    int(n.InteractInfo.CommentCount),
+		Shares:      int(n.InteractInfo.ShareCount),
+	}
+	if video.Title == "" {
+		video.Title = n.DisplayTitle
+	}
+	for _, tag := range n.TagList {
+		video.Tags = append(video.Tags, tag.Name)
+	}
+	if len(n.Goods) > 0 {
+		goods := n.Goods[0]
+		video.ProductInfo = &ProductInfo{
+			ProductID:   goods.GoodsID,
+			Name:        goods.Title,
+			Price:       goods.Price,
+			Description: goods.Desc,
+			ShopID:      goods.SellerID,
+		}
+	}
+	return video
+}
+
+// xhsComment 笔记评论
+type xhsComment struct {
+	ID              string   `json:"id"`
+	NoteID          string   `json:"note_id"`
+	Content         string   `json:"content"`
+	LikeCount       xhsCount `json:"like_count"`
+	SubCommentCount xhsCount `json:"sub_comment_count"`
+	CreateTime      int64    `json:"create_time"` // 毫秒
+	UserInfo        xhsUser  `json:"user_info"`
+	TargetComment   *struct {
+		ID       string  `json:"id"`
+		UserInfo xhsUser `json:"user_info"`
+	} `json:"target_comment"`
+}
+
+// toCommentData 将笔记评论转换为通用的评论数据，parentID 为所属一级评论ID
+func (c *xhsComment) toCommentData(noteID, parentID string) *CommentData {
+	comment := &CommentData{
+		CommentID: c.ID,
+		VideoID:   noteID,
+		UserID:    c.UserInfo.UserID,
+		ParentID:  parentID,
+		Content:   c.Content,
+		Likes:     int(c.LikeCount),
+		Replies:   int(c.SubCommentCount),
+		Timestamp: c.CreateTime / 1000,
+	}
+	if c.TargetComment != nil {
+		comment.ReplyToUserID = c.TargetComment.UserInfo.UserID
+	}
+	return comment
+}
+
+// xhsItem 商城商品
+type xhsItem struct {
+	ItemID        string  `json:"item_id"`
+	Name          string  `json:"name"`
+	Price         float64 `json:"price"`
+	OriginalPrice float64 `json:"original_price"`
+	Desc          string  `json:"desc"`
+	Sales         int     `json:"sales"`
+	SellerID      string  `json:"seller_id"`
+	ShipFrom      string  `json:"ship_from"`
+	OnSale        *bool   `json:"on_sale"`
+	Skus          []struct {
+		SkuID         string  `json:"sku_id"`
+		Name          string  `json:"name"`
+		Price         float64 `json:"price"`
+		OriginalPrice float64 `json:"original_price"`
+		Stock         int     `json:"stock"`
+	} `json:"skus"`
+	Rating *struct {
+		Score        float64 `json:"score"`
+		CommentCount int     `json:"comment_count"`
+		GoodRate     float64 `json:"good_rate"`
+	} `json:"rating"`
+}
+
+// toProductInfo 将商城商品转换为通用的商品信息
+func (i *xhsItem) toProductInfo() *ProductInfo {
+	product := &ProductInfo{
+		ProductID:     i.ItemID,
+		Name:          i.Name,
+		Price:         i.Price,
+		OriginalPrice: i.OriginalPrice,
+		Description:   i.Desc,
+		Sales:         i.Sales,
+		ShopID:        i.SellerID,
+		ShipFrom:      i.ShipFrom,
+		OffShelf:      i.OnSale != nil && !*i.OnSale,
+	}
+	for _, sku := range i.Skus {
+		product.SKUs = append(product.SKUs, &ProductSKU{
+			SkuID:         sku.SkuID,
+			Name:          sku.Name,
+			Price:         sku.Price,
+			OriginalPrice: sku.OriginalPrice,
+			Stock:         sku.Stock,
+		})
+	}
+	if i.Rating != nil {
+		product.Rating = &RatingSummary{
+			Score:       i.Rating.Score,
+			ReviewCount: i.Rating.CommentCount,
+			GoodRate:    i.Rating.GoodRate,
+		}
+	}
+	product.FillPriceRange()
+	return product
+}
+
+// request 发送带签名的请求，校验通用响应结构并将 data 解析到 out
+func (s *XiaohongshuScraper) request(method, path string, params url.Values, payload interface{}, out interface{}) error {
+	uri := path
+	if len(params) > 0 {
+		uri += "?" + params.Encode()
+	}
+
+	// 请求体使用紧凑格式且不转义 HTML 字符，与签名时的内容保持一致
+	var body []byte
+	if payload != nil {
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(payload); err != nil {
+			return err
+		}
+		body = bytes.TrimSpace(buffer.Bytes())
+	}
+
+	req, err := http.NewRequest(method, xhsAPIHost+uri, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+
+	// 设置请求头
+	setHeaders(req, Xiaohongshu, s.userAgent, s.cookies)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json;charset=UTF-8")
+	}
+	for key, value := range xhsSign(uri, body, cookieValue(s.cookies, "a1"), time.Now()) {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应内容
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// 461 表示触发了验证码或签名失效
+	if resp.StatusCode == 461 {
+		return fmt.Errorf("%w: 小红书接口返回状态码 %d", ErrChallenge, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("小红书接口返回状态码 %d", resp.StatusCode)
+	}
+
+	// 解析JSON响应
+	var result struct {
+		Code    int             `json:"code"`
+		Success bool            `json:"success"`
+		Msg     string          `json:"msg"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return err
+	}
+	// -100 表示登录已过期
+	if result.Code == -100 {
+		return fmt.Errorf("%w: 小红书接口返回 %d %s", ErrAuthExpired, result.Code, result.Msg)
+	}
+	if !result.Success {
+		return fmt.Errorf("小红书接口返回错误: %d %s", result.Code, result.Msg)
+	}
+
+	return json.Unmarshal(result.Data, out)
+}
+
+// rememberToken 记录笔记的 xsec_token
+func (s *XiaohongshuScraper) rememberToken(noteID, token string) {
+	if token == "" {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tokens[noteID] = token
+}
+
+// token 返回笔记的 xsec_token
+func (s *XiaohongshuScraper) token(noteID string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.tokens[noteID]
+}
+
+// GetUserInfo 获取用户信息
+func (s *XiaohongshuScraper) GetUserInfo(userID string) (*UserData, error) {
+	var result struct {
+		BasicInfo struct {
+			Nickname   string `json:"nickname"`
+			Desc       string `json:"desc"`
+			IPLocation string `json:"ip_location"`
+		} `json:"basic_info"`
+		Interactions []struct {
+			Type  string   `json:"type"`
+			Count xhsCount `json:"count"`
+		} `json:"interactions"`
+		Tags []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
+	}
+	params := url.Values{"target_user_id": {userID}}
+	if err := s.request("GET", "/api/sns/web/v1/user/otherinfo", params, nil, &result); err != nil {
+		return nil, err
+	}
+
+	user := &UserData{
+		UserID:      userID,
+		Nickname:    result.BasicInfo.Nickname,
+		Description: result.BasicInfo.Desc,
+	}
+	for _, interaction := range result.Interactions {
+		switch interaction.Type {
+		case "fans":
+			user.Followers = int(interaction.Count)
+		case "follows":
+			user.Following = int(interaction.Count)
+		}
+	}
+	for _, tag := range result.Tags {
+		user.Tags = append(user.Tags, tag.Name)
+	}
+	// IP 属地作为标签保留，便于识别地区
+	if result.BasicInfo.IPLocation != "" {
+		user.Tags = append(user.Tags, result.BasicInfo.IPLocation)
+	}
+
+	return user, nil
+}
+
+// GetFollowers 获取用户的粉丝列表，小红书网页端不公开粉丝列表
+func (s *XiaohongshuScraper) GetFollowers(userID string, cursor string) ([]*UserData, string, error) {
+	return nil, "", fmt.Errorf("%w: 小红书网页端不公开粉丝列表", ErrNotSupported)
+}
+
+// GetFollowing 获取用户的关注列表，小红书网页端不公开关注列表
+func (s *XiaohongshuScraper) GetFollowing(userID string, cursor string) ([]*UserData, string, error) {
+	return nil, "", fmt.Errorf("%w: 小红书网页端不公开关注列表", ErrNotSupported)
+}
+
+// GetUserVideos 获取用户笔记列表，并逐条获取笔记详情补全描述、标签、互动数据和关联商品
+func (s *XiaohongshuScraper) GetUserVideos(userID string, cursor string) ([]*VideoData, string, error) {
+	var result struct {
+		Notes   []*xhsNote `json:"notes"`
+		Cursor  string     `json:"cursor"`
+		HasMore bool       `json:"has_more"`
+	}
+	params := url.Values{
+		"num":           {"30"},
+		"cursor":        {cursor},
+		"user_id":       {userID},
+		"image_formats": {"jpg,webp,avif"},
+	}
+	if err := s.request("GET", "/api/sns/web/v1/user_posted", params, nil, &result); err != nil {
+		return nil, "", err
+	}
+
+	videos := make([]*VideoData, 0, len(result.Notes))
+	for _, note := range result.Notes {
+		s.rememberToken(note.NoteID, note.XsecToken)
+
+		// 详情获取失败时保留列表中的简要信息
+		if detail, err := s.getNote(note.NoteID, "pc_user"); err == nil {
+			note = detail
+		}
+		if note.User.UserID == "" {
+			note.User.UserID = userID
+		}
+		videos = append(videos, note.toVideoData())
+	}
+
+	if !result.HasMore {
+		return videos, "", nil
+	}
+	return videos, result.Cursor, nil
+}
+
+// getNote 获取笔记详情
+func (s *XiaohongshuScraper) getNote(noteID, source string) (*xhsNote, error) {
+	var result struct {
+		Items []struct {
+			NoteCard *xhsNote `json:"note_card"`
+		} `json:"items"`
+	}
+	payload := map[string]interface{}{
+		"source_note_id": noteID,
+		"image_formats":  []string{"jpg", "webp", "avif"},
+		"extra":          map[string]string{"need_body_topic": "1"},
+		"xsec_source":    source,
+		"xsec_token":     s.token(noteID),
+	}
+	if err := s.request("POST", "/api/sns/web/v1/feed", nil, payload, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Items) == 0 || result.Items[0].NoteCard == nil {
+		return nil, fmt.Errorf("笔记 %s 不存在或不可见", noteID)
+	}
+
+	note := result.Items[0].NoteCard
+	note.NoteID = noteID
+	return note, nil
+}
+
+// GetVideoComments 获取笔记评论
+func (s *XiaohongshuScraper) GetVideoComments(videoID string, cursor string) ([]*CommentData, string, error) {
+	var result struct {
+		Comments []*xhsComment `json:"comments"`
+		Cursor   string        `json:"cursor"`
+		HasMore  bool          `json:"has_more"`
+	}
+	params := url.Values{
+		"note_id":        {videoID},
+		"cursor":         {cursor},
+		"top_comment_id": {""},
+		"image_formats":  {"jpg,webp,avif"},
+		"xsec_token":     {s.token(videoID)},
+	}
+	if err := s.request("GET", "/api/sns/web/v2/comment/page", params, nil, &result); err != nil {
+		return nil, "", err
+	}
+
+	comments := make([]*CommentData, 0, len(result.Comments))
+	for _, comment := range result.Comments {
+		comments = append(comments, comment.toCommentData(videoID, ""))
+	}
+
+	if !result.HasMore {
+		return comments, "", nil
+	}
+	return comments, result.Cursor, nil
+}
+
+// GetCommentReplies 获取评论的回复列表
+func (s *XiaohongshuScraper) GetCommentReplies(videoID string, commentID string, cursor string) ([]*CommentData, string, error) {
+	var result struct {
+		Comments []*xhsComment `json:"comments"`
+		Cursor   string        `json:"cursor"`
+		HasMore  bool          `json:"has_more"`
+	}
+	params := url.Values{
+		"note_id":         {videoID},
+		"root_comment_id": {commentID},
+		"num":             {"10"},
+		"cursor":          {cursor},
+		"image_formats":   {"jpg,webp,avif"},
+		"xsec_token":      {s.token(videoID)},
+	}
+	if err := s.request("GET", "/api/sns/web/v2/comment/sub/page", params, nil, &result); err != nil {
+		return nil, "", err
+	}
+
+	replies := make([]*CommentData, 0, len(result.Comments))
+	for _, reply := range result.Comments {
+		replies = append(replies, reply.toCommentData(videoID, commentID))
+	}
+
+	if !result.HasMore {
+		return replies, "", nil
+	}
+	return replies, result.Cursor, nil
+}
+
+// GetProductInfo 获取商品信息
+func (s *XiaohongshuScraper) GetProductInfo(productID string) (*ProductInfo, error) {
+	var result xhsItem
+	params := url.Values{"item_id": {productID}}
+	if err := s.request("GET", "/api/store/item/detail", params, nil, &result); err != nil {
+		return nil, err
+	}
+
+	result.ItemID = productID
+	return result.toProductInfo(), nil
+}
+
+// GetProductReviews 获取商品评价
+func (s *XiaohongshuScraper) GetProductReviews(productID string, cursor string) ([]*ProductReview, string, error) {
+	var result struct {
+		Comments []struct {
+			ID         string `json:"id"`
+			UserID     string `json:"user_id"`
+			Content    string `json:"content"`
+			Score      int    `json:"score"`
+			SkuDesc    string `json:"sku_desc"`
+			CreateTime int64  `json:"create_time"` // 毫秒
+		} `json:"comments"`
+		Cursor  string `json:"cursor"`
+		HasMore bool   `json:"has_more"`
+	}
+	params := url.Values{"item_id": {productID}, "cursor": {cursor}}
+	if err := s.request("GET", "/api/store/item/comments", params, nil, &result); err != nil {
+		return nil, "", err
+	}
+
+	reviews := make([]*ProductReview, 0, len(result.Comments))
+	for _, comment := range result.Comments {
+		reviews = append(reviews, &ProductReview{
+			ReviewID:  comment.ID,
+			ProductID: productID,
+			UserID:    comment.UserID,
+			Content:   comment.Content,
+			Rating:    comment.Score,
+			SkuName:   comment.SkuDesc,
+			Timestamp: comment.CreateTime / 1000,
+		})
+	}
+
+	if !result.HasMore {
+		return reviews, "", nil
+	}
+	return reviews, result.Cursor, nil
+}
+
+// GetShopInfo 获取店铺信息
+func (s *XiaohongshuScraper) GetShopInfo(shopID string) (*ShopData, error) {
+	var result struct {
+		Name      string   `json:"name"`
+		Score     float64  `json:"score"`
+		FansCount xhsCount `json:"fans_count"`
+		Category  string   `json:"category"`
+		Location  string   `json:"location"`
+	}
+	params := url.Values{"seller_id": {shopID}}
+	if err := s.request("GET", "/api/store/seller/info", params, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &ShopData{
+		ShopID:    shopID,
+		Name:      result.Name,
+		Rating:    result.Score,
+		Followers: int(result.FansCount),
+		Category:  result.Category,
+		Location:  result.Location,
+	}, nil
+}
+
+// GetShopProducts 获取店铺的全部商品
+func (s *XiaohongshuScraper) GetShopProducts(shopID string, cursor string) ([]*ProductInfo, string, error) {
+	var result struct {
+		Items   []*xhsItem `json:"items"`
+		Cursor  string     `json:"cursor"`
+		HasMore bool       `json:"has_more"`
+	}
+	params := url.Values{"seller_id": {shopID}, "cursor": {cursor}}
+	if err := s.request("GET", "/api/store/seller/items", params, nil, &result); err != nil {
+		return nil, "", err
+	}
+
+	products := make([]*ProductInfo, 0, len(result.Items))
+	for _, item := range result.Items {
+		if item.SellerID == "" {
+			item.SellerID = shopID
+		}
+		products = append(products, item.toProductInfo())
+	}
+
+	if !result.HasMore {
+		return products, "", nil
+	}
+	return products, result.Cursor, nil
+}
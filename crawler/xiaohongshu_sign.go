@@ -0,0 +1,88 @@
+package crawler
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"hash/crc32"
+	"strconv"
+	"time"
+)
+
+// 小红书 Web 端签名使用的自定义 Base64 字母表
+const (
+	xhsSignAlphabet   = "A4NjFqYu5wPHsO0XTdDgMa2r1ZQocVte9UJBvk6/7=yRnhISGKblCWi+LpfE8xzm3" // X-s，第 65 个字符用于补位
+	xhsCommonAlphabet = "ZmserbBoHQtNP+wOcza/LpngG8yJq42KWYj0DSfdikx3VT16IlUAFM97hECvuRX5"  // X-s-common
+)
+
+// xhsCommon X-s-common 请求头的内容，字段顺序与网页端一致
+type xhsCommon struct {
+	S0  int    `json:"s0"`
+	S1  string `json:"s1"`
+	X0  string `json:"x0"`
+	X1  string `json:"x1"`
+	X2  string `json:"x2"`
+	X3  string `json:"x3"`
+	X4  string `json:"x4"`
+	X5  string `json:"x5"`
+	X6  string `json:"x6"`
+	X7  string `json:"x7"`
+	X8  string `json:"x8"`
+	X9  uint32 `json:"x9"`
+	X10 int    `json:"x10"`
+}
+
+// xhsSign 计算小红书 Web API 的请求签名，返回需要附加的 X-s、X-t 和 X-s-common 请求头。
+// uri 为包含查询参数的请求路径，body 为 POST 请求的 JSON 请求体，a1 取自 Cookie。
+// 网页端的签名算法会不定期更新，接口返回 461 或签名错误时需要同步更新
+func xhsSign(uri string, body []byte, a1 string, now time.Time) map[string]string {
+	xt := strconv.FormatInt(now.UnixMilli(), 10)
+
+	// X-s：时间戳、固定盐、路径和请求体的 MD5，再做自定义 Base64
+	sum := md5.Sum([]byte(xt + "test" + uri + string(body)))
+	xs := customBase64([]byte(hex.EncodeToString(sum[:])), xhsSignAlphabet, xhsSignAlphabet[64])
+
+	common, _ := json.Marshal(xhsCommon{
+		S0:  5,
+		X0:  "1",
+		X1:  "3.2.0",
+		X2:  "Windows",
+		X3:  "xhs-pc-web",
+		X4:  "2.3.1",
+		X5:  a1,
+		X6:  xt,
+		X7:  xs,
+		X9:  crc32.ChecksumIEEE([]byte(xt+xs)) ^ crc32.IEEE,
+		X10: 1,
+	})
+
+	return map[string]string{
+		"X-s":        xs,
+		"X-t":        xt,
+		"X-s-common": customBase64(common, xhsCommonAlphabet, '='),
+	}
+}
+
+// customBase64 使用自定义字母表进行 Base64 编码
+func customBase64(data []byte, alphabet string, pad byte) string {
+	var buffer bytes.Buffer
+	for i := 0; i < len(data); i += 3 {
+		var chunk [3]byte
+		n := copy(chunk[:], data[i:])
+
+		buffer.WriteByte(alphabet[chunk[0]>>2])
+		buffer.WriteByte(alphabet[(chunk[0]&3)<<4|chunk[1]>>4])
+		if n > 1 {
+			buffer.WriteByte(alphabet[(chunk[1]&15)<<2|chunk[2]>>6])
+		} else {
+			buffer.WriteByte(pad)
+		}
+		if n > 2 {
+			buffer.WriteByte(alphabet[chunk[2]&63])
+		} else {
+			buffer.WriteByte(pad)
+		}
+	}
+	return buffer.String()
+}
@@ -0,0 +1,313 @@
+package crawler
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// newTestXiaohongshuScraper 创建请求转发到测试服务器的小红书爬虫
+func newTestXiaohongshuScraper(t *testing.T, routes map[string]string) (*XiaohongshuScraper, *fixtureServer) {
+	server := newFixtureServer(t, routes)
+	s := NewXiaohongshuScraper("test-agent", "a1=18c4a1b2c3dxhsa1; web_session=test")
+	s.client = server.client()
+	return s, server
+}
+
+func TestXiaohongshuGetUserInfo(t *testing.T) {
+	s, _ := newTestXiaohongshuScraper(t, map[string]string{
+		"/api/sns/web/v1/user/otherinfo": "xhs_user_otherinfo.json",
+	})
+
+	user, err := s.GetUserInfo("5ff0e6410000000001008400")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UserID != "5ff0e6410000000001008400" || user.Nickname != "果园小王" || user.Description != "烟台苹果种植户，产地直发" {
+		t.Errorf("用户基本信息 %+v", user)
+	}
+	if user.Followers != 15000 || user.Following != 128 {
+		t.Errorf("粉丝数 %d 关注数 %d，期望 15000 和 128", user.Followers, user.Following)
+	}
+	if len(user.Tags) != 2 || user.Tags[0] != "水果" || user.Tags[1] != "山东" {
+		t.Errorf("标签 %v，期望 [水果 山东]", user.Tags)
+	}
+}
+
+func TestXiaohongshuGetUserVideos(t *testing.T) {
+	s, server := newTestXiaohongshuScraper(t, map[string]string{
+		"/api/sns/web/v1/user_posted": "xhs_user_posted.json",
+		"/api/sns/web/v1/feed":        "xhs_feed.json",
+	})
+
+	videos, cursor, err := s.GetUserVideos("5ff0e6410000000001008400", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor != "6650a1b2000000001e00c0de" {
+		t.Errorf("游标 %q，期望列表返回的 cursor", cursor)
+	}
+	if len(videos) != 2 {
+		t.Fatalf("获取到 %d 条笔记，期望 2 条", len(videos))
+	}
+
+	video := videos[0]
+	if video.VideoID != "6650a1b2000000001e00c0de" || video.UserID != "5ff0e6410000000001008400" {
+		t.Errorf("笔记ID %q 用户ID %q", video.VideoID, video.UserID)
+	}
+	if video.Title != "今年第一批烟台红富士上市啦" || video.Description != "脆甜多汁，产地直发 #烟台苹果 #红富士" {
+		t.Errorf("笔记标题 %q 描述 %q，期望使用详情中的内容", video.Title, video.Description)
+	}
+	if video.Likes != 23000 || video.Comments != 356 || video.Shares != 87 {
+		t.Errorf("点赞 %d 评论 %d 分享 %d", video.Likes, video.Comments, video.Shares)
+	}
+	if len(video.Tags) != 2 || video.Tags[0] != "烟台苹果" {
+		t.Errorf("标签 %v", video.Tags)
+	}
+
+	product := video.ProductInfo
+	if product == nil {
+		t.Fatal("笔记关联的商品未转换为 ProductInfo")
+	}
+	if product.ProductID != "64f1c0de9a0b1c0001a2b3c4" || product.Name != "烟台红富士苹果 5斤装 80mm+" ||
+		product.Price != 39.9 || product.ShopID != "5ff0e6410000000001008401" {
+		t.Errorf("关联商品 %+v", product)
+	}
+
+	// 获取笔记详情的请求需要签名
+	feed := server.request("/api/sns/web/v1/feed")
+	if feed == nil {
+		t.Fatal("未请求笔记详情")
+	}
+	for _, header := range []string{"X-s", "X-t", "X-s-common"} {
+		if feed.Header.Get(header) == "" {
+			t.Errorf("笔记详情请求缺少签名请求头 %s", header)
+		}
+	}
+}
+
+func TestXiaohongshuGetUserVideosWithoutDetail(t *testing.T) {
+	// 详情接口不可用时保留列表中的简要信息
+	s, _ := newTestXiaohongshuScraper(t, map[string]string{
+		"/api/sns/web/v1/user_posted": "xhs_user_posted.json",
+	})
+
+	videos, _, err := s.GetUserVideos("5ff0e6410000000001008400", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(videos) != 2 {
+		t.Fatalf("获取到 %d 条笔记，期望 2 条", len(videos))
+	}
+	if videos[0].Title != "今年第一批红富士" || videos[0].Likes != 23000 {
+		t.Errorf("第一条笔记 %+v，期望使用列表中的标题和点赞数", videos[0])
+	}
+	if videos[1].UserID != "5ff0e6410000000001008400" {
+		t.Errorf("列表中缺少用户ID时 UserID = %q，期望使用请求的用户ID", videos[1].UserID)
+	}
+	if videos[0].ProductInfo != nil {
+		t.Error("列表中没有商品时不应生成 ProductInfo")
+	}
+}
+
+func TestXiaohongshuGetVideoComments(t *testing.T) {
+	s, server := newTestXiaohongshuScraper(t, map[string]string{
+		"/api/sns/web/v2/comment/page": "xhs_comment_page.json",
+	})
+	s.rememberToken("6650a1b2000000001e00c0de", "ABtoken1")
+
+	comments, cursor, err := s.GetVideoComments("6650a1b2000000001e00c0de", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor != "" {
+		t.Errorf("没有更多评论时游标 %q，期望为空", cursor)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("获取到 %d 条评论，期望 2 条", len(comments))
+	}
+
+	first := comments[0]
+	if first.CommentID != "6651c0de000000001f00aaaa" || first.VideoID != "6650a1b2000000001e00c0de" ||
+		first.UserID != "60a1b2c3000000000100abcd" || first.Content != "去年买过，真的很甜" {
+		t.Errorf("第一条评论 %+v", first)
+	}
+	if first.Likes != 12000 || first.Replies != 12 {
+		t.Errorf("点赞 %d 回复 %d，期望 12000 和 12", first.Likes, first.Replies)
+	}
+	if first.Timestamp != 1716600000 {
+		t.Errorf("评论时间 %d，期望毫秒时间戳转换为秒", first.Timestamp)
+	}
+	if first.ParentID != "" || first.ReplyToUserID != "" {
+		t.Errorf("一级评论 ParentID %q ReplyToUserID %q，期望为空", first.ParentID, first.ReplyToUserID)
+	}
+
+	second := comments[1]
+	if second.Likes != 3 || second.Timestamp != 1716603600 {
+		t.Errorf("第二条评论点赞 %d 时间 %d", second.Likes, second.Timestamp)
+	}
+	if second.ReplyToUserID != "60a1b2c3000000000100abcd" {
+		t.Errorf("ReplyToUserID %q，期望被回复评论的用户ID", second.ReplyToUserID)
+	}
+
+	// 评论请求携带笔记的 xsec_token
+	req := server.request("/api/sns/web/v2/comment/page")
+	if token := req.URL.Query().Get("xsec_token"); token != "ABtoken1" {
+		t.Errorf("xsec_token = %q，期望 ABtoken1", token)
+	}
+}
+
+func TestXiaohongshuGetCommentReplies(t *testing.T) {
+	s, _ := newTestXiaohongshuScraper(t, map[string]string{
+		"/api/sns/web/v2/comment/sub/page": "xhs_comment_page.json",
+	})
+
+	replies, _, err := s.GetCommentReplies("6650a1b2000000001e00c0de", "6651c0de000000001f00aaaa", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, reply := range replies {
+		if reply.ParentID != "6651c0de000000001f00aaaa" {
+			t.Errorf("回复 %s 的 ParentID %q，期望所属一级评论ID", reply.CommentID, reply.ParentID)
+		}
+	}
+}
+
+func TestXiaohongshuGetProductInfo(t *testing.T) {
+	s, _ := newTestXiaohongshuScraper(t, map[string]string{
+		"/api/store/item/detail": "xhs_item_detail.json",
+	})
+
+	product, err := s.GetProductInfo("64f1c0de9a0b1c0001a2b3c4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.ProductID != "64f1c0de9a0b1c0001a2b3c4" {
+		t.Errorf("商品ID %q，期望使用请求的商品ID", product.ProductID)
+	}
+	if product.Name != "烟台红富士苹果 5斤装" || product.Price != 39.9 || product.OriginalPrice != 59.9 ||
+		product.Sales != 3200 || product.ShopID != "5ff0e6410000000001008401" || product.ShipFrom != "山东烟台" {
+		t.Errorf("商品信息 %+v", product)
+	}
+	if !product.OffShelf {
+		t.Error("on_sale 为 false 时应标记为下架")
+	}
+	if len(product.SKUs) != 2 || product.SKUs[1].SkuID != "s2" || product.SKUs[1].Price != 69.9 || product.SKUs[1].Stock != 0 {
+		t.Errorf("规格 %+v", product.SKUs)
+	}
+	if product.MinPrice != 39.9 || product.MaxPrice != 69.9 {
+		t.Errorf("价格区间 %.1f-%.1f，期望按规格计算为 39.9-69.9", product.MinPrice, product.MaxPrice)
+	}
+	if product.Rating == nil || product.Rating.Score != 4.8 || product.Rating.ReviewCount != 860 || product.Rating.GoodRate != 0.97 {
+		t.Errorf("评价汇总 %+v", product.Rating)
+	}
+}
+
+func TestXiaohongshuRequestErrors(t *testing.T) {
+	s, _ := newTestXiaohongshuScraper(t, nil)
+
+	// 未配置的接口返回 404
+	if _, err := s.GetProductInfo("missing"); err == nil {
+		t.Error("接口返回 404 时应返回错误")
+	}
+}
+
+func TestXhsSign(t *testing.T) {
+	const a1 = "18c4a1b2c3dxhsa1"
+	now := time.UnixMilli(1716600000000)
+
+	tests := []struct {
+		name string
+		uri  string
+		body string
+		xs   string
+	}{
+		{"GET", "/api/sns/web/v1/user_posted?num=30", "", "O65lO2TL1iTLsiMp0gZB1iF+OgVUOj4kO2MpZ2sWOiT3"},
+		{"POST", "/api/sns/web/v1/feed", `{"source_note_id":"6650a1b2000000001e00c0de"}`, "s25psj9COYOUsl9LsBcbZB1W0jAC12sbZYs+ZB5i12F3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := xhsSign(tt.uri, []byte(tt.body), a1, now)
+			if headers["X-t"] != "1716600000000" {
+				t.Errorf("X-t = %q，期望毫秒时间戳", headers["X-t"])
+			}
+			if headers["X-s"] != tt.xs {
+				t.Errorf("X-s = %q，期望 %q", headers["X-s"], tt.xs)
+			}
+
+			// X-s 解码后为时间戳、盐、路径和请求体的 MD5
+			encoding := base64.NewEncoding(xhsSignAlphabet[:64]).WithPadding(rune(xhsSignAlphabet[64]))
+			decoded, err := encoding.DecodeString(headers["X-s"])
+			if err != nil {
+				t.Fatalf("解码 X-s 失败: %v", err)
+			}
+			sum := md5.Sum([]byte("1716600000000test" + tt.uri + tt.body))
+			if string(decoded) != hex.EncodeToString(sum[:]) {
+				t.Errorf("X-s 解码为 %q，期望 %x", decoded, sum)
+			}
+
+			// X-s-common 解码后为包含 a1、X-t 和 X-s 的 JSON
+			decoded, err = base64.NewEncoding(xhsCommonAlphabet).DecodeString(headers["X-s-common"])
+			if err != nil {
+				t.Fatalf("解码 X-s-common 失败: %v", err)
+			}
+			var common xhsCommon
+			if err := json.Unmarshal(decoded, &common); err != nil {
+				t.Fatalf("解析 X-s-common 失败: %v", err)
+			}
+			if common.X5 != a1 || common.X6 != headers["X-t"] || common.X7 != headers["X-s"] {
+				t.Errorf("X-s-common %+v，期望 x5=a1 x6=X-t x7=X-s", common)
+			}
+		})
+	}
+}
+
+func TestCustomBase64(t *testing.T) {
+	// 使用标准字母表时结果与标准 Base64 一致
+	const standard = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	for _, input := range []string{"", "f", "fo", "foo", "foob", "fooba", "foobar"} {
+		if got, want := customBase64([]byte(input), standard, '='), base64.StdEncoding.EncodeToString([]byte(input)); got != want {
+			t.Errorf("customBase64(%q) = %q，期望 %q", input, got, want)
+		}
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	tests := map[string]int{
+		"128":   128,
+		"1.2万":  12000,
+		"10w+":  100000,
+		"1,024": 1024,
+		"":      0,
+		"未知":    0,
+	}
+	for text, want := range tests {
+		if got := parseCount(text); got != want {
+			t.Errorf("parseCount(%q) = %d，期望 %d", text, got, want)
+		}
+	}
+}
+
+func TestCookieValue(t *testing.T) {
+	cookies := "a1=18c4a1b2c3dxhsa1; web_session=abc; webId=xyz"
+	if got := cookieValue(cookies, "web_session"); got != "abc" {
+		t.Errorf("cookieValue(web_session) = %q，期望 abc", got)
+	}
+	if got := cookieValue(cookies, "missing"); got != "" {
+		t.Errorf("cookieValue(missing) = %q，期望为空", got)
+	}
+
+	updated := setCookieValue(cookies, "web_session", "def")
+	if got := cookieValue(updated, "web_session"); got != "def" {
+		t.Errorf("更新后 web_session = %q，期望 def", got)
+	}
+	if got := cookieValue(updated, "a1"); got != "18c4a1b2c3dxhsa1" {
+		t.Errorf("更新后 a1 = %q，期望保持不变", got)
+	}
+	if got := cookieValue(setCookieValue(cookies, "ttwid", "t1"), "ttwid"); got != "t1" {
+		t.Errorf("新增的 ttwid = %q，期望 t1", got)
+	}
+}
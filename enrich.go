@@ -0,0 +1,36 @@
+package main
+
+import (
+	"Crawler/crawler"
+)
+
+// enrichVideo 对视频进行农产品分类、产地提取和价格归一化，返回 false 表示应丢弃该视频
+func (c *Crawler) enrichVideo(video *crawler.VideoData) bool {
+	if !c.classifier.Keep(c.classifier.ClassifyVideo(video)) {
+		return false
+	}
+
+	c.originExtractor.ExtractVideo(video)
+	if video.ProductInfo != nil {
+		c.originExtractor.ExtractProduct(video.ProductInfo)
+		c.priceNormalizer.NormalizeProduct(video.ProductInfo)
+	}
+
+	return true
+}
+
+// enrichProduct 对商品进行农产品分类、产地提取和价格归一化，返回 false 表示应丢弃该商品
+func (c *Crawler) enrichProduct(product *crawler.ProductInfo) bool {
+	if !c.classifier.Keep(c.classifier.ClassifyProduct(product)) {
+		return false
+	}
+
+	c.originExtractor.ExtractProduct(product)
+	c.priceNormalizer.NormalizeProduct(product)
+	return true
+}
+
+// enrichComment 对评论进行垃圾评论检测，返回 false 表示应丢弃该评论
+func (c *Crawler) enrichComment(comment *crawler.CommentData) bool {
+	return c.spamDetector.Check(comment)
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/sentiment"
+	"Crawler/utils/spam"
+	"Crawler/utils/storage"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// exportOptions 导出命令参数
+type exportOptions struct {
+	inputDir    string
+	exportDir   string
+	platform    string
+	topKeywords int
+	excludeSpam bool
+}
+
+// runExport 导出命令：离线分析采集结果中的评论和商品评价，将分析结果写回数据文件和数据库，
+// 并按视频和商品导出汇总
+func runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	inputDir := flags.String("input", "output", "采集结果目录")
+	exportDir := flags.String("output", "export", "导出目录")
+	platform := flags.String("platform", "douyin", "数据所属平台，仅用于未标记平台的旧数据 (douyin 或 kuaishou)")
+	configFile := flags.String("config", "", "配置文件，启用 db_config 时将分析结果同时写入数据库")
+	lexiconFile := flags.String("lexicon", "", "自定义情感词典文件（JSON），为空时使用内置词典")
+	dictFile := flags.String("dict", "", "附加分词词典文件，每行一个词")
+	topKeywords := flags.Int("top-keywords", 10, "每个视频或商品导出的高频关键词数")
+	spamThreshold := flags.Float64("spam-threshold", 0.6, "判定为垃圾评论的最低得分")
+	excludeSpam := flags.Bool("exclude-spam", false, "汇总时排除判定为垃圾的评论")
+	flags.Parse(args)
+
+	// 初始化评论分析器
+	analyzer, err := sentiment.NewAnalyzer(sentiment.Config{
+		Enabled:     true,
+		LexiconFile: *lexiconFile,
+		DictFile:    *dictFile,
+	})
+	if err != nil {
+		log.Fatalf("评论分析器初始化失败: %v", err)
+	}
+
+	// 按配置连接数据库
+	store, err := openStore(*configFile)
+	if err != nil {
+		log.Fatalf("存储初始化失败: %v", err)
+	}
+	defer store.Close()
+
+	if err := os.MkdirAll(*exportDir, 0755); err != nil {
+		log.Fatalf("创建导出目录失败: %v", err)
+	}
+
+	options := exportOptions{
+		inputDir:    *inputDir,
+		exportDir:   *exportDir,
+		platform:    *platform,
+		topKeywords: *topKeywords,
+		excludeSpam: *excludeSpam,
+	}
+	detector := spam.NewDetector(spam.Config{
+		Enabled:   true,
+		Threshold: *spamThreshold,
+	})
+	if err := exportFeedback(analyzer, detector, store, options); err != nil {
+		log.Fatalf("导出评论分析失败: %v", err)
+	}
+}
+
+// exportFeedback 分析全部评论和商品评价并导出明细和汇总。
+// 视频评论计入所属视频，若视频挂载了商品也计入该商品；商品评价计入对应商品。
+// 垃圾评论检测需要看到全部评论，因此先读取全部评论再逐条评分。
+func exportFeedback(analyzer *sentiment.Analyzer, detector *spam.Detector, store *storage.Manager, options exportOptions) error {
+	// 视频挂载的商品
+	videoProducts := make(map[string]string)
+	err := forEachFile(options.inputDir, "video_*.json", func(path string, data []byte) error {
+		var video crawler.VideoData
+		if err := json.Unmarshal(data, &video); err != nil {
+			return err
+		}
+		if video.ProductInfo != nil && video.ProductInfo.ProductID != "" {
+			videoProducts[video.VideoID] = video.ProductInfo.ProductID
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	aggregator := sentiment.NewAggregator()
+	rows := [][]string{{"source_type", "source_id", "target_id", "sentiment", "label", "complaints", "keywords", "spam_score", "content"}}
+
+	// 读取视频评论并记录到垃圾评论检测器
+	var paths []string
+	var comments []*crawler.CommentData
+	err = forEachFile(options.inputDir, "comment_*.json", func(path string, data []byte) error {
+		var comment crawler.CommentData
+		if err := json.Unmarshal(data, &comment); err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		comments = append(comments, &comment)
+		detector.Observe(&comment)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 分析视频评论
+	spamCount := 0
+	for i, comment := range comments {
+		detector.Score(comment)
+		analysis := analyzer.AnalyzeComment(comment)
+		rows = append(rows, analysisRow("comment", comment.CommentID, comment.VideoID, comment.Content, analysis, comment.SpamScore))
+
+		if detector.IsSpam(comment) {
+			spamCount++
+		}
+		if !options.excludeSpam || !detector.IsSpam(comment) {
+			aggregator.Add(sentiment.TargetVideo, comment.VideoID, analysis)
+			aggregator.Add(sentiment.TargetProduct, videoProducts[comment.VideoID], analysis)
+		}
+
+		platform := recordPlatform(comment.Platform, options.platform)
+		if err := store.SaveComment(comment, platform); err != nil {
+			log.Printf("%v", err)
+		}
+		if err := store.SaveCommentAnalysis("comment", comment.CommentID, analysis, platform); err != nil {
+			log.Printf("%v", err)
+		}
+		if err := writeJSON(paths[i], comment); err != nil {
+			return err
+		}
+	}
+
+	// 分析商品评价
+	err = forEachFile(options.inputDir, "review_*.json", func(path string, data []byte) error {
+		var review crawler.ProductReview
+		if err := json.Unmarshal(data, &review); err != nil {
+			return err
+		}
+
+		analysis := analyzer.AnalyzeReview(&review)
+		aggregator.Add(sentiment.TargetProduct, review.ProductID, analysis)
+		rows = append(rows, analysisRow("review", review.ReviewID, review.ProductID, review.Content, analysis, 0))
+
+		if err := store.SaveCommentAnalysis("review", review.ReviewID, analysis, recordPlatform(review.Platform, options.platform)); err != nil {
+			log.Printf("%v", err)
+		}
+		return writeJSON(path, &review)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 导出明细
+	if err := writeCSV(filepath.Join(options.exportDir, "comment_analysis.csv"), rows); err != nil {
+		return err
+	}
+
+	// 导出汇总
+	summaries := aggregator.Summaries(options.topKeywords)
+	header := []string{"target_id", "total", "positive", "neutral", "negative", "avg_sentiment"}
+	header = append(header, analyzer.Categories()...)
+	header = append(header, "top_keywords")
+	summaryRows := map[string][][]string{
+		sentiment.TargetVideo:   {header},
+		sentiment.TargetProduct: {header},
+	}
+	for _, summary := range summaries {
+		summaryRows[summary.TargetType] = append(summaryRows[summary.TargetType], summaryRow(summary, analyzer.Categories()))
+
+		if err := store.SaveFeedbackSummary(summary, options.platform); err != nil {
+			log.Printf("%v", err)
+		}
+	}
+	if err := writeCSV(filepath.Join(options.exportDir, "video_feedback.csv"), summaryRows[sentiment.TargetVideo]); err != nil {
+		return err
+	}
+	if err := writeCSV(filepath.Join(options.exportDir, "product_feedback.csv"), summaryRows[sentiment.TargetProduct]); err != nil {
+		return err
+	}
+	if err := writeJSON(filepath.Join(options.exportDir, "feedback_summary.json"), summaries); err != nil {
+		return err
+	}
+
+	log.Printf("已分析 %d 条评论和评价（其中垃圾评论 %d 条），导出 %d 个视频和 %d 个商品的汇总到 %s",
+		len(rows)-1, spamCount, len(summaryRows[sentiment.TargetVideo])-1, len(summaryRows[sentiment.TargetProduct])-1, options.exportDir)
+	return nil
+}
+
+// analysisRow 生成分析明细行
+func analysisRow(sourceType, sourceID, targetID, content string, analysis *crawler.CommentAnalysis, spamScore float64) []string {
+	return []string{
+		sourceType,
+		sourceID,
+		targetID,
+		strconv.FormatFloat(analysis.Sentiment, 'f', 2, 64),
+		analysis.Label,
+		strings.Join(analysis.Complaints, ","),
+		strings.Join(analysis.Keywords, ","),
+		strconv.FormatFloat(spamScore, 'f', 2, 64),
+		content,
+	}
+}
+
+// summaryRow 生成汇总行，各投诉类别单独成列
+func summaryRow(summary *crawler.FeedbackSummary, categories []string) []string {
+	row := []string{
+		summary.TargetID,
+		strconv.Itoa(summary.Total),
+		strconv.Itoa(summary.Positive),
+		strconv.Itoa(summary.Neutral),
+		strconv.Itoa(summary.Negative),
+		strconv.FormatFloat(summary.AvgSentiment, 'f', 2, 64),
+	}
+	for _, category := range categories {
+		row = append(row, strconv.Itoa(summary.Complaints[category]))
+	}
+
+	var keywords []string
+	for _, keyword := range summary.TopKeywords {
+		keywords = append(keywords, fmt.Sprintf("%s:%d", keyword.Word, keyword.Count))
+	}
+	return append(row, strings.Join(keywords, ","))
+}
+
+// recordPlatform 返回记录所属平台，旧版本采集的记录未标记平台时使用命令行指定的平台
+func recordPlatform(platform, fallback string) string {
+	if platform != "" {
+		return platform
+	}
+	return fallback
+}
+
+// openStore 按配置文件中的 db_config 创建存储管理器，未指定配置文件时不写入数据库
+func openStore(configFile string) (*storage.Manager, error) {
+	var dbConfig storage.Config
+	if configFile != "" {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取配置文件失败: %v", err)
+		}
+		var fileConfig struct {
+			DBConfig storage.Config `json:"db_config"`
+		}
+		if err := json.Unmarshal(data, &fileConfig); err != nil {
+			return nil, fmt.Errorf("解析配置文件失败: %v", err)
+		}
+		dbConfig = fileConfig.DBConfig
+	}
+	return storage.NewManager(dbConfig)
+}
+
+// forEachFile 依次读取目录中匹配的文件
+func forEachFile(dir, pattern string, handle func(path string, data []byte) error) error {
+	paths, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := handle(path, data); err != nil {
+			return fmt.Errorf("处理文件 %s 失败: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// writeJSON 将数据以缩进格式写入JSON文件
+func writeJSON(path string, v interface{}) error {
+	jsonData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, jsonData, 0644)
+}
+
+// writeCSV 写入CSV文件，带 BOM 以便 Excel 正确识别中文
+func writeCSV(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString("\xEF\xBB\xBF"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return nil
+}
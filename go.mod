@@ -3,17 +3,19 @@ module Crawler
 go 1.22.0
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/chromedp/cdproto v0.0.0-20240304214822-eeb3d13057c9
 	github.com/chromedp/chromedp v0.9.3
 	github.com/go-sql-driver/mysql v1.7.1
+	golang.org/x/crypto v0.31.0
 )
 
 require (
-	github.com/chromedp/cdproto v0.0.0-20240304214822-eeb3d13057c9 // indirect
 	github.com/chromedp/sysutil v1.0.0 // indirect
 	github.com/gobwas/httphead v0.1.0 // indirect
 	github.com/gobwas/pool v0.2.1 // indirect
 	github.com/gobwas/ws v1.3.2 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
-	golang.org/x/sys v0.17.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
 )
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"Crawler/crawler"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// userGraph 关注关系图的广度优先扩展状态
+type userGraph struct {
+	visited map[string]bool
+	nodes   []string
+	next    []string
+}
+
+// expandUserGraph 以种子用户为起点广度优先扩展关注关系图，返回发现的全部用户ID
+func (c *Crawler) expandUserGraph(seeds []string) []string {
+	graph := &userGraph{
+		visited: make(map[string]bool),
+	}
+
+	// 种子用户作为第0层
+	for _, userID := range seeds {
+		if !graph.visited[userID] {
+			graph.visited[userID] = true
+			graph.nodes = append(graph.nodes, userID)
+			graph.next = append(graph.next, userID)
+		}
+	}
+
+	for depth := 0; depth < c.config.GraphDepth && len(graph.next) > 0; depth++ {
+		level := graph.next
+		graph.next = nil
+
+		log.Printf("扩展关注关系图第 %d 层，共 %d 个用户", depth+1, len(level))
+
+		for _, userID := range level {
+			if c.graphFull(graph) {
+				break
+			}
+
+			// 粉丝关注了当前用户
+			c.crawlUserRelations(userID, c.scraper.GetFollowers, func(user *crawler.UserData) bool {
+				return c.addGraphNode(graph, user, &crawler.UserEdge{FromUserID: user.UserID, ToUserID: userID})
+			})
+
+			// 当前用户关注了对方
+			c.crawlUserRelations(userID, c.scraper.GetFollowing, func(user *crawler.UserData) bool {
+				return c.addGraphNode(graph, user, &crawler.UserEdge{FromUserID: userID, ToUserID: user.UserID})
+			})
+		}
+	}
+
+	log.Printf("关注关系图扩展完成，共发现 %d 个用户", len(graph.nodes))
+	return graph.nodes
+}
+
+// graphFull 检查关注关系图是否已达到最大节点数
+func (c *Crawler) graphFull(graph *userGraph) bool {
+	return c.config.GraphMaxNodes > 0 && len(graph.nodes) >= c.config.GraphMaxNodes
+}
+
+// addGraphNode 记录一条关注关系并将新用户加入下一层，返回 false 表示停止翻页
+func (c *Crawler) addGraphNode(graph *userGraph, user *crawler.UserData, edge *crawler.UserEdge) bool {
+	// 粉丝数不足的用户不纳入关系图
+	if user.UserID == "" || user.Followers < c.config.GraphMinFollowers {
+		return true
+	}
+
+	if !graph.visited[user.UserID] {
+		if c.graphFull(graph) {
+			return false
+		}
+		graph.visited[user.UserID] = true
+		graph.nodes = append(graph.nodes, user.UserID)
+		graph.next = append(graph.next, user.UserID)
+		c.saveUserData(user)
+	}
+
+	c.saveUserEdge(edge)
+	return true
+}
+
+// crawlUserRelations 分页爬取用户的粉丝或关注列表，visit 返回 false 时停止翻页
+func (c *Crawler) crawlUserRelations(userID string, fetch func(userID, cursor string) ([]*crawler.UserData, string, error), visit func(user *crawler.UserData) bool) {
+	cursor := ""
+	retryCount := 0
+
+	for {
+		// 获取关系列表
+		users, nextCursor, err := fetch(userID, cursor)
+		if errors.Is(err, crawler.ErrNotSupported) {
+			log.Printf("获取用户 %s 关系列表失败: %v", userID, err)
+			break
+		}
+		if err != nil {
+			c.recoverSession(err)
+			retryCount++
+			if retryCount > c.config.Retries {
+				log.Printf("获取用户 %s 关系列表失败，已达到最大重试次数", userID)
+				break
+			}
+			log.Printf("获取用户 %s 关系列表失败: %v，正在重试...", userID, err)
+			time.Sleep(time.Duration(c.config.Timeout) * time.Second)
+			continue
+		}
+
+		// 重置重试计数
+		retryCount = 0
+
+		for _, user := range users {
+			if !visit(user) {
+				return
+			}
+		}
+
+		// 如果没有更多数据，或者下一页游标与当前游标相同，则退出循环
+		if nextCursor == "" || nextCursor == cursor {
+			break
+		}
+
+		// 更新游标
+		cursor = nextCursor
+
+		// 休眠一段时间，避免请求过于频繁
+		time.Sleep(time.Second * 3)
+	}
+}
+
+// saveUserEdge 追加保存用户关注关系，启用数据库存储时同时写入数据库
+func (c *Crawler) saveUserEdge(edge *crawler.UserEdge) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// 标记所属平台
+	edge.Platform = c.config.Platform
+
+	if c.store.IsEnabled() {
+		if err := c.store.SaveUserEdge(edge, c.config.Platform); err != nil {
+			log.Printf("保存关注关系 %s -> %s 到数据库失败: %v", edge.FromUserID, edge.ToUserID, err)
+		}
+	}
+
+	// 将关注关系转换为JSON
+	jsonData, err := json.Marshal(edge)
+	if err != nil {
+		log.Printf("序列化关注关系失败: %v", err)
+		return
+	}
+
+	// 按行追加到文件
+	filePath := fmt.Sprintf("%s/user_edges.jsonl", c.config.OutputDir)
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Printf("打开关注关系文件失败: %v", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(jsonData, '\n')); err != nil {
+		log.Printf("保存关注关系到文件失败: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"Crawler/crawler"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// monitorLive 直播监控，定期检查被跟踪用户是否开播，并对直播中的直播间采样，直到 stop 关闭
+func (c *Crawler) monitorLive(userIDs []string, stop <-chan struct{}) {
+	liveScraper := c.scraper.(crawler.LiveScraper)
+
+	ticker := time.NewTicker(time.Duration(c.config.LiveInterval) * time.Second)
+	defer ticker.Stop()
+
+	// 记录正在直播的直播间，用于判断直播结束
+	liveRooms := make(map[string]*crawler.LiveRoom)
+
+	for {
+		for _, userID := range userIDs {
+			c.sampleLiveRoom(liveScraper, userID, liveRooms)
+		}
+
+		select {
+		case <-stop:
+			log.Println("直播监控已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sampleLiveRoom 检查用户的直播状态，正在直播时保存一次采样
+func (c *Crawler) sampleLiveRoom(liveScraper crawler.LiveScraper, userID string, liveRooms map[string]*crawler.LiveRoom) {
+	room, err := liveScraper.GetLiveRoom(userID)
+	if err != nil {
+		c.recoverSession(err)
+		log.Printf("获取用户 %s 直播状态失败: %v", userID, err)
+		return
+	}
+
+	// 未开播时检查上一场直播是否刚刚结束
+	if !room.IsLive {
+		if previous, ok := liveRooms[userID]; ok {
+			log.Printf("用户 %s 的直播 %s 已结束", userID, previous.RoomID)
+			delete(liveRooms, userID)
+		}
+		return
+	}
+
+	// 新开播的直播间保存一次元数据
+	if previous, ok := liveRooms[userID]; !ok || previous.RoomID != room.RoomID {
+		log.Printf("用户 %s 正在直播: %s", userID, room.Title)
+		c.saveLiveRoom(room)
+	}
+	liveRooms[userID] = room
+
+	// 获取购物车商品
+	products, err := liveScraper.GetLiveProducts(room.RoomID)
+	if err != nil {
+		c.recoverSession(err)
+		log.Printf("获取直播间 %s 商品失败: %v", room.RoomID, err)
+	}
+
+	// 购物车商品只做分类和产地提取，不丢弃
+	for _, product := range products {
+		c.enrichProduct(product)
+	}
+
+	c.saveLiveSample(&crawler.LiveSample{
+		RoomID:       room.RoomID,
+		UserID:       userID,
+		Timestamp:    time.Now().Unix(),
+		ViewerCount:  room.ViewerCount,
+		TotalViewers: room.TotalViewers,
+		Likes:        room.Likes,
+		Products:     products,
+	})
+}
+
+// saveLiveRoom 保存直播间信息，启用数据库存储时同时写入数据库
+func (c *Crawler) saveLiveRoom(room *crawler.LiveRoom) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// 标记所属平台
+	room.Platform = c.config.Platform
+
+	if c.store.IsEnabled() {
+		if err := c.store.SaveLiveRoom(room, c.config.Platform); err != nil {
+			log.Printf("保存直播间 %s 到数据库失败: %v", room.RoomID, err)
+		}
+	}
+
+	// 将直播间信息转换为JSON
+	jsonData, err := json.MarshalIndent(room, "", "  ")
+	if err != nil {
+		log.Printf("序列化直播间数据失败: %v", err)
+		return
+	}
+
+	// 保存到文件
+	filePath := fmt.Sprintf("%s/live_%s.json", c.config.OutputDir, room.RoomID)
+	if err := os.WriteFile(filePath, jsonData, 0644); err != nil {
+		log.Printf("保存直播间数据到文件失败: %v", err)
+		return
+	}
+
+	log.Printf("已保存直播间 %s 的数据到 %s", room.RoomID, filePath)
+}
+
+// saveLiveSample 追加保存直播间采样数据，启用数据库存储时同时写入采样和购物车商品
+func (c *Crawler) saveLiveSample(sample *crawler.LiveSample) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// 标记所属平台
+	sample.Platform = c.config.Platform
+
+	if c.store.IsEnabled() {
+		if err := c.store.SaveLiveSample(sample, c.config.Platform); err != nil {
+			log.Printf("保存直播间 %s 的采样数据到数据库失败: %v", sample.RoomID, err)
+		}
+	}
+
+	// 将采样数据转换为JSON
+	jsonData, err := json.Marshal(sample)
+	if err != nil {
+		log.Printf("序列化直播采样数据失败: %v", err)
+		return
+	}
+
+	// 按行追加到文件
+	filePath := fmt.Sprintf("%s/live_%s_samples.jsonl", c.config.OutputDir, sample.RoomID)
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Printf("打开直播采样文件失败: %v", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(jsonData, '\n')); err != nil {
+		log.Printf("保存直播采样数据到文件失败: %v", err)
+	}
+}
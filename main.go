@@ -2,24 +2,101 @@ package main
 
 import (
 	"Crawler/crawler"
+	"Crawler/utils/accountpool"
+	"Crawler/utils/browserpool"
+	"Crawler/utils/classifier"
+	"Crawler/utils/cookiestore"
+	"Crawler/utils/origin"
+	"Crawler/utils/pricenorm"
+	"Crawler/utils/spam"
+	"Crawler/utils/storage"
+	"Crawler/utils/tracker"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
+// defaultUserAgent 请求使用的浏览器标识
+const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36"
+
 // Config 存储爬虫配置信息
 type Config struct {
-	Concurrency int
-	UserAgent   string
-	Timeout     int
-	Retries     int
-	Cookies     string
-	Platform    string
-	OutputDir   string
+	Concurrency    int
+	UserAgent      string
+	Timeout        int
+	Retries        int
+	Cookies        string
+	Account        string // Cookie存储中的账号名称
+	CookieStore    string // 加密Cookie存储文件，需设置环境变量 CRAWLER_COOKIE_KEY
+	Login          bool   // 没有有效会话时是否打开浏览器登录
+	LoginQRCode    bool   // 登录时在终端中显示二维码扫码登录，不打开浏览器界面
+	ConfigFile     string // 配置文件，启用其中的 db_config 时商品信息同时写入数据库，供商品变化追踪对比
+	Platform       string
+	Backend        string // 数据获取方式：api 直接请求接口，browser 在浏览器中打开页面截获接口响应
+	Browsers       int    // 浏览器后端和浏览器签名共用的浏览器进程数
+	OutputDir      string
+	ReplyThreshold int // 评论回复数超过该值时抓取回复列表，小于0表示不抓取
+
+	// 多账号
+	Accounts        []string // 使用Cookie存储中的多个账号，all 表示平台的全部账号
+	AccountStrategy string   // 账号分配策略：round_robin 或 least_used
+	AccountRPM      int      // 每个账号每分钟的请求数，0 表示不限制
+
+	// 风控验证
+	ChallengeSolver string // 验证处理方式：manual 在浏览器中人工验证，http(s) 地址为外部验证服务，为空时不处理
+
+	// 关注关系图扩展
+	GraphDepth        int // 扩展层数，0表示不扩展
+	GraphMaxNodes     int // 最大用户数，0表示不限制
+	GraphMinFollowers int // 纳入关系图的最小粉丝数
+
+	// 直播监控
+	LiveInterval int // 直播状态检查和采样间隔（秒），0表示不监控
+
+	// 店铺采集
+	CrawlShops bool // 是否爬取商品所属店铺的全部商品
+
+	// 商品评价
+	ReviewPages int // 每个商品最多采集的评价页数，0表示不采集
+
+	// 农产品分类
+	Classify          bool    // 是否对视频和商品进行农产品分类
+	AgriOnly          bool    // 是否只保留农产品相关的视频和商品
+	AgriMinConfidence float64 // 判定为农产品的最低置信度
+	TaxonomyFile      string  // 自定义分类体系文件
+
+	// 产地提取
+	ExtractOrigin bool   // 是否从标题和描述中提取产地
+	GIFile        string // 自定义地理标志产品列表文件
+
+	// 价格归一化
+	NormalizePrice bool // 是否将商品价格换算为每斤/每公斤单价
+
+	// 商品变化追踪
+	TrackChanges     bool    // 是否对比上一次采集结果检测商品变化
+	PriceDropPercent float64 // 降价超过该百分比时触发事件
+	PriceRisePercent float64 // 涨价超过该百分比时触发事件
+	SalesJumpPercent float64 // 销量增长超过该百分比时触发事件
+	SalesJumpMin     int     // 销量增长的最小绝对值
+	Notify           string  // 通知器列表
+
+	// 垃圾评论检测
+	DetectSpam    bool    // 是否检测模板评论、刷评用户和广告评论
+	DropSpam      bool    // 是否丢弃判定为垃圾的评论
+	SpamThreshold float64 // 判定为垃圾评论的最低得分
+
+	// 抖音请求签名
+	DouyinSigner     string // 签名方式：xbogus 为内置算法，browser 为在浏览器中执行网页端脚本
+	DouyinSignScript string // 浏览器签名使用的 JS 函数文件，为空时使用默认脚本
 }
 
 // Crawler 爬虫主结构体
@@ -29,6 +106,21 @@ type Crawler struct {
 	mutex      sync.Mutex
 	urlChannel chan string
 	scraper    crawler.Scraper
+	transports []*crawler.BrowserTransport // 各账号的浏览器后端，使用 API 后端时为空
+	browsers   *browserpool.Pool           // 浏览器后端和浏览器签名共用的浏览器池，不使用浏览器时为空
+	signer     *crawler.BrowserSigner      // 各账号共用的抖音浏览器签名器，使用内置算法时为空
+	sessions   *cookiestore.Store          // 加密Cookie存储，未启用时为空
+	accounts   *accountpool.Pool           // 多账号时的账号池，使用单个账号时为空
+	store      *storage.Manager            // 数据库存储，未启用 db_config 时不写入
+	solver     crawler.ChallengeSolver     // 风控验证处理器，未启用时为空
+	challenges map[string]*challengeState  // 各账号最近一次验证的结果
+
+	visitedShops    map[string]bool
+	classifier      *classifier.Classifier
+	originExtractor *origin.Extractor
+	priceNormalizer *pricenorm.Normalizer
+	tracker         *tracker.Tracker
+	spamDetector    *spam.Detector
 }
 
 // NewCrawler 创建新的爬虫实例
@@ -36,26 +128,123 @@ func NewCrawler(config Config) *Crawler {
 	return &Crawler{
 		config:     config,
 		urlChannel: make(chan string, config.Concurrency),
+
+		visitedShops: make(map[string]bool),
+		challenges:   make(map[string]*challengeState),
 	}
 }
 
 // Initialize 初始化爬虫
 func (c *Crawler) Initialize() error {
-	// 根据平台选择对应的爬虫实现
-	switch crawler.Platform(c.config.Platform) {
-	case crawler.Douyin:
-		c.scraper = crawler.NewDouyinScraper(c.config.UserAgent, c.config.Cookies)
-	case crawler.Kuaishou:
-		c.scraper = crawler.NewKuaishouScraper(c.config.UserAgent, c.config.Cookies)
-	default:
+	// 打开Cookie存储，启用时优先使用已保存的会话
+	var err error
+	c.sessions, err = openSessions(c.config.CookieStore, c.config.Login, c.config.LoginQRCode)
+	if err != nil {
+		return err
+	}
+
+	c.solver, err = newChallengeSolver(c.config.ChallengeSolver)
+	if err != nil {
+		return err
+	}
+
+	// 浏览器后端和浏览器签名共用一个浏览器池
+	if c.config.Backend == "browser" || c.config.DouyinSigner == "browser" {
+		c.browsers = browserpool.New(browserpool.Config{
+			Size:           c.config.Browsers,
+			TabsPerBrowser: c.config.Concurrency + 1,
+			Headless:       true,
+		})
+	}
+
+	// 使用多个账号时每个账号创建独立的爬虫，由账号池分配请求
+	if len(c.config.Accounts) > 0 {
+		c.scraper, err = c.newAccountScraper()
+	} else {
+		c.scraper, err = c.newScraper(c.config.Account, c.config.Cookies)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 检查平台是否支持所需的可选功能
+	info, ok := crawler.Lookup(c.config.Platform)
+	if !ok {
 		return fmt.Errorf("不支持的平台: %s", c.config.Platform)
 	}
+	if c.config.LiveInterval > 0 && !info.Supports(crawler.CapabilityLive) {
+		return fmt.Errorf("平台 %s 不支持直播监控", c.config.Platform)
+	}
+	if c.config.ReplyThreshold >= 0 && !info.Supports(crawler.CapabilityReplies) {
+		log.Printf("平台 %s 不支持评论回复，将跳过回复采集", c.config.Platform)
+		c.config.ReplyThreshold = -1
+	}
 
 	// 初始化爬虫
 	if err := c.scraper.Initialize(); err != nil {
 		return fmt.Errorf("爬虫初始化失败: %v", err)
 	}
 
+	// 初始化农产品分类器
+	c.classifier, err = classifier.NewClassifier(classifier.Config{
+		Enabled:             c.config.Classify || c.config.AgriOnly,
+		TaxonomyFile:        c.config.TaxonomyFile,
+		MinConfidence:       c.config.AgriMinConfidence,
+		DropNonAgricultural: c.config.AgriOnly,
+	})
+	if err != nil {
+		return fmt.Errorf("农产品分类器初始化失败: %v", err)
+	}
+
+	// 初始化产地提取器
+	c.originExtractor, err = origin.NewExtractor(origin.Config{
+		Enabled: c.config.ExtractOrigin,
+		GIFile:  c.config.GIFile,
+	})
+	if err != nil {
+		return fmt.Errorf("产地提取器初始化失败: %v", err)
+	}
+
+	// 初始化价格归一化器
+	c.priceNormalizer = pricenorm.NewNormalizer(pricenorm.Config{
+		Enabled: c.config.NormalizePrice,
+	})
+
+	// 打开数据库存储，商品变化追踪从中读取上一次采集的商品
+	c.store, err = openStore(c.config.ConfigFile)
+	if err != nil {
+		return err
+	}
+	if c.config.TrackChanges && !c.store.IsEnabled() {
+		return errors.New("商品变化追踪需要在 -config 指定的配置文件中启用 db_config")
+	}
+
+	// 初始化商品变化追踪，未指定文件路径的文件通知器写入输出目录
+	notifiers := tracker.ParseNotifiers(c.config.Notify)
+	for i := range notifiers {
+		if notifiers[i].Type == "file" && notifiers[i].File == "" {
+			notifiers[i].File = fmt.Sprintf("%s/product_events.jsonl", c.config.OutputDir)
+		}
+	}
+	c.tracker, err = tracker.NewTracker(tracker.Config{
+		Enabled:          c.config.TrackChanges,
+		PriceDropPercent: c.config.PriceDropPercent,
+		PriceRisePercent: c.config.PriceRisePercent,
+		SalesJumpPercent: c.config.SalesJumpPercent,
+		SalesJumpMin:     c.config.SalesJumpMin,
+		Notifiers:        notifiers,
+	}, c.store)
+	if err != nil {
+		return fmt.Errorf("商品变化追踪初始化失败: %v", err)
+	}
+
+	// 初始化垃圾评论检测器
+	c.spamDetector = spam.NewDetector(spam.Config{
+		Enabled:   c.config.DetectSpam || c.config.DropSpam,
+		Threshold: c.config.SpamThreshold,
+		DropSpam:  c.config.DropSpam,
+	})
+
 	// 创建输出目录
 	if err := os.MkdirAll(c.config.OutputDir, 0755); err != nil {
 		return fmt.Errorf("创建输出目录失败: %v", err)
@@ -64,8 +253,105 @@ func (c *Crawler) Initialize() error {
 	return nil
 }
 
+// newScraper 根据平台和后端创建账号使用的爬虫，并配置会话刷新和抖音请求签名方式
+func (c *Crawler) newScraper(account, cookies string) (crawler.Scraper, error) {
+	cookies, err := c.accountCookies(account, cookies)
+	if err != nil {
+		return nil, err
+	}
+
+	var scraper crawler.Scraper
+	switch c.config.Backend {
+	case "", "api":
+		scraper, err = crawler.NewScraper(c.config.Platform, c.config.UserAgent, cookies)
+	case "browser":
+		var transport *crawler.BrowserTransport
+		scraper, transport, err = crawler.NewBrowserScraper(c.config.Platform, c.config.UserAgent, cookies, crawler.BrowserConfig{
+			Timeout: time.Duration(c.config.Timeout) * time.Second,
+			MaxTabs: c.config.Concurrency,
+			Pool:    c.browsers,
+		})
+		if err == nil {
+			c.transports = append(c.transports, transport)
+		}
+	default:
+		err = fmt.Errorf("未知的数据获取方式: %s", c.config.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 会话刷新后的Cookie立即用于后续请求
+	if err := c.useSessions(scraper, account); err != nil {
+		return nil, err
+	}
+
+	// 配置抖音请求签名方式，多个账号共用一个签名器
+	if douyin, ok := scraper.(*crawler.DouyinScraper); ok {
+		switch c.config.DouyinSigner {
+		case "", "xbogus":
+		case "browser":
+			if c.signer == nil {
+				var script []byte
+				if c.config.DouyinSignScript != "" {
+					if script, err = os.ReadFile(c.config.DouyinSignScript); err != nil {
+						return nil, fmt.Errorf("读取签名脚本失败: %v", err)
+					}
+				}
+				c.signer = crawler.NewBrowserSigner(crawler.BrowserSignerConfig{
+					Script:    strings.TrimSpace(string(script)),
+					UserAgent: c.config.UserAgent,
+					Pool:      c.browsers,
+				})
+			}
+			douyin.SetSigner(c.signer)
+		default:
+			return nil, fmt.Errorf("未知的抖音签名方式: %s", c.config.DouyinSigner)
+		}
+	}
+
+	return scraper, nil
+}
+
+// Close 释放爬虫占用的资源，如浏览器签名器和浏览器后端启动的浏览器
+func (c *Crawler) Close() {
+	if closer, ok := c.scraper.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("关闭爬虫失败: %v", err)
+		}
+	}
+	for _, transport := range c.transports {
+		transport.Close()
+	}
+	if c.browsers != nil {
+		c.browsers.Close()
+	}
+	if c.sessions != nil {
+		c.sessions.Close()
+	}
+	if c.store != nil {
+		c.store.Close()
+	}
+}
+
 // Start 启动爬虫
 func (c *Crawler) Start(userIDs []string) {
+	// 直播监控与工作协程并行运行，只跟踪种子用户
+	stopLive := make(chan struct{})
+	var liveWG sync.WaitGroup
+	if c.config.LiveInterval > 0 {
+		liveWG.Add(1)
+		go func(trackedUsers []string) {
+			defer liveWG.Done()
+			c.monitorLive(trackedUsers, stopLive)
+		}(userIDs)
+	}
+
+	// 按关注关系扩展待爬取的用户
+	if c.config.GraphDepth > 0 {
+		userIDs = c.expandUserGraph(userIDs)
+	}
+
 	// 启动工作协程
 	for i := 0; i < c.config.Concurrency; i++ {
 		c.wg.Add(1)
@@ -83,6 +369,18 @@ func (c *Crawler) Start(userIDs []string) {
 	// 等待所有工作协程完成
 	c.wg.Wait()
 	log.Println("爬虫任务完成")
+	c.logAccountStats()
+
+	// 直播监控持续运行，直到收到退出信号
+	if c.config.LiveInterval > 0 {
+		log.Println("直播监控运行中，按 Ctrl+C 退出")
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		close(stopLive)
+		liveWG.Wait()
+	}
 }
 
 // worker 工作协程
@@ -92,6 +390,9 @@ func (c *Crawler) worker() {
 	for userID := range c.urlChannel {
 		// 获取用户信息
 		userData, err := c.scraper.GetUserInfo(userID)
+		if c.recoverSession(err) {
+			userData, err = c.scraper.GetUserInfo(userID)
+		}
 		if err != nil {
 			log.Printf("获取用户 %s 信息失败: %v", userID, err)
 			continue
@@ -117,6 +418,7 @@ func (c *Crawler) crawlUserVideos(userID string) {
 		// 获取用户视频列表
 		videos, nextCursor, err := c.scraper.GetUserVideos(userID, cursor)
 		if err != nil {
+			c.recoverSession(err)
 			retryCount++
 			if retryCount > c.config.Retries {
 				log.Printf("获取用户 %s 视频列表失败，已达到最大重试次数", userID)
@@ -132,6 +434,12 @@ func (c *Crawler) crawlUserVideos(userID string) {
 
 		// 保存视频数据
 		for _, video := range videos {
+			// 分类并提取产地，跳过非农产品视频
+			if !c.enrichVideo(video) {
+				log.Printf("视频 %s 不是农产品相关内容，已跳过", video.VideoID)
+				continue
+			}
+
 			c.saveVideoData(video)
 
 			// 获取视频评论
@@ -168,6 +476,7 @@ func (c *Crawler) crawlVideoComments(videoID string) {
 		// 获取视频评论
 		comments, nextCursor, err := c.scraper.GetVideoComments(videoID, cursor)
 		if err != nil {
+			c.recoverSession(err)
 			retryCount++
 			if retryCount > c.config.Retries {
 				log.Printf("获取视频 %s 评论失败，已达到最大重试次数", videoID)
@@ -181,9 +490,63 @@ func (c *Crawler) crawlVideoComments(videoID string) {
 		// 重置重试计数
 		retryCount = 0
 
-		// 保存评论数据
+		// 保存评论数据，跳过垃圾评论
 		for _, comment := range comments {
+			if !c.enrichComment(comment) {
+				continue
+			}
+
 			c.saveCommentData(comment)
+
+			// 回复数超过阈值且平台支持时抓取回复列表
+			if replyScraper, ok := c.scraper.(crawler.ReplyScraper); ok && c.config.ReplyThreshold >= 0 && comment.Replies > c.config.ReplyThreshold {
+				c.crawlCommentReplies(replyScraper, videoID, comment.CommentID)
+			}
+		}
+
+		// 如果没有更多数据，或者下一页游标与当前游标相同，则退出循环
+		if nextCursor == "" || nextCursor == cursor {
+			break
+		}
+
+		// 更新游标
+		cursor = nextCursor
+
+		// 休眠一段时间，避免请求过于频繁
+		time.Sleep(time.Second * 3)
+	}
+}
+
+// crawlCommentReplies 爬取评论的回复列表
+func (c *Crawler) crawlCommentReplies(replyScraper crawler.ReplyScraper, videoID, commentID string) {
+	cursor := ""
+	retryCount := 0
+
+	for {
+		// 获取评论回复
+		replies, nextCursor, err := replyScraper.GetCommentReplies(videoID, commentID, cursor)
+		if err != nil {
+			c.recoverSession(err)
+			retryCount++
+			if retryCount > c.config.Retries {
+				log.Printf("获取评论 %s 回复失败，已达到最大重试次数", commentID)
+				break
+			}
+			log.Printf("获取评论 %s 回复失败: %v，正在重试...", commentID, err)
+			time.Sleep(time.Duration(c.config.Timeout) * time.Second)
+			continue
+		}
+
+		// 重置重试计数
+		retryCount = 0
+
+		// 保存回复数据，跳过垃圾评论
+		for _, reply := range replies {
+			if !c.enrichComment(reply) {
+				continue
+			}
+
+			c.saveCommentData(reply)
 		}
 
 		// 如果没有更多数据，或者下一页游标与当前游标相同，则退出循环
@@ -203,13 +566,77 @@ func (c *Crawler) crawlVideoComments(videoID string) {
 func (c *Crawler) crawlProductInfo(productID string) {
 	// 获取商品信息
 	productInfo, err := c.scraper.GetProductInfo(productID)
+	if c.recoverSession(err) {
+		productInfo, err = c.scraper.GetProductInfo(productID)
+	}
+	if errors.Is(err, crawler.ErrNotFound) {
+		log.Printf("商品 %s 已不存在", productID)
+		c.trackMissingProduct(productID)
+		return
+	}
 	if err != nil {
 		log.Printf("获取商品 %s 信息失败: %v", productID, err)
 		return
 	}
 
+	// 分类并提取产地，跳过非农产品商品
+	if !c.enrichProduct(productInfo) {
+		log.Printf("商品 %s 不是农产品，已跳过", productID)
+		return
+	}
+
 	// 保存商品信息
 	c.saveProductInfo(productInfo)
+
+	// 爬取商品评价
+	c.crawlProductReviews(productID)
+
+	// 爬取商品所属店铺的全部商品
+	if c.config.CrawlShops && productInfo.ShopID != "" {
+		c.crawlShop(productInfo.ShopID)
+	}
+}
+
+// crawlProductReviews 爬取商品评价，最多采集 ReviewPages 页
+func (c *Crawler) crawlProductReviews(productID string) {
+	cursor := ""
+	retryCount := 0
+
+	for page := 0; page < c.config.ReviewPages; {
+		// 获取商品评价
+		reviews, nextCursor, err := c.scraper.GetProductReviews(productID, cursor)
+		if err != nil {
+			c.recoverSession(err)
+			retryCount++
+			if retryCount > c.config.Retries {
+				log.Printf("获取商品 %s 评价失败，已达到最大重试次数", productID)
+				break
+			}
+			log.Printf("获取商品 %s 评价失败: %v，正在重试...", productID, err)
+			time.Sleep(time.Duration(c.config.Timeout) * time.Second)
+			continue
+		}
+
+		// 重置重试计数
+		retryCount = 0
+		page++
+
+		// 保存评价数据
+		for _, review := range reviews {
+			c.saveProductReview(review)
+		}
+
+		// 如果没有更多数据，或者下一页游标与当前游标相同，则退出循环
+		if nextCursor == "" || nextCursor == cursor {
+			break
+		}
+
+		// 更新游标
+		cursor = nextCursor
+
+		// 休眠一段时间，避免请求过于频繁
+		time.Sleep(time.Second * 3)
+	}
 }
 
 // saveUserData 保存用户数据
@@ -217,6 +644,9 @@ func (c *Crawler) saveUserData(userData *crawler.UserData) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	// 标记所属平台
+	userData.Platform = c.config.Platform
+
 	// 将用户数据转换为JSON
 	jsonData, err := json.MarshalIndent(userData, "", "  ")
 	if err != nil {
@@ -234,11 +664,24 @@ func (c *Crawler) saveUserData(userData *crawler.UserData) {
 	log.Printf("已保存用户 %s 的数据到 %s", userData.UserID, filePath)
 }
 
-// saveVideoData 保存视频数据
+// saveVideoData 保存视频数据，启用数据库存储时同时写入数据库并记录视频带货的商品
 func (c *Crawler) saveVideoData(videoData *crawler.VideoData) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	// 标记所属平台
+	videoData.Platform = c.config.Platform
+	if videoData.ProductInfo != nil {
+		videoData.ProductInfo.Platform = c.config.Platform
+		videoData.ProductInfo.Source = crawler.SourceShelf
+	}
+
+	if c.store.IsEnabled() {
+		if err := c.store.SaveVideo(videoData, c.config.Platform); err != nil {
+			log.Printf("保存视频 %s 到数据库失败: %v", videoData.VideoID, err)
+		}
+	}
+
 	// 将视频数据转换为JSON
 	jsonData, err := json.MarshalIndent(videoData, "", "  ")
 	if err != nil {
@@ -261,6 +704,9 @@ func (c *Crawler) saveCommentData(commentData *crawler.CommentData) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	// 标记所属平台
+	commentData.Platform = c.config.Platform
+
 	// 将评论数据转换为JSON
 	jsonData, err := json.MarshalIndent(commentData, "", "  ")
 	if err != nil {
@@ -278,11 +724,43 @@ func (c *Crawler) saveCommentData(commentData *crawler.CommentData) {
 	log.Printf("已保存评论 %s 的数据到 %s", commentData.CommentID, filePath)
 }
 
+// trackMissingProduct 处理已不存在的商品，上一次采集时在售的商品记为下架
+func (c *Crawler) trackMissingProduct(productID string) {
+	product, events := c.tracker.TrackMissing(productID, c.config.Platform)
+	if product == nil {
+		return
+	}
+	c.saveProductHistory(product, events)
+}
+
+// saveProductHistory 将商品和变化事件写入数据库，作为下一次采集对比的基准
+func (c *Crawler) saveProductHistory(productInfo *crawler.ProductInfo, events []*crawler.ProductEvent) {
+	if !c.store.IsEnabled() {
+		return
+	}
+	if err := c.store.SaveProduct(productInfo, c.config.Platform); err != nil {
+		log.Printf("保存商品 %s 到数据库失败: %v", productInfo.ProductID, err)
+	}
+	for _, event := range events {
+		if err := c.store.SaveProductEvent(event); err != nil {
+			log.Printf("保存商品 %s 的变化事件失败: %v", event.ProductID, err)
+		}
+	}
+}
+
 // saveProductInfo 保存商品信息
 func (c *Crawler) saveProductInfo(productInfo *crawler.ProductInfo) {
+	// 写入数据库前与上一次采集结果对比
+	events := c.tracker.Track(productInfo, c.config.Platform)
+	defer c.saveProductHistory(productInfo, events)
+
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	// 标记所属平台和来源
+	productInfo.Platform = c.config.Platform
+	productInfo.Source = crawler.SourceShelf
+
 	// 将商品信息转换为JSON
 	jsonData, err := json.MarshalIndent(productInfo, "", "  ")
 	if err != nil {
@@ -300,35 +778,179 @@ func (c *Crawler) saveProductInfo(productInfo *crawler.ProductInfo) {
 	log.Printf("已保存商品 %s 的数据到 %s", productInfo.ProductID, filePath)
 }
 
+// saveProductReview 保存商品评价，启用数据库存储时同时写入数据库
+func (c *Crawler) saveProductReview(review *crawler.ProductReview) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// 标记所属平台
+	review.Platform = c.config.Platform
+
+	if c.store.IsEnabled() {
+		if err := c.store.SaveProductReview(review, c.config.Platform); err != nil {
+			log.Printf("保存商品评价 %s 到数据库失败: %v", review.ReviewID, err)
+		}
+	}
+
+	// 将商品评价转换为JSON
+	jsonData, err := json.MarshalIndent(review, "", "  ")
+	if err != nil {
+		log.Printf("序列化商品评价失败: %v", err)
+		return
+	}
+
+	// 保存到文件
+	filePath := fmt.Sprintf("%s/review_%s.json", c.config.OutputDir, review.ReviewID)
+	if err := os.WriteFile(filePath, jsonData, 0644); err != nil {
+		log.Printf("保存商品评价到文件失败: %v", err)
+		return
+	}
+
+	log.Printf("已保存商品评价 %s 的数据到 %s", review.ReviewID, filePath)
+}
+
 func main() {
+	// 子命令
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "resolve":
+			runResolve(os.Args[2:])
+			return
+		case "platforms":
+			listPlatforms()
+			return
+		case "benchmark":
+			runBenchmark(os.Args[2:])
+			return
+		}
+	}
+
 	// 解析命令行参数
-	platform := flag.String("platform", "douyin", "爬虫平台 (douyin 或 kuaishou)")
+	platform := flag.String("platform", "douyin", fmt.Sprintf("爬虫平台 (%s)，运行 platforms 子命令查看各平台支持的功能", strings.Join(platformNames(), "、")))
 	concurrency := flag.Int("concurrency", 5, "并发数")
 	timeout := flag.Int("timeout", 30, "超时时间（秒）")
 	retries := flag.Int("retries", 3, "重试次数")
-	cookies := flag.String("cookies", "", "Cookie字符串")
+	cookies := flag.String("cookies", "", "Cookie字符串，启用Cookie存储时保存为账号的会话")
+	account := flag.String("account", "default", "Cookie存储中的账号名称")
+	cookieStore := flag.String("cookie-store", "cookies.enc", "加密Cookie存储文件，需设置环境变量 CRAWLER_COOKIE_KEY")
+	login := flag.Bool("login", false, "没有有效会话或登录失效时打开浏览器登录")
+	loginQRCode := flag.Bool("login-qrcode", false, "登录时在终端中显示登录二维码，用手机 App 扫码登录，适用于没有图形界面的服务器（隐含 -login）")
+	accounts := flag.String("accounts", "", "使用Cookie存储中的多个账号，以逗号分隔，all 表示平台的全部账号")
+	accountStrategy := flag.String("account-strategy", "round_robin", "账号分配策略：round_robin（轮流）或 least_used（优先使用请求最少的账号）")
+	accountRPM := flag.Int("account-rpm", 0, "每个账号每分钟的请求数（0表示不限制）")
+	challengeSolver := flag.String("challenge-solver", "", "触发滑块等风控验证时的处理方式：manual（在浏览器中人工验证）或外部验证服务的 http(s) 地址，为空时不处理")
+	backend := flag.String("backend", "api", "数据获取方式：api（直接请求接口）或 browser（在浏览器中打开页面，截获页面加载的接口数据）")
+	browsers := flag.Int("browsers", 1, "浏览器后端和浏览器签名共用的浏览器进程数")
 	outputDir := flag.String("output", "output", "输出目录")
+	configFile := flag.String("config", "", "配置文件，启用 db_config 时将商品信息同时写入数据库，供商品变化追踪对比")
 	userIDs := flag.String("users", "", "用户ID列表，以逗号分隔")
+	replyThreshold := flag.Int("reply-threshold", 10, "评论回复数超过该值时抓取回复（小于0表示不抓取）")
+	graphDepth := flag.Int("graph-depth", 0, "按粉丝和关注关系广度优先扩展的层数（0表示不扩展）")
+	graphMaxNodes := flag.Int("graph-max-nodes", 1000, "关系图扩展的最大用户数（0表示不限制）")
+	graphMinFollowers := flag.Int("graph-min-followers", 0, "纳入关系图的最小粉丝数")
+	liveInterval := flag.Int("live-interval", 0, "直播监控的采样间隔（秒），0表示不监控")
+	shopIDs := flag.String("shops", "", "店铺ID列表，以逗号分隔")
+	crawlShops := flag.Bool("crawl-shops", false, "爬取商品所属店铺的全部商品")
+	reviewPages := flag.Int("review-pages", 3, "每个商品最多采集的评价页数（0表示不采集）")
+	classify := flag.Bool("classify", false, "对视频和商品进行农产品分类")
+	agriOnly := flag.Bool("agri-only", false, "只保留农产品相关的视频和商品（隐含 -classify）")
+	agriMinConfidence := flag.Float64("agri-min-confidence", 0.25, "判定为农产品的最低置信度")
+	taxonomyFile := flag.String("taxonomy", "", "自定义农产品分类体系文件（JSON），为空时使用内置分类体系")
+	extractOrigin := flag.Bool("extract-origin", false, "从标题和描述中提取产地和地理标志产品")
+	giFile := flag.String("gi-file", "", "自定义地理标志产品列表文件（JSON），为空时使用内置列表")
+	normalizePrice := flag.Bool("normalize-price", false, "将商品价格换算为每斤/每公斤单价")
+	trackChanges := flag.Bool("track-changes", false, "对比数据库中上一次采集的结果，检测商品降价、销量激增和下架（需要 -config 启用 db_config）")
+	priceDrop := flag.Float64("price-drop", 10, "降价超过该百分比时触发事件")
+	priceRise := flag.Float64("price-rise", 0, "涨价超过该百分比时触发事件（0表示不追踪涨价）")
+	salesJump := flag.Float64("sales-jump", 50, "销量增长超过该百分比时触发事件")
+	salesJumpMin := flag.Int("sales-jump-min", 100, "触发销量激增事件的最小销量增长")
+	notify := flag.String("notify", "stdout,file", "商品变化事件通知器，以逗号分隔：stdout、file[:路径]、webhook:URL")
+	detectSpam := flag.Bool("detect-spam", false, "检测模板评论、刷评用户和广告评论，在评论中记录垃圾评论得分和原因")
+	dropSpam := flag.Bool("drop-spam", false, "丢弃判定为垃圾的评论（隐含 -detect-spam）")
+	spamThreshold := flag.Float64("spam-threshold", 0.6, "判定为垃圾评论的最低得分")
+	douyinSigner := flag.String("douyin-signer", "xbogus", "抖音请求签名方式：xbogus（内置算法）或 browser（在浏览器中执行网页端签名脚本）")
+	douyinSignScript := flag.String("douyin-sign-script", "", "浏览器签名使用的 JS 函数文件，函数参数为查询字符串和 UA，返回签名参数对象")
 	flag.Parse()
 
 	// 检查必要参数
-	if *cookies == "" {
-		log.Fatal("必须提供Cookie参数")
+	if *userIDs == "" && *shopIDs == "" {
+		log.Fatal("必须提供至少一个用户ID或店铺ID")
+	}
+
+	// 解析账号列表
+	var accountList []string
+	for _, name := range strings.Split(*accounts, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			accountList = append(accountList, name)
+		}
 	}
 
-	if *userIDs == "" {
-		log.Fatal("必须提供至少一个用户ID")
+	// 解析店铺ID列表
+	var shopIDList []string
+	for _, id := range strings.Split(*shopIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			shopIDList = append(shopIDList, id)
+		}
 	}
 
 	// 初始化爬虫配置
 	config := Config{
-		Concurrency: *concurrency,
-		UserAgent:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36",
-		Timeout:     *timeout,
-		Retries:     *retries,
-		Cookies:     *cookies,
-		Platform:    *platform,
-		OutputDir:   *outputDir,
+		Concurrency:    *concurrency,
+		UserAgent:      defaultUserAgent,
+		Timeout:        *timeout,
+		Retries:        *retries,
+		Cookies:        *cookies,
+		Account:        *account,
+		CookieStore:    *cookieStore,
+		Login:          *login || *loginQRCode,
+		LoginQRCode:    *loginQRCode,
+		Platform:       *platform,
+		Backend:        *backend,
+		Browsers:       *browsers,
+		OutputDir:      *outputDir,
+		ConfigFile:     *configFile,
+		ReplyThreshold: *replyThreshold,
+
+		Accounts:        accountList,
+		AccountStrategy: *accountStrategy,
+		AccountRPM:      *accountRPM,
+
+		ChallengeSolver: *challengeSolver,
+
+		GraphDepth:        *graphDepth,
+		GraphMaxNodes:     *graphMaxNodes,
+		GraphMinFollowers: *graphMinFollowers,
+
+		LiveInterval: *liveInterval,
+		CrawlShops:   *crawlShops,
+		ReviewPages:  *reviewPages,
+
+		Classify:          *classify,
+		AgriOnly:          *agriOnly,
+		AgriMinConfidence: *agriMinConfidence,
+		TaxonomyFile:      *taxonomyFile,
+
+		ExtractOrigin: *extractOrigin,
+		GIFile:        *giFile,
+
+		NormalizePrice: *normalizePrice,
+
+		TrackChanges:     *trackChanges,
+		PriceDropPercent: *priceDrop,
+		PriceRisePercent: *priceRise,
+		SalesJumpPercent: *salesJump,
+		SalesJumpMin:     *salesJumpMin,
+		Notify:           *notify,
+
+		DetectSpam:    *detectSpam,
+		DropSpam:      *dropSpam,
+		SpamThreshold: *spamThreshold,
+
+		DouyinSigner:     *douyinSigner,
+		DouyinSignScript: *douyinSignScript,
 	}
 
 	// 创建爬虫实例
@@ -338,6 +960,7 @@ func main() {
 	if err := crawler.Initialize(); err != nil {
 		log.Fatalf("爬虫初始化失败: %v", err)
 	}
+	defer crawler.Close()
 
 	// 解析用户ID列表
 	userIDList := []string{}
@@ -347,13 +970,25 @@ func main() {
 		}
 	}
 
-	// 如果命令行参数中没有用户ID，则使用-users参数中的用户ID
-	if len(userIDList) == 0 && *userIDs != "" {
-		userIDList = []string{*userIDs}
+	// 如果命令行参数中没有用户ID，则使用-users参数中以逗号分隔的用户ID
+	if len(userIDList) == 0 {
+		for _, id := range strings.Split(*userIDs, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				userIDList = append(userIDList, id)
+			}
+		}
 	}
 
 	// 启动爬虫
 	log.Println("爬虫程序初始化完成")
 	log.Println("准备开始数据采集...")
-	crawler.Start(userIDList)
+
+	// 采集指定店铺
+	if len(shopIDList) > 0 {
+		crawler.CrawlShops(shopIDList)
+	}
+
+	if len(userIDList) > 0 {
+		crawler.Start(userIDList)
+	}
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"Crawler/crawler"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// listPlatforms 列出已注册的平台及其支持的可选功能
+func listPlatforms() {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "平台\t名称\t可选功能\t后端\t登录地址")
+	for _, info := range crawler.Platforms() {
+		var capabilities []string
+		for _, capability := range info.Capabilities {
+			capabilities = append(capabilities, string(capability))
+		}
+		if len(capabilities) == 0 {
+			capabilities = []string{"-"}
+		}
+		backends := "api"
+		if len(info.BrowserRoutes) > 0 {
+			backends = "api,browser"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", info.Name, info.DisplayName, strings.Join(capabilities, ","), backends, info.LoginURL)
+	}
+	writer.Flush()
+}
+
+// platformNames 返回可用于 -platform 的平台名称，不含只采集商品的电商平台
+func platformNames() []string {
+	var names []string
+	for _, info := range crawler.Platforms() {
+		if info.New != nil {
+			names = append(names, string(info.Name))
+		}
+	}
+	return names
+}
+
+// productPlatformNames 返回支持商品比价的电商平台名称
+func productPlatformNames() []string {
+	var names []string
+	for _, info := range crawler.Platforms() {
+		if info.Supports(crawler.CapabilityProducts) {
+			names = append(names, string(info.Name))
+		}
+	}
+	return names
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/resolve"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// runResolve 实体识别命令：读取各平台的采集结果，识别不同平台上的同一创作者和同一商品，
+// 导出实体簇并写入数据库
+func runResolve(args []string) {
+	flags := flag.NewFlagSet("resolve", flag.ExitOnError)
+	inputs := flags.String("inputs", "", "各平台的采集结果目录，格式为 平台=目录，以逗号分隔，如 douyin=output/douyin,kuaishou=output/kuaishou")
+	exportDir := flags.String("output", "export", "导出目录")
+	configFile := flags.String("config", "", "配置文件，启用 db_config 时将识别结果同时写入数据库")
+	overrideFile := flags.String("overrides", "", "人工覆盖文件（JSON），指定必须关联或禁止关联的实体")
+	minConfidence := flags.Float64("min-confidence", 0.7, "判定为同一实体的最低置信度")
+	flags.Parse(args)
+
+	if *inputs == "" {
+		log.Fatal("请通过 -inputs 指定各平台的采集结果目录")
+	}
+
+	resolver, err := resolve.NewResolver(resolve.Config{
+		MinConfidence: *minConfidence,
+		OverrideFile:  *overrideFile,
+	})
+	if err != nil {
+		log.Fatalf("实体识别器初始化失败: %v", err)
+	}
+
+	store, err := openStore(*configFile)
+	if err != nil {
+		log.Fatalf("存储初始化失败: %v", err)
+	}
+	defer store.Close()
+
+	// 读取各平台的创作者和商品
+	var creators []*resolve.Creator
+	var products []*resolve.Product
+	for _, input := range strings.Split(*inputs, ",") {
+		platform, dir, ok := strings.Cut(strings.TrimSpace(input), "=")
+		if !ok {
+			log.Fatalf("采集结果目录格式错误: %s", input)
+		}
+
+		err := forEachFile(dir, "user_*.json", func(path string, data []byte) error {
+			var user crawler.UserData
+			if err := json.Unmarshal(data, &user); err != nil {
+				return err
+			}
+			creators = append(creators, &resolve.Creator{Platform: recordPlatform(user.Platform, platform), User: &user})
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("读取创作者失败: %v", err)
+		}
+
+		err = forEachFile(dir, "product_*.json", func(path string, data []byte) error {
+			var product crawler.ProductInfo
+			if err := json.Unmarshal(data, &product); err != nil {
+				return err
+			}
+			products = append(products, &resolve.Product{Platform: recordPlatform(product.Platform, platform), Product: &product})
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("读取商品失败: %v", err)
+		}
+	}
+
+	creatorClusters := resolver.ResolveCreators(creators)
+	productClusters := resolver.ResolveProducts(products)
+
+	if err := store.ReplaceEntityClusters(resolve.EntityCreator, creatorClusters); err != nil {
+		log.Printf("%v", err)
+	}
+	if err := store.ReplaceEntityClusters(resolve.EntityProduct, productClusters); err != nil {
+		log.Printf("%v", err)
+	}
+
+	// 导出实体簇和实体关联
+	if err := os.MkdirAll(*exportDir, 0755); err != nil {
+		log.Fatalf("创建导出目录失败: %v", err)
+	}
+	clusters := append(creatorClusters, productClusters...)
+	if err := writeJSON(filepath.Join(*exportDir, "entity_clusters.json"), clusters); err != nil {
+		log.Fatalf("导出实体簇失败: %v", err)
+	}
+
+	rows := [][]string{{"cluster_id", "entity_type", "platform", "entity_id", "name", "confidence", "reasons", "manual"}}
+	for _, cluster := range clusters {
+		for _, member := range cluster.Members {
+			rows = append(rows, []string{
+				cluster.ClusterID,
+				cluster.EntityType,
+				member.Platform,
+				member.ID,
+				member.Name,
+				strconv.FormatFloat(cluster.Confidence, 'f', 2, 64),
+				strings.Join(cluster.Reasons, ","),
+				strconv.FormatBool(cluster.Manual),
+			})
+		}
+	}
+	if err := writeCSV(filepath.Join(*exportDir, "entity_links.csv"), rows); err != nil {
+		log.Fatalf("导出实体关联失败: %v", err)
+	}
+
+	log.Printf("已识别 %d 个创作者中的 %d 个跨平台创作者簇，%d 个商品中的 %d 个跨平台商品簇，结果已导出到 %s",
+		len(creators), len(creatorClusters), len(products), len(productClusters), *exportDir)
+}
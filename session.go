@@ -0,0 +1,250 @@
+package main
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/autocookie"
+	"Crawler/utils/cookiestore"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// openSessions 打开Cookie存储并设置会话刷新函数，login 为 true 时无法续期的会话打开浏览器重新登录，
+// qrcode 为 true 时在无头浏览器中截取登录二维码并输出到终端扫码登录。
+// 未设置加密口令时不使用存储，返回的存储为空；需要存储的 -login 直接返回错误
+func openSessions(file string, login, qrcode bool) (*cookiestore.Store, error) {
+	if file == "" || os.Getenv("CRAWLER_COOKIE_KEY") == "" {
+		if login {
+			return nil, errors.New("使用 -login 需要设置环境变量 CRAWLER_COOKIE_KEY 以保存登录会话")
+		}
+		if file != "" {
+			log.Printf("警告: 未设置环境变量 CRAWLER_COOKIE_KEY，不使用Cookie存储 %s，登录会话不会保存，登录失效后也不会自动续期", file)
+		}
+		return nil, nil
+	}
+
+	store, err := cookiestore.NewStore(cookiestore.Config{File: file})
+	if err != nil {
+		return nil, err
+	}
+
+	// 会话即将过期时在浏览器中续期，登录已失效且允许登录时打开浏览器重新登录
+	manager := autocookie.NewManager(autocookie.Config{Enabled: true, Headless: !login || qrcode})
+	store.SetRefresher(func(session *cookiestore.Session) ([]*http.Cookie, error) {
+		if len(session.Cookies) > 0 {
+			refreshed, err := manager.RefreshCookies(session.Platform, session.HTTPCookies())
+			if err == nil || !login {
+				return refreshed, err
+			}
+			log.Printf("%v，需要重新登录", err)
+		}
+		if !login {
+			return nil, errors.New("没有可续期的会话，请使用 -login 登录")
+		}
+		return manager.GetCookies(session.Platform)
+	}, loginCookies)
+
+	store.StartAutoRefresh()
+	return store, nil
+}
+
+// accountCookies 返回账号使用的Cookie。
+// 提供了 cookies 时保存为账号的会话；否则使用存储中的有效会话，允许登录时没有会话则打开浏览器登录
+func (c *Crawler) accountCookies(account, cookies string) (string, error) {
+	platform := c.config.Platform
+	if c.sessions == nil {
+		if cookies == "" {
+			return "", errors.New("必须提供Cookie参数")
+		}
+		return cookies, nil
+	}
+
+	if cookies != "" {
+		session := cookiestore.NewSession(platform, account, cookiestore.ParseCookies(cookies), loginCookies(platform))
+		if err := c.sessions.Put(session); err != nil {
+			return "", err
+		}
+		return cookies, nil
+	}
+	if session, err := c.sessions.Get(platform, account); err == nil {
+		log.Printf("使用已保存的 %s 账号 %s 的会话", platform, account)
+		return session.Header(), nil
+	}
+	if !c.config.Login {
+		return "", fmt.Errorf("没有 %s 账号 %s 的有效会话，请提供Cookie参数或使用 -login 登录", platform, account)
+	}
+	session, err := c.sessions.Refresh(platform, account)
+	if err != nil {
+		return "", err
+	}
+	return session.Header(), nil
+}
+
+// loginCookies 返回平台表示登录状态的Cookie名称
+func loginCookies(platform string) []string {
+	if info, ok := crawler.Lookup(platform); ok {
+		return info.LoginCookies
+	}
+	return nil
+}
+
+// recoverSession 登录失效时刷新会话，触发风控验证时处理验证，返回是否可以重试。刷新后的Cookie在下一次请求时生效。
+// 使用多个账号时由账号池在分配的账号上处理，这里不再处理
+func (c *Crawler) recoverSession(err error) bool {
+	if c.accounts != nil {
+		return false
+	}
+	if errors.Is(err, crawler.ErrChallenge) {
+		return c.solveChallenge(c.config.Account, err)
+	}
+	if c.sessions == nil || !errors.Is(err, crawler.ErrAuthExpired) {
+		return false
+	}
+	return c.refreshAccount(c.config.Account)
+}
+
+// newChallengeSolver 根据 -challenge-solver 创建风控验证处理器，为空时返回空
+func newChallengeSolver(solver string) (crawler.ChallengeSolver, error) {
+	switch {
+	case solver == "":
+		return nil, nil
+	case solver == "manual":
+		return autocookie.NewManualSolver(autocookie.NewManager(autocookie.Config{Enabled: true})), nil
+	case strings.HasPrefix(solver, "http://") || strings.HasPrefix(solver, "https://"):
+		return crawler.NewHTTPSolver(solver, 0), nil
+	default:
+		return nil, fmt.Errorf("未知的风控验证处理方式: %s", solver)
+	}
+}
+
+// challengeState 账号最近一次风控验证的结果，同一账号同时只处理一个验证
+type challengeState struct {
+	mutex    sync.Mutex
+	solvedAt time.Time
+	ok       bool
+	cookies  string // 未启用Cookie存储时验证通过后得到的Cookie，由 Crawler.mutex 保护
+}
+
+// solveChallenge 处理账号触发的风控验证，返回验证是否通过，验证通过后的Cookie合并到账号的会话中，
+// 未启用Cookie存储时保存在内存中，由 useSessions 设置的会话刷新用于后续请求。
+// 等待期间其他请求已完成该账号的验证时直接使用其结果
+func (c *Crawler) solveChallenge(account string, err error) bool {
+	if c.solver == nil || !errors.Is(err, crawler.ErrChallenge) {
+		return false
+	}
+
+	c.mutex.Lock()
+	state, ok := c.challenges[account]
+	if !ok {
+		state = &challengeState{}
+		c.challenges[account] = state
+	}
+	c.mutex.Unlock()
+
+	requested := time.Now()
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	if state.solvedAt.After(requested) {
+		return state.ok
+	}
+
+	platform := c.config.Platform
+	challenge := crawler.Challenge{Platform: crawler.Platform(platform), Reason: err.Error()}
+	var challengeErr *crawler.ChallengeError
+	if errors.As(err, &challengeErr) {
+		challenge = challengeErr.Challenge
+	}
+	if challenge.URL == "" {
+		if info, ok := crawler.Lookup(platform); ok {
+			challenge.URL = info.LoginURL
+		}
+	}
+	challenge.Account = account
+	challenge.UserAgent = c.config.UserAgent
+	challenge.Cookies = c.config.Cookies
+	if c.sessions != nil {
+		challenge.Cookies = c.sessions.Header(platform, account)
+	} else if cookies := c.challengeCookies(account); cookies != "" {
+		challenge.Cookies = mergeCookies(challenge.Cookies, cookiestore.ParseCookies(cookies))
+	}
+
+	log.Printf("%s 账号 %s 触发风控验证（%s），正在处理", platform, account, challenge.Reason)
+	cookies, err := c.solver.Solve(&challenge)
+	state.solvedAt = time.Now()
+	state.ok = err == nil
+	if err != nil {
+		log.Printf("%s 账号 %s 的风控验证未通过: %v", platform, account, err)
+		return false
+	}
+
+	if c.sessions != nil && len(cookies) > 0 {
+		if err := c.sessions.Merge(platform, account, cookies); err != nil {
+			log.Printf("保存验证后的Cookie失败: %v", err)
+		}
+	} else if len(cookies) > 0 {
+		c.mutex.Lock()
+		state.cookies = mergeCookies(state.cookies, cookies)
+		c.mutex.Unlock()
+	}
+	log.Printf("%s 账号 %s 的风控验证已通过", platform, account)
+	return true
+}
+
+// challengeCookies 返回未启用Cookie存储时账号验证通过后得到的Cookie
+func (c *Crawler) challengeCookies(account string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if state, ok := c.challenges[account]; ok {
+		return state.cookies
+	}
+	return ""
+}
+
+// mergeCookies 将 cookies 合并到请求头格式的 header 中，同名Cookie使用 cookies 中的值
+func mergeCookies(header string, cookies []*http.Cookie) string {
+	merged := cookiestore.ParseCookies(header)
+	for _, cookie := range cookies {
+		replaced := false
+		for _, existing := range merged {
+			if existing.Name == cookie.Name {
+				existing.Value = cookie.Value
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, &http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+
+	parts := make([]string, 0, len(merged))
+	for _, cookie := range merged {
+		parts = append(parts, cookie.Name+"="+cookie.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// useSessions 让账号爬虫的请求使用存储中该账号的最新会话；
+// 未启用Cookie存储但启用了风控验证处理时，验证通过后得到的Cookie覆盖请求中的同名Cookie
+func (c *Crawler) useSessions(scraper crawler.Scraper, account string) error {
+	platform := c.config.Platform
+	var err error
+	switch {
+	case c.sessions != nil:
+		err = crawler.UseSession(platform, scraper, func() string {
+			return c.sessions.Header(platform, account)
+		})
+	case c.solver != nil:
+		err = crawler.UseCookies(platform, scraper, func() string {
+			return c.challengeCookies(account)
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("启用会话刷新失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"Crawler/crawler"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// CrawlShops 爬取指定店铺的信息和全部商品
+func (c *Crawler) CrawlShops(shopIDs []string) {
+	for _, shopID := range shopIDs {
+		c.crawlShop(shopID)
+	}
+	log.Println("店铺采集完成")
+}
+
+// crawlShop 爬取店铺信息及其全部商品，每个店铺只爬取一次
+func (c *Crawler) crawlShop(shopID string) {
+	if !c.markShopVisited(shopID) {
+		return
+	}
+
+	// 获取店铺信息
+	shopData, err := c.scraper.GetShopInfo(shopID)
+	if c.recoverSession(err) {
+		shopData, err = c.scraper.GetShopInfo(shopID)
+	}
+	if err != nil {
+		log.Printf("获取店铺 %s 信息失败: %v", shopID, err)
+	} else {
+		c.saveShopData(shopData)
+	}
+
+	// 分页获取店铺商品
+	cursor := ""
+	retryCount := 0
+
+	for {
+		products, nextCursor, err := c.scraper.GetShopProducts(shopID, cursor)
+		if err != nil {
+			c.recoverSession(err)
+			retryCount++
+			if retryCount > c.config.Retries {
+				log.Printf("获取店铺 %s 商品列表失败，已达到最大重试次数", shopID)
+				break
+			}
+			log.Printf("获取店铺 %s 商品列表失败: %v，正在重试...", shopID, err)
+			time.Sleep(time.Duration(c.config.Timeout) * time.Second)
+			continue
+		}
+
+		// 重置重试计数
+		retryCount = 0
+
+		// 保存商品数据并爬取评价，跳过非农产品商品。
+		// 商品记录所属店铺，以便通过视频商品关联查询带货店铺商品的视频
+		for _, product := range products {
+			if product.ShopID == "" {
+				product.ShopID = shopID
+			}
+			if !c.enrichProduct(product) {
+				continue
+			}
+
+			c.saveProductInfo(product)
+			c.crawlProductReviews(product.ProductID)
+		}
+
+		// 如果没有更多数据，或者下一页游标与当前游标相同，则退出循环
+		if nextCursor == "" || nextCursor == cursor {
+			break
+		}
+
+		// 更新游标
+		cursor = nextCursor
+
+		// 休眠一段时间，避免请求过于频繁
+		time.Sleep(time.Second * 3)
+	}
+}
+
+// markShopVisited 标记店铺已爬取，店铺已爬取过时返回 false
+func (c *Crawler) markShopVisited(shopID string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.visitedShops[shopID] {
+		return false
+	}
+	c.visitedShops[shopID] = true
+	return true
+}
+
+// saveShopData 保存店铺数据，启用数据库存储时同时写入数据库
+func (c *Crawler) saveShopData(shopData *crawler.ShopData) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// 标记所属平台
+	shopData.Platform = c.config.Platform
+
+	if c.store.IsEnabled() {
+		if err := c.store.SaveShop(shopData, c.config.Platform); err != nil {
+			log.Printf("保存店铺 %s 到数据库失败: %v", shopData.ShopID, err)
+		}
+	}
+
+	// 将店铺数据转换为JSON
+	jsonData, err := json.MarshalIndent(shopData, "", "  ")
+	if err != nil {
+		log.Printf("序列化店铺数据失败: %v", err)
+		return
+	}
+
+	// 保存到文件
+	filePath := fmt.Sprintf("%s/shop_%s.json", c.config.OutputDir, shopData.ShopID)
+	if err := os.WriteFile(filePath, jsonData, 0644); err != nil {
+		log.Printf("保存店铺数据到文件失败: %v", err)
+		return
+	}
+
+	log.Printf("已保存店铺 %s 的数据到 %s", shopData.ShopID, filePath)
+}
@@ -0,0 +1,275 @@
+package accountpool
+
+import (
+	"Crawler/utils/logger"
+	"Crawler/utils/ratelimit"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoAccount 账号池中没有可用的账号，全部账号均已停用
+var ErrNoAccount = errors.New("没有可用的账号")
+
+// 账号分配策略
+const (
+	RoundRobin = "round_robin" // 按顺序轮流分配
+	LeastUsed  = "least_used"  // 优先分配正在使用和累计请求最少的账号
+)
+
+// Config 账号池配置
+type Config struct {
+	Strategy          string `json:"strategy"`            // 分配策略：round_robin 或 least_used，默认 round_robin
+	RequestsPerMinute int    `json:"requests_per_minute"` // 每个账号每分钟的请求数，0 表示不限制
+	MaxFailures       int    `json:"max_failures"`        // 连续失败多少次后进入冷却，默认 3
+	Cooldown          int    `json:"cooldown"`            // 冷却时间（秒），默认 300
+}
+
+// Account 账号池中的账号
+type Account struct {
+	Name string
+
+	limiter       *ratelimit.Limiter
+	requests      int
+	failures      int
+	consecutive   int // 连续失败次数
+	inFlight      int
+	cooldownUntil time.Time
+	paused        bool
+	retired       bool
+	reason        string
+}
+
+// Stats 账号的使用统计
+type Stats struct {
+	Name          string    `json:"name"`
+	Requests      int       `json:"requests"`
+	Failures      int       `json:"failures"`
+	InFlight      int       `json:"in_flight"`
+	CooldownUntil time.Time `json:"cooldown_until,omitempty"`
+	Paused        bool      `json:"paused"`
+	Retired       bool      `json:"retired"`
+	Reason        string    `json:"reason,omitempty"` // 最近一次冷却或停用的原因
+}
+
+// Pool 平台账号池，为每次请求分配账号并跟踪各账号的请求数、失败和冷却状态
+type Pool struct {
+	strategy          string
+	requestsPerMinute int
+	maxFailures       int
+	cooldown          time.Duration
+	accounts          []*Account
+	next              int
+	mutex             sync.Mutex
+}
+
+// New 创建账号池
+func New(config Config) *Pool {
+	if config.Strategy == "" {
+		config.Strategy = RoundRobin
+	}
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = 3
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 300
+	}
+
+	return &Pool{
+		strategy:          config.Strategy,
+		requestsPerMinute: config.RequestsPerMinute,
+		maxFailures:       config.MaxFailures,
+		cooldown:          time.Duration(config.Cooldown) * time.Second,
+	}
+}
+
+// Add 添加账号，每个账号使用独立的速率限制器
+func (p *Pool) Add(name string) *Account {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	account := &Account{
+		Name: name,
+		limiter: ratelimit.NewLimiter(ratelimit.Config{
+			Enabled:           p.requestsPerMinute > 0,
+			RequestsPerMinute: p.requestsPerMinute,
+		}),
+	}
+	p.accounts = append(p.accounts, account)
+	return account
+}
+
+// Acquire 按分配策略选择可用的账号，并等待该账号的速率限制。
+// 全部账号都在冷却或暂停时等待账号恢复，全部账号均已停用时返回 ErrNoAccount
+func (p *Pool) Acquire() (*Account, error) {
+	for {
+		p.mutex.Lock()
+		account, wait := p.pick()
+		if account != nil {
+			account.inFlight++
+			account.requests++
+		}
+		p.mutex.Unlock()
+
+		if account != nil {
+			account.limiter.Wait()
+			return account, nil
+		}
+		if wait <= 0 {
+			return nil, ErrNoAccount
+		}
+
+		logger.Debug("全部账号都在冷却，等待 %v", wait)
+		time.Sleep(wait)
+	}
+}
+
+// pick 选择可用的账号，没有可用账号时返回再次尝试前的等待时间，调用方需持有锁
+func (p *Pool) pick() (*Account, time.Duration) {
+	now := time.Now()
+	var best *Account
+	var wait time.Duration
+	for i := range p.accounts {
+		// 轮询从上次分配的下一个账号开始查找
+		index := i
+		if p.strategy == RoundRobin {
+			index = (p.next + i) % len(p.accounts)
+		}
+		account := p.accounts[index]
+		if account.retired {
+			continue
+		}
+		if account.paused {
+			if wait == 0 || time.Second < wait {
+				wait = time.Second
+			}
+			continue
+		}
+		if remaining := account.cooldownUntil.Sub(now); remaining > 0 {
+			if wait == 0 || remaining < wait {
+				wait = remaining
+			}
+			continue
+		}
+
+		if p.strategy == RoundRobin {
+			p.next = index + 1
+			return account, 0
+		}
+		if best == nil || account.inFlight < best.inFlight ||
+			(account.inFlight == best.inFlight && account.requests < best.requests) {
+			best = account
+		}
+	}
+	return best, wait
+}
+
+// Release 归还账号并记录请求结果，连续失败达到上限时账号进入冷却
+func (p *Pool) Release(account *Account, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	account.inFlight--
+	if err == nil {
+		account.consecutive = 0
+		return
+	}
+
+	account.failures++
+	account.consecutive++
+	if account.consecutive >= p.maxFailures && !account.retired {
+		p.cooldownLocked(account, p.cooldown, err.Error())
+	}
+}
+
+// Cooldown 暂停分配账号一段时间，d 为 0 时使用配置的冷却时间
+func (p *Pool) Cooldown(account *Account, d time.Duration, reason string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if d <= 0 {
+		d = p.cooldown
+	}
+	p.cooldownLocked(account, d, reason)
+}
+
+// cooldownLocked 设置账号冷却，调用方需持有锁
+func (p *Pool) cooldownLocked(account *Account, d time.Duration, reason string) {
+	account.cooldownUntil = time.Now().Add(d)
+	account.paused = false
+	account.consecutive = 0
+	account.reason = reason
+	logger.Warn("账号 %s 暂停使用 %v: %s", account.Name, d, reason)
+}
+
+// Pause 暂停分配账号直到调用 Resume 或 Cooldown，用于处理验证等需要独占账号的操作
+func (p *Pool) Pause(account *Account, reason string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	account.paused = true
+	account.reason = reason
+	logger.Info("账号 %s 暂停使用: %s", account.Name, reason)
+}
+
+// Resume 恢复分配暂停或冷却中的账号
+func (p *Pool) Resume(account *Account) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	account.paused = false
+	account.cooldownUntil = time.Time{}
+	account.consecutive = 0
+	account.reason = ""
+}
+
+// Retire 停用账号，之后不再分配
+func (p *Pool) Retire(account *Account, reason string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if account.retired {
+		return
+	}
+	account.retired = true
+	account.reason = reason
+	logger.Warn("账号 %s 已停用: %s", account.Name, reason)
+}
+
+// Size 返回未停用的账号数
+func (p *Pool) Size() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	size := 0
+	for _, account := range p.accounts {
+		if !account.retired {
+			size++
+		}
+	}
+	return size
+}
+
+// Stats 返回全部账号的使用统计，按添加顺序排列
+func (p *Pool) Stats() []Stats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := make([]Stats, 0, len(p.accounts))
+	for _, account := range p.accounts {
+		stat := Stats{
+			Name:     account.Name,
+			Requests: account.requests,
+			Failures: account.failures,
+			InFlight: account.inFlight,
+			Paused:   account.paused,
+			Retired:  account.retired,
+			Reason:   account.reason,
+		}
+		if account.cooldownUntil.After(time.Now()) {
+			stat.CooldownUntil = account.cooldownUntil
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
@@ -1,128 +1,453 @@
 package autocookie
 
 import (
+	"Crawler/crawler"
+	"Crawler/utils/browserpool"
 	"Crawler/utils/logger"
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
 	"github.com/chromedp/chromedp"
 )
 
 // Manager Cookie管理器
 type Manager struct {
-	enabled     bool
-	browserType string
-	headless    bool
+	enabled      bool
+	browserType  string
+	headless     bool
+	loginTimeout time.Duration
+	qrCodeFile   string
+	pool         *browserpool.Pool
+	ownPool      bool
+	mutex        sync.Mutex
 }
 
 // Config Cookie配置
 type Config struct {
-	Enabled     bool   `json:"enabled"`
-	BrowserType string `json:"browser_type"`
-	Headless    bool   `json:"headless"`
+	Enabled      bool   `json:"enabled"`
+	BrowserType  string `json:"browser_type"`
+	Headless     bool   `json:"headless"`
+	LoginTimeout int    `json:"login_timeout"` // 等待登录的超时时间（秒），默认 180
+	QRCodeFile   string `json:"qrcode_file"`   // 无头模式下保存登录二维码的文件，默认为当前目录下的 <平台>_login_qrcode.png
+}
+
+// LoginResult 登录结果
+type LoginResult struct {
+	Platform   string
+	Cookies    []*http.Cookie
+	QRCodeFile string        // 保存的登录二维码图片，没有截取到二维码时为空
+	ExpiresAt  time.Time     // 登录状态Cookie的最早过期时间，零值表示未知
+	Elapsed    time.Duration // 从打开登录页面到登录成功的时间
 }
 
 // NewManager 创建Cookie管理器
 func NewManager(config Config) *Manager {
+	loginTimeout := config.LoginTimeout
+	if loginTimeout <= 0 {
+		loginTimeout = 180
+	}
+
 	return &Manager{
-		enabled:     config.Enabled,
-		browserType: config.BrowserType,
-		headless:    config.Headless,
+		enabled:      config.Enabled,
+		browserType:  config.BrowserType,
+		headless:     config.Headless,
+		loginTimeout: time.Duration(loginTimeout) * time.Second,
+		qrCodeFile:   config.QRCodeFile,
 	}
 }
 
-// GetCookies 获取指定网站的Cookie
-func (m *Manager) GetCookies(platform string) (string, error) {
-	if !m.enabled {
-		return "", fmt.Errorf("自动获取Cookie功能未启用")
+// SetPool 使用共用的浏览器池获取Cookie，浏览器池的无头模式需与 headless 配置一致
+func (m *Manager) SetPool(pool *browserpool.Pool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.ownPool {
+		m.pool.Close()
 	}
+	m.pool = pool
+	m.ownPool = false
+}
 
-	var url string
-	switch platform {
-	case "douyin":
-		url = "https://www.douyin.com/"
-	case "kuaishou":
-		url = "https://www.kuaishou.com/"
-	default:
-		return "", fmt.Errorf("不支持的平台: %s", platform)
+// getPool 返回浏览器池，未设置共用浏览器池时创建独立的浏览器池
+func (m *Manager) getPool() *browserpool.Pool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.pool == nil {
+		m.pool = browserpool.New(browserpool.Config{Size: 1, TabsPerBrowser: 1, Headless: m.headless})
+		m.ownPool = true
 	}
+	return m.pool
+}
 
-	logger.Info("正在自动获取 %s 的Cookie...", platform)
+// Close 关闭独立创建的浏览器池
+func (m *Manager) Close() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 
-	// 创建Chrome实例的选项
-	opts := []chromedp.ExecAllocatorOption{
-		chromedp.NoFirstRun,
-		chromedp.NoDefaultBrowserCheck,
-		chromedp.DisableGPU,
+	if m.ownPool {
+		m.pool.Close()
+		m.pool, m.ownPool = nil, false
 	}
+}
 
-	// 根据配置决定是否使用无头模式
-	if m.headless {
-		opts = append(opts, chromedp.Headless)
+// GetCookies 登录并获取指定平台的Cookie，登录过程见 Login。
+// 通过 CDP 读取浏览器中的全部Cookie（包括 HttpOnly Cookie），只保留平台主域名及其子域名下的Cookie
+func (m *Manager) GetCookies(platform string) ([]*http.Cookie, error) {
+	result, err := m.Login(platform)
+	if err != nil {
+		return nil, err
 	}
+	return result.Cookies, nil
+}
 
-	// 创建Chrome实例
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	defer cancel()
+// Login 打开平台登录页面并等待登录。
+// 无头模式下截取页面中的登录二维码，在终端中绘制并保存为 PNG 文件，用手机 App 扫码登录，二维码刷新后重新输出；
+// 有界面模式下直接在打开的浏览器中登录。两种模式都轮询浏览器Cookie，出现登录状态Cookie即登录成功，超时返回错误
+func (m *Manager) Login(platform string) (*LoginResult, error) {
+	return m.collect(platform, nil)
+}
 
-	// 创建新的Chrome实例
-	ctx, cancel := chromedp.NewContext(allocCtx)
-	defer cancel()
+// RefreshCookies 在浏览器中载入已有的Cookie并重新访问平台，获取服务端续期后的Cookie。
+// 无需人工操作，登录状态已失效时返回错误，需要调用 GetCookies 重新登录
+func (m *Manager) RefreshCookies(platform string, cookies []*http.Cookie) ([]*http.Cookie, error) {
+	if len(cookies) == 0 {
+		return nil, fmt.Errorf("没有可刷新的Cookie")
+	}
+	result, err := m.collect(platform, cookies)
+	if err != nil {
+		return nil, err
+	}
+	return result.Cookies, nil
+}
+
+// collect 打开平台登录页面并读取Cookie，existing 非空时先载入已有Cookie并跳过登录
+func (m *Manager) collect(platform string, existing []*http.Cookie) (*LoginResult, error) {
+	if !m.enabled {
+		return nil, fmt.Errorf("自动获取Cookie功能未启用")
+	}
+
+	// 从平台注册信息中获取登录地址
+	info, ok := crawler.Lookup(platform)
+	if !ok || info.LoginURL == "" {
+		return nil, fmt.Errorf("不支持的平台: %s", platform)
+	}
+	loginURL, err := url.Parse(info.LoginURL)
+	if err != nil {
+		return nil, fmt.Errorf("解析登录地址失败: %v", err)
+	}
+	domain := baseDomain(loginURL.Hostname())
+
+	if existing == nil {
+		logger.Info("正在自动获取 %s 的Cookie...", platform)
+	} else {
+		logger.Info("正在刷新 %s 的Cookie...", platform)
+	}
+
+	// 从浏览器池租用页面，获取完成后关闭页面，避免登录状态被其他租用者复用
+	tab, err := m.getPool().Acquire(context.Background(), browserpool.TabOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("租用浏览器页面失败: %v", err)
+	}
+	defer tab.Discard()
+	start := time.Now()
 
 	// 设置超时时间
-	ctx, cancel = context.WithTimeout(ctx, 60*time.Second)
+	ctx, cancel := context.WithTimeout(tab.Context(), 60*time.Second)
 	defer cancel()
 
+	// 载入已有的Cookie
+	if existing != nil {
+		if err := chromedp.Run(ctx, setBrowserCookies(existing, domain)); err != nil {
+			return nil, fmt.Errorf("载入Cookie失败: %v", err)
+		}
+	}
+
 	// 访问目标网站
-	if err := chromedp.Run(ctx, chromedp.Navigate(url)); err != nil {
-		return "", fmt.Errorf("访问网站失败: %v", err)
+	if err := chromedp.Run(ctx, chromedp.Navigate(info.LoginURL)); err != nil {
+		return nil, fmt.Errorf("访问网站失败: %v", err)
 	}
 
 	// 等待页面加载完成
 	if err := chromedp.Run(ctx, chromedp.Sleep(5*time.Second)); err != nil {
-		return "", fmt.Errorf("等待页面加载失败: %v", err)
+		return nil, fmt.Errorf("等待页面加载失败: %v", err)
 	}
 
-	logger.Info("页面已加载，请在浏览器中手动登录...")
+	// 等待登录
+	result := &LoginResult{Platform: platform}
+	if existing == nil {
+		loginCtx, cancelLogin := context.WithTimeout(tab.Context(), m.loginTimeout)
+		defer cancelLogin()
+		if err := m.waitForLogin(loginCtx, info, domain, result); err != nil {
+			return nil, err
+		}
+	}
+
+	// 登录可能超过页面超时时间，读取Cookie使用新的超时
+	readCtx, cancelRead := context.WithTimeout(tab.Context(), 10*time.Second)
+	defer cancelRead()
+
+	cookies, err := readCookies(readCtx, domain)
+	if err != nil {
+		return nil, err
+	}
+	if len(cookies) == 0 {
+		return nil, fmt.Errorf("未获取到 %s 的Cookie", domain)
+	}
 
-	// 等待用户登录
-	if !m.headless {
-		logger.Info("请在打开的浏览器中登录，登录成功后按回车继续...")
-		fmt.Scanln()
+	// 刷新时登录状态Cookie缺失说明登录已失效
+	if existing != nil && !hasLoginCookie(cookies, info.LoginCookies) {
+		return nil, fmt.Errorf("%s 的登录状态已失效，需要重新登录", platform)
+	}
+
+	result.Cookies = cookies
+	result.ExpiresAt = loginExpiry(cookies, info.LoginCookies)
+	result.Elapsed = time.Since(start)
+
+	header := CookieHeader(cookies)
+	logger.Info("成功获取 %d 个Cookie: %s", len(cookies), header[:min(len(header), 30)]+"...")
+	return result, nil
+}
+
+// waitForLogin 轮询浏览器Cookie直到出现登录状态Cookie。
+// 无头模式下每次轮询检查登录二维码，二维码变化时在终端中绘制并保存到 result.QRCodeFile
+func (m *Manager) waitForLogin(ctx context.Context, info *crawler.PlatformInfo, domain string, result *LoginResult) error {
+	if m.headless {
+		logger.Info("页面已加载，正在获取登录二维码...")
 	} else {
-		// 在无头模式下，等待一段时间
-		if err := chromedp.Run(ctx, chromedp.Sleep(30*time.Second)); err != nil {
-			return "", fmt.Errorf("等待登录超时: %v", err)
+		logger.Info("页面已加载，请在打开的浏览器中登录...")
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	var lastQRCode string
+	for {
+		cookies, err := readCookies(ctx, domain)
+		if err == nil && hasLoginCookie(cookies, info.LoginCookies) {
+			logger.Info("%s 登录成功", info.DisplayName)
+			return nil
+		}
+
+		if m.headless {
+			if qrCode, err := m.showQRCode(ctx, string(info.Name), lastQRCode, result); err == nil {
+				lastQRCode = qrCode
+			} else if !errors.Is(err, errNoQRCode) && ctx.Err() == nil {
+				logger.Warn("获取登录二维码失败: %v", err)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if m.headless && lastQRCode == "" {
+				return fmt.Errorf("等待 %s 登录超时，页面中没有找到登录二维码", info.DisplayName)
+			}
+			return fmt.Errorf("等待 %s 登录超时", info.DisplayName)
 		}
 	}
+}
 
-	// 获取Cookie
-	var cookies []*struct {
-		Name  string `json:"name"`
-		Value string `json:"value"`
+// showQRCode 截取登录二维码，与上次输出的二维码 last 不同时在终端中绘制并保存为 PNG 文件，返回当前二维码
+func (m *Manager) showQRCode(ctx context.Context, platform, last string, result *LoginResult) (string, error) {
+	data, err := captureQRCode(ctx)
+	if err != nil {
+		return last, err
 	}
-	if err := chromedp.Run(ctx, chromedp.Evaluate(`
-		(function() {
-			return document.cookie.split(';').map(function(cookie) {
-				const parts = cookie.trim().split('=');
-				return {name: parts[0], value: parts[1]};
-			});
-		})()
-	`, &cookies)); err != nil {
-		return "", fmt.Errorf("获取Cookie失败: %v", err)
+	modules, err := qrModules(data)
+	if err != nil {
+		return last, err
+	}
+	qrCode := renderQRCode(modules)
+	if qrCode == last {
+		return last, nil
 	}
 
-	// 格式化Cookie
-	var cookieParts []string
+	file := m.qrCodeFile
+	if file == "" {
+		file = platform + "_login_qrcode.png"
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return last, fmt.Errorf("保存登录二维码失败: %v", err)
+	}
+	result.QRCodeFile = file
+
+	if last == "" {
+		logger.Info("请使用手机 App 扫描二维码登录，二维码图片已保存到 %s", file)
+	} else {
+		logger.Info("登录二维码已刷新，请重新扫描，二维码图片已保存到 %s", file)
+	}
+	fmt.Print(qrCode)
+	return qrCode, nil
+}
+
+// readCookies 读取页面所在浏览器上下文中平台主域名及其子域名下的Cookie
+func readCookies(ctx context.Context, domain string) ([]*http.Cookie, error) {
+	var browserCookies []*network.Cookie
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		c := chromedp.FromContext(ctx)
+		var err error
+		browserCookies, err = storage.GetCookies().WithBrowserContextID(c.BrowserContextID).Do(cdp.WithExecutor(ctx, c.Browser))
+		return err
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("获取Cookie失败: %v", err)
+	}
+
+	// 按平台主域名过滤
+	var cookies []*http.Cookie
+	for _, cookie := range browserCookies {
+		host := strings.TrimPrefix(cookie.Domain, ".")
+		if host != domain && !strings.HasSuffix(host, "."+domain) {
+			continue
+		}
+		cookies = append(cookies, convertCookie(cookie))
+	}
+	return cookies, nil
+}
+
+// setBrowserCookies 将Cookie写入页面所在的浏览器上下文，没有域名的Cookie写入平台主域名
+func setBrowserCookies(cookies []*http.Cookie, domain string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		params := make([]*network.CookieParam, 0, len(cookies))
+		for _, cookie := range cookies {
+			param := &network.CookieParam{
+				Name:     cookie.Name,
+				Value:    cookie.Value,
+				Domain:   cookie.Domain,
+				Path:     cookie.Path,
+				Secure:   cookie.Secure,
+				HTTPOnly: cookie.HttpOnly,
+			}
+			if param.Domain == "" {
+				param.Domain = "." + domain
+			}
+			if param.Path == "" {
+				param.Path = "/"
+			}
+			if !cookie.Expires.IsZero() {
+				expires := cdp.TimeSinceEpoch(cookie.Expires)
+				param.Expires = &expires
+			}
+			params = append(params, param)
+		}
+
+		c := chromedp.FromContext(ctx)
+		return storage.SetCookies(params).WithBrowserContextID(c.BrowserContextID).Do(cdp.WithExecutor(ctx, c.Browser))
+	})
+}
+
+// hasLoginCookie 判断是否包含平台的登录状态Cookie，平台未声明时视为包含
+func hasLoginCookie(cookies []*http.Cookie, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
 	for _, cookie := range cookies {
-		cookieParts = append(cookieParts, fmt.Sprintf("%s=%s", cookie.Name, cookie.Value))
+		for _, name := range names {
+			if cookie.Name == name && cookie.Value != "" {
+				return true
+			}
+		}
 	}
-	cookieStr := strings.Join(cookieParts, "; ")
+	return false
+}
 
-	logger.Info("成功获取Cookie: %s", cookieStr[:min(len(cookieStr), 30)]+"...")
-	return cookieStr, nil
+// loginExpiry 返回登录状态Cookie中最早的过期时间，零值表示未知
+func loginExpiry(cookies []*http.Cookie, names []string) time.Time {
+	var expiresAt time.Time
+	for _, cookie := range cookies {
+		for _, name := range names {
+			if cookie.Name == name && !cookie.Expires.IsZero() && (expiresAt.IsZero() || cookie.Expires.Before(expiresAt)) {
+				expiresAt = cookie.Expires
+			}
+		}
+	}
+	return expiresAt
+}
+
+// baseDomain 返回主机名的主域名，如 www.douyin.com 返回 douyin.com
+func baseDomain(host string) string {
+	labels := strings.Split(host, ".")
+	if len(labels) <= 2 {
+		return host
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
+
+// convertCookie 将浏览器Cookie转换为 http.Cookie，会话Cookie的 Expires 为零值
+func convertCookie(cookie *network.Cookie) *http.Cookie {
+	converted := &http.Cookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Domain:   cookie.Domain,
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HTTPOnly,
+	}
+	if !cookie.Session && cookie.Expires > 0 {
+		converted.Expires = time.Unix(int64(cookie.Expires), 0)
+	}
+	switch cookie.SameSite {
+	case network.CookieSameSiteStrict:
+		converted.SameSite = http.SameSiteStrictMode
+	case network.CookieSameSiteLax:
+		converted.SameSite = http.SameSiteLaxMode
+	case network.CookieSameSiteNone:
+		converted.SameSite = http.SameSiteNoneMode
+	}
+	return converted
+}
+
+// CookieHeader 将Cookie格式化为请求头中的Cookie字符串，跳过已过期的Cookie
+func CookieHeader(cookies []*http.Cookie) string {
+	now := time.Now()
+	var parts []string
+	for _, cookie := range cookies {
+		if !cookie.Expires.IsZero() && cookie.Expires.Before(now) {
+			continue
+		}
+		parts = append(parts, cookie.Name+"="+cookie.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// NewJar 创建包含指定Cookie的 http.CookieJar。
+// Domain 以点开头的Cookie对子域名生效，其余Cookie只对所在主机生效
+func NewJar(cookies []*http.Cookie) (http.CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cookie := range cookies {
+		host := strings.TrimPrefix(cookie.Domain, ".")
+		if host == "" {
+			continue
+		}
+		path := cookie.Path
+		if path == "" {
+			path = "/"
+		}
+
+		// cookiejar 中 Domain 非空表示域名Cookie，主机Cookie需要清空 Domain
+		entry := *cookie
+		if !strings.HasPrefix(cookie.Domain, ".") {
+			entry.Domain = ""
+		}
+		jar.SetCookies(&url.URL{Scheme: "https", Host: host, Path: path}, []*http.Cookie{&entry})
+	}
+	return jar, nil
 }
 
 // min 返回两个整数中的较小值
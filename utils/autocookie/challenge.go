@@ -0,0 +1,105 @@
+package autocookie
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/browserpool"
+	"Crawler/utils/logger"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// challengeDoneScript 判断验证是否已完成：页面地址不是验证页面，且页面中没有验证码元素
+const challengeDoneScript = `!/captcha|verify/i.test(location.href) &&
+	!document.querySelector('[id*="captcha"], [class*="captcha"], iframe[src*="captcha"], iframe[src*="verify"]')`
+
+// ManualSolver 在浏览器中打开验证页面，等待人工完成验证的 crawler.ChallengeSolver
+type ManualSolver struct {
+	manager *Manager
+}
+
+// NewManualSolver 创建人工处理验证的 ChallengeSolver，manager 需使用有界面的浏览器
+func NewManualSolver(manager *Manager) *ManualSolver {
+	return &ManualSolver{manager: manager}
+}
+
+// Solve 实现 crawler.ChallengeSolver。载入触发验证的会话Cookie后打开验证页面，
+// 每 2 秒检查一次验证是否完成，完成后返回浏览器中平台的Cookie，超过登录超时时间返回错误
+func (s *ManualSolver) Solve(challenge *crawler.Challenge) ([]*http.Cookie, error) {
+	m := s.manager
+	if !m.enabled {
+		return nil, fmt.Errorf("自动获取Cookie功能未启用")
+	}
+	if m.headless {
+		return nil, errors.New("人工处理验证需要有界面的浏览器")
+	}
+
+	info, ok := crawler.Lookup(string(challenge.Platform))
+	if !ok || info.LoginURL == "" {
+		return nil, fmt.Errorf("不支持的平台: %s", challenge.Platform)
+	}
+	loginURL, err := url.Parse(info.LoginURL)
+	if err != nil {
+		return nil, fmt.Errorf("解析登录地址失败: %v", err)
+	}
+	domain := baseDomain(loginURL.Hostname())
+
+	// 平台以外的独立验证页面无法使用会话Cookie，改为打开平台首页由平台弹出验证
+	page := challenge.URL
+	if u, err := url.Parse(page); err != nil || baseDomain(u.Hostname()) != domain {
+		page = info.LoginURL
+	}
+
+	tab, err := m.getPool().Acquire(context.Background(), browserpool.TabOptions{UserAgent: challenge.UserAgent})
+	if err != nil {
+		return nil, fmt.Errorf("租用浏览器页面失败: %v", err)
+	}
+	defer tab.Discard()
+
+	ctx, cancel := context.WithTimeout(tab.Context(), m.loginTimeout)
+	defer cancel()
+
+	// 载入触发验证的会话Cookie
+	var existing []*http.Cookie
+	for _, part := range strings.Split(challenge.Cookies, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && name != "" {
+			existing = append(existing, &http.Cookie{Name: name, Value: value})
+		}
+	}
+	if len(existing) > 0 {
+		if err := chromedp.Run(ctx, setBrowserCookies(existing, domain)); err != nil {
+			return nil, fmt.Errorf("载入Cookie失败: %v", err)
+		}
+	}
+
+	if err := chromedp.Run(ctx, chromedp.Navigate(page), chromedp.Sleep(5*time.Second)); err != nil {
+		return nil, fmt.Errorf("打开验证页面失败: %v", err)
+	}
+	logger.Info("请在打开的浏览器中完成 %s 的验证（%s），完成后如页面未自动跳转，请手动打开平台首页", info.DisplayName, challenge.Reason)
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		var done bool
+		if err := chromedp.Run(ctx, chromedp.Evaluate(challengeDoneScript, &done)); err == nil && done {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("等待完成验证超时（%v）", m.loginTimeout)
+		case <-ticker.C:
+		}
+	}
+	logger.Info("%s 验证已完成", info.DisplayName)
+
+	readCtx, cancelRead := context.WithTimeout(tab.Context(), 10*time.Second)
+	defer cancelRead()
+	return readCookies(readCtx, domain)
+}
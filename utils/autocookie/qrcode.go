@@ -0,0 +1,149 @@
+package autocookie
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// errNoQRCode 页面中没有找到登录二维码
+var errNoQRCode = errors.New("页面中没有登录二维码")
+
+// findQRCodeScript 在页面中查找登录二维码并标记为 data-crawler-qrcode。
+// 候选为可见的近似正方形图片或画布，类名、地址或说明中包含 qr 或“二维码”的优先；
+// 没有候选时点击一次文字为“登录”的按钮打开登录弹窗，返回 found、clicked 或 none
+const findQRCodeScript = `(() => {
+	document.querySelectorAll('[data-crawler-qrcode]').forEach(el => el.removeAttribute('data-crawler-qrcode'));
+	const hint = el => {
+		const parent = el.parentElement ? String(el.parentElement.className) : '';
+		const text = [String(el.className), el.id, el.getAttribute('src') || '', el.getAttribute('alt') || '', parent].join(' ');
+		return /qr|二维码/i.test(text) ? 1 : 0;
+	};
+	const candidates = [...document.querySelectorAll('img, canvas')].filter(el => {
+		const rect = el.getBoundingClientRect();
+		return rect.width >= 80 && rect.width <= 500 && Math.abs(rect.width - rect.height) <= rect.width * 0.1;
+	});
+	candidates.sort((a, b) => hint(b) - hint(a));
+	if (candidates.length > 0) {
+		candidates[0].setAttribute('data-crawler-qrcode', '1');
+		return 'found';
+	}
+	const login = [...document.querySelectorAll('button, a, div, span')].find(el => el.children.length === 0 && el.innerText && el.innerText.trim() === '登录');
+	if (login && !window.__crawlerLoginClicked) {
+		window.__crawlerLoginClicked = true;
+		login.click();
+		return 'clicked';
+	}
+	return 'none';
+})()`
+
+// captureQRCode 截取页面中的登录二维码，返回 PNG 图片
+func captureQRCode(ctx context.Context) ([]byte, error) {
+	var state string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(findQRCodeScript, &state)); err != nil {
+		return nil, err
+	}
+	if state != "found" {
+		return nil, errNoQRCode
+	}
+
+	var buf []byte
+	if err := chromedp.Run(ctx, chromedp.Screenshot(`[data-crawler-qrcode]`, &buf, chromedp.ByQuery)); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// qrModules 从二维码截图中还原模块矩阵，true 表示深色模块。
+// 根据左上角定位图案（7 个模块宽）估算模块大小，无法识别时按 41 个模块采样
+func qrModules(data []byte) ([][]bool, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// 深色像素的外接矩形即二维码区域
+	bounds := img.Bounds()
+	dark := func(x, y int) bool {
+		gray := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+		return gray.Y < 128
+	}
+	left, top, right, bottom := bounds.Max.X, bounds.Max.Y, bounds.Min.X-1, bounds.Min.Y-1
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if dark(x, y) {
+				left, right = min(left, x), max(right, x)
+				top, bottom = min(top, y), max(bottom, y)
+			}
+		}
+	}
+	if right < left || bottom < top {
+		return nil, errNoQRCode
+	}
+	width := right - left + 1
+
+	// 定位图案第一行是 7 个模块宽的连续深色像素，模块数为 21 + 4k
+	run := 0
+	for x := left; x <= right && dark(x, top); x++ {
+		run++
+	}
+	size := 41
+	if moduleSize := float64(run) / 7; moduleSize >= 1 {
+		version := math.Round((float64(width)/moduleSize - 21) / 4)
+		if version >= 0 && version <= 39 {
+			size = 21 + 4*int(version)
+		}
+	}
+
+	// 在每个模块的中心采样
+	step := float64(width) / float64(size)
+	modules := make([][]bool, size)
+	for row := range modules {
+		modules[row] = make([]bool, size)
+		for col := range modules[row] {
+			x := left + int((float64(col)+0.5)*step)
+			y := top + int((float64(row)+0.5)*step)
+			modules[row][col] = dark(x, y)
+		}
+	}
+	return modules, nil
+}
+
+// renderQRCode 用 Unicode 半格字符在终端绘制二维码，每行字符对应两行模块。
+// 浅色模块绘制为实心，在深色背景的终端中与原图一致，四周保留 2 个模块的空白
+func renderQRCode(modules [][]bool) string {
+	const quiet = 2
+	size := len(modules) + 2*quiet
+	light := func(row, col int) bool {
+		row, col = row-quiet, col-quiet
+		if row < 0 || col < 0 || row >= len(modules) || col >= len(modules) {
+			return true
+		}
+		return !modules[row][col]
+	}
+
+	var b strings.Builder
+	for row := 0; row < size; row += 2 {
+		for col := 0; col < size; col++ {
+			upper, lower := light(row, col), row+1 < size && light(row+1, col)
+			switch {
+			case upper && lower:
+				b.WriteString("█")
+			case upper:
+				b.WriteString("▀")
+			case lower:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,243 @@
+package classifier
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/logger"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed taxonomy.json
+var defaultTaxonomy []byte
+
+// 关键词权重，具体品类比大类泛称更可信
+const (
+	categoryWeight = 1.0
+	cropWeight     = 2.0
+)
+
+// Taxonomy 农产品分类体系
+type Taxonomy struct {
+	Categories []Category `json:"categories"`
+	Exclude    []string   `json:"exclude"` // 命中即判定为非农产品的词，如“苹果手机”
+}
+
+// Category 农产品大类
+type Category struct {
+	Name     string   `json:"name"`
+	Label    string   `json:"label"`
+	Keywords []string `json:"keywords"` // 大类泛称
+	Crops    []Crop   `json:"crops"`
+}
+
+// Crop 具体品类
+type Crop struct {
+	Name     string   `json:"name"`
+	Label    string   `json:"label"`
+	Keywords []string `json:"keywords"`
+}
+
+// Config 分类器配置
+type Config struct {
+	Enabled             bool    `json:"enabled"`
+	TaxonomyFile        string  `json:"taxonomy_file"`         // 自定义分类体系文件，为空时使用内置分类体系
+	MinConfidence       float64 `json:"min_confidence"`        // 判定为农产品的最低置信度
+	DropNonAgricultural bool    `json:"drop_non_agricultural"` // 是否丢弃非农产品数据
+}
+
+// keyword 展开后的关键词
+type keyword struct {
+	text     string
+	category string
+	crop     string
+	weight   float64
+}
+
+// Classifier 基于词典规则的农产品分类器
+type Classifier struct {
+	enabled       bool
+	minConfidence float64
+	drop          bool
+	categories    []string // 保持分类体系中的顺序，得分相同时靠前的优先
+	keywords      []keyword
+	exclude       []string
+}
+
+// NewClassifier 创建农产品分类器
+func NewClassifier(config Config) (*Classifier, error) {
+	if !config.Enabled {
+		return &Classifier{enabled: false}, nil
+	}
+
+	// 加载分类体系
+	data := defaultTaxonomy
+	if config.TaxonomyFile != "" {
+		var err error
+		data, err = os.ReadFile(config.TaxonomyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取分类体系文件失败: %v", err)
+		}
+	}
+
+	var taxonomy Taxonomy
+	if err := json.Unmarshal(data, &taxonomy); err != nil {
+		return nil, fmt.Errorf("解析分类体系失败: %v", err)
+	}
+
+	classifier := &Classifier{
+		enabled:       true,
+		minConfidence: config.MinConfidence,
+		drop:          config.DropNonAgricultural,
+	}
+
+	// 展开关键词
+	for _, category := range taxonomy.Categories {
+		classifier.categories = append(classifier.categories, category.Name)
+		for _, text := range category.Keywords {
+			classifier.keywords = append(classifier.keywords, keyword{
+				text:     strings.ToLower(text),
+				category: category.Name,
+				weight:   categoryWeight,
+			})
+		}
+		for _, crop := range category.Crops {
+			for _, text := range crop.Keywords {
+				classifier.keywords = append(classifier.keywords, keyword{
+					text:     strings.ToLower(text),
+					category: category.Name,
+					crop:     crop.Name,
+					weight:   cropWeight,
+				})
+			}
+		}
+	}
+	for _, text := range taxonomy.Exclude {
+		classifier.exclude = append(classifier.exclude, strings.ToLower(text))
+	}
+
+	// 长词优先匹配，避免“凤梨”同时命中“梨”
+	sort.SliceStable(classifier.keywords, func(i, j int) bool {
+		return utf8.RuneCountInString(classifier.keywords[i].text) > utf8.RuneCountInString(classifier.keywords[j].text)
+	})
+
+	logger.Info("农产品分类器已加载，共 %d 个大类 %d 个关键词", len(classifier.categories), len(classifier.keywords))
+	return classifier, nil
+}
+
+// IsEnabled 检查分类器是否启用
+func (c *Classifier) IsEnabled() bool {
+	return c.enabled
+}
+
+// Classify 对若干文本进行农产品分类
+func (c *Classifier) Classify(texts ...string) *crawler.AgriClass {
+	if !c.enabled {
+		return nil
+	}
+
+	text := strings.ToLower(strings.Join(texts, " "))
+
+	// 命中排除词直接判定为非农产品
+	for _, word := range c.exclude {
+		if strings.Contains(text, word) {
+			return &crawler.AgriClass{Keywords: []string{word}}
+		}
+	}
+
+	categoryScores := make(map[string]float64)
+	cropScores := make(map[string]float64)
+	var matched []string
+	var total float64
+
+	for _, kw := range c.keywords {
+		count := strings.Count(text, kw.text)
+		if count == 0 {
+			continue
+		}
+
+		// 同一关键词重复出现最多计3次，已匹配的部分不再参与短词匹配
+		score := kw.weight * float64(min(count, 3))
+		categoryScores[kw.category] += score
+		if kw.crop != "" {
+			cropScores[kw.crop] += score
+		}
+		total += score
+		matched = append(matched, kw.text)
+		text = strings.ReplaceAll(text, kw.text, " ")
+	}
+
+	result := &crawler.AgriClass{Keywords: matched}
+	if total == 0 {
+		return result
+	}
+
+	// 选出得分最高的大类
+	var best float64
+	for _, category := range c.categories {
+		if categoryScores[category] > best {
+			best = categoryScores[category]
+			result.Category = category
+		}
+	}
+
+	// 在大类中选出得分最高的具体品类
+	var bestCrop float64
+	for _, kw := range c.keywords {
+		if kw.category == result.Category && kw.crop != "" && cropScores[kw.crop] > bestCrop {
+			bestCrop = cropScores[kw.crop]
+			result.Crop = kw.crop
+		}
+	}
+
+	// 置信度综合考虑命中强度和大类的集中程度
+	confidence := best / total * math.Min(1, best/(2*cropWeight))
+	result.Confidence = math.Round(confidence*100) / 100
+	result.IsAgricultural = result.Confidence >= c.minConfidence
+
+	return result
+}
+
+// ClassifyVideo 对视频进行分类并将结果附加到视频上
+func (c *Classifier) ClassifyVideo(video *crawler.VideoData) *crawler.AgriClass {
+	if !c.enabled {
+		return nil
+	}
+
+	texts := []string{video.Title, video.Description}
+	texts = append(texts, video.Tags...)
+	if video.ProductInfo != nil {
+		texts = append(texts, video.ProductInfo.Name, video.ProductInfo.Category)
+	}
+
+	video.Agri = c.Classify(texts...)
+	return video.Agri
+}
+
+// ClassifyProduct 对商品进行分类并将结果附加到商品上
+func (c *Classifier) ClassifyProduct(product *crawler.ProductInfo) *crawler.AgriClass {
+	if !c.enabled {
+		return nil
+	}
+
+	texts := []string{product.Name, product.Category, product.Description}
+	for _, sku := range product.SKUs {
+		texts = append(texts, sku.Name)
+	}
+
+	product.Agri = c.Classify(texts...)
+	return product.Agri
+}
+
+// Keep 判断分类后的数据是否需要保留
+func (c *Classifier) Keep(class *crawler.AgriClass) bool {
+	if !c.enabled || !c.drop || class == nil {
+		return true
+	}
+	return class.IsAgricultural
+}
@@ -0,0 +1,539 @@
+package cookiestore
+
+import (
+	"Crawler/utils/logger"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrNoSession 存储中没有有效的会话
+var ErrNoSession = errors.New("没有有效的登录会话")
+
+// Config Cookie存储配置
+type Config struct {
+	File          string `json:"file"`           // 存储文件路径
+	Key           string `json:"key"`            // 加密口令，为空时读取环境变量 CRAWLER_COOKIE_KEY
+	RefreshBefore int    `json:"refresh_before"` // 会话过期前多少小时开始刷新，默认 24
+	CheckInterval int    `json:"check_interval"` // 检查会话有效期的间隔（分钟），默认 30
+}
+
+// Cookie 持久化的Cookie
+type Cookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Expires  time.Time `json:"expires,omitempty"` // 零值表示会话Cookie或有效期未知
+	HttpOnly bool      `json:"http_only,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+}
+
+// Session 平台账号的登录会话
+type Session struct {
+	Platform  string    `json:"platform"`
+	Account   string    `json:"account"`
+	Cookies   []*Cookie `json:"cookies"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // 登录状态Cookie的最早过期时间，零值表示未知
+}
+
+// Header 返回请求头中的Cookie字符串，跳过已过期的Cookie
+func (s *Session) Header() string {
+	now := time.Now()
+	var parts []string
+	for _, cookie := range s.Cookies {
+		if !cookie.Expires.IsZero() && cookie.Expires.Before(now) {
+			continue
+		}
+		parts = append(parts, cookie.Name+"="+cookie.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// HTTPCookies 转换为 http.Cookie 列表
+func (s *Session) HTTPCookies() []*http.Cookie {
+	cookies := make([]*http.Cookie, 0, len(s.Cookies))
+	for _, cookie := range s.Cookies {
+		cookies = append(cookies, &http.Cookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Expires:  cookie.Expires,
+			HttpOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		})
+	}
+	return cookies
+}
+
+// Expired 判断会话是否已过期，有效期未知的会话视为有效
+func (s *Session) Expired() bool {
+	return !s.ExpiresAt.IsZero() && s.ExpiresAt.Before(time.Now())
+}
+
+// NewSession 根据Cookie创建会话，loginCookies 为表示登录状态的Cookie名称
+func NewSession(platform, account string, cookies []*http.Cookie, loginCookies []string) *Session {
+	session := &Session{
+		Platform:  platform,
+		Account:   account,
+		UpdatedAt: time.Now(),
+	}
+	for _, cookie := range cookies {
+		session.Cookies = append(session.Cookies, &Cookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Expires:  cookie.Expires,
+			HttpOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		})
+
+		// 会话有效期取登录状态Cookie中最早的过期时间
+		for _, name := range loginCookies {
+			if cookie.Name == name && !cookie.Expires.IsZero() && (session.ExpiresAt.IsZero() || cookie.Expires.Before(session.ExpiresAt)) {
+				session.ExpiresAt = cookie.Expires
+			}
+		}
+	}
+	return session
+}
+
+// ParseCookies 解析请求头格式的Cookie字符串，解析结果没有有效期
+func ParseCookies(header string) []*http.Cookie {
+	var cookies []*http.Cookie
+	for _, part := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && name != "" {
+			cookies = append(cookies, &http.Cookie{Name: name, Value: value})
+		}
+	}
+	return cookies
+}
+
+// Refresher 刷新会话，返回新的Cookie
+type Refresher func(session *Session) ([]*http.Cookie, error)
+
+// Store 加密的Cookie存储，按平台和账号保存登录会话
+type Store struct {
+	file          string
+	key           []byte
+	salt          []byte
+	refreshBefore time.Duration
+	checkInterval time.Duration
+	sessions      map[string]*Session
+	refresher     Refresher
+	loginCookies  func(platform string) []string
+	refreshing    map[string]*sync.Mutex
+	mutex         sync.RWMutex
+	done          chan struct{}
+}
+
+// NewStore 创建Cookie存储并加载已有的存储文件
+func NewStore(config Config) (*Store, error) {
+	if config.File == "" {
+		return nil, errors.New("未指定Cookie存储文件")
+	}
+	passphrase := config.Key
+	if passphrase == "" {
+		passphrase = os.Getenv("CRAWLER_COOKIE_KEY")
+	}
+	if passphrase == "" {
+		return nil, errors.New("未设置Cookie存储的加密口令，请设置环境变量 CRAWLER_COOKIE_KEY")
+	}
+	if config.RefreshBefore <= 0 {
+		config.RefreshBefore = 24
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 30
+	}
+
+	store := &Store{
+		file:          config.File,
+		refreshBefore: time.Duration(config.RefreshBefore) * time.Hour,
+		checkInterval: time.Duration(config.CheckInterval) * time.Minute,
+		sessions:      make(map[string]*Session),
+		refreshing:    make(map[string]*sync.Mutex),
+		done:          make(chan struct{}),
+	}
+	if err := store.load(passphrase); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// scrypt 参数，派生一次密钥约需 100 毫秒
+const (
+	scryptN       = 1 << 15
+	scryptR       = 8
+	scryptP       = 1
+	scryptKeySize = 32
+	saltSize      = 16
+)
+
+// deriveKey 使用 scrypt 从加密口令和盐派生 AES-256 密钥
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("派生Cookie存储密钥失败: %v", err)
+	}
+	return key, nil
+}
+
+// newSalt 生成随机盐
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("生成Cookie存储密钥的盐失败: %v", err)
+	}
+	return salt, nil
+}
+
+// sessionKey 返回会话在存储中的键
+func sessionKey(platform, account string) string {
+	return platform + "/" + account
+}
+
+// storeFile 存储文件格式，sessions 加密后保存在 data 中，salt 为派生密钥使用的盐
+type storeFile struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// load 使用加密口令派生密钥，加载并解密存储文件。
+// 文件不存在时为空存储并生成新的盐
+func (s *Store) load(passphrase string) error {
+	data, err := os.ReadFile(s.file)
+	if os.IsNotExist(err) {
+		return s.setPassphrase(passphrase, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("读取Cookie存储失败: %v", err)
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("解析Cookie存储失败: %v", err)
+	}
+	if len(file.Salt) == 0 {
+		return errors.New("Cookie存储缺少派生密钥的盐")
+	}
+	if err := s.setPassphrase(passphrase, file.Salt); err != nil {
+		return err
+	}
+	gcm, err := s.cipher()
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return errors.New("解密Cookie存储失败，请检查加密口令")
+	}
+
+	var sessions []*Session
+	if err := json.Unmarshal(plain, &sessions); err != nil {
+		return fmt.Errorf("解析Cookie存储失败: %v", err)
+	}
+	for _, session := range sessions {
+		s.sessions[sessionKey(session.Platform, session.Account)] = session
+	}
+	return nil
+}
+
+// setPassphrase 使用加密口令和盐派生密钥，salt 为空时生成新的盐
+func (s *Store) setPassphrase(passphrase string, salt []byte) error {
+	if len(salt) == 0 {
+		var err error
+		if salt, err = newSalt(); err != nil {
+			return err
+		}
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	s.key = key
+	s.salt = salt
+	return nil
+}
+
+// save 加密并写入存储文件，调用方需持有锁
+func (s *Store) save() error {
+	sessions := make([]*Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessionKey(sessions[i].Platform, sessions[i].Account) < sessionKey(sessions[j].Platform, sessions[j].Account)
+	})
+	plain, err := json.Marshal(sessions)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := s.cipher()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data, err := json.Marshal(storeFile{Salt: s.salt, Nonce: nonce, Data: gcm.Seal(nil, nonce, plain, nil)})
+	if err != nil {
+		return err
+	}
+
+	// 先写临时文件再重命名，避免写入中断损坏存储
+	if dir := filepath.Dir(s.file); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("创建Cookie存储目录失败: %v", err)
+		}
+	}
+	tmp := s.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入Cookie存储失败: %v", err)
+	}
+	if err := os.Rename(tmp, s.file); err != nil {
+		return fmt.Errorf("写入Cookie存储失败: %v", err)
+	}
+	return nil
+}
+
+// cipher 创建 AES-GCM 加密器
+func (s *Store) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Get 返回平台账号未过期的会话
+func (s *Store) Get(platform, account string) (*Session, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	session, ok := s.sessions[sessionKey(platform, account)]
+	if !ok || session.Expired() {
+		return nil, ErrNoSession
+	}
+	return session, nil
+}
+
+// Put 保存会话并写入存储文件
+func (s *Store) Put(session *Session) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sessions[sessionKey(session.Platform, session.Account)] = session
+	return s.save()
+}
+
+// Merge 将Cookie合并到平台账号的会话中并保存，同名Cookie使用新值，没有会话时返回 ErrNoSession
+func (s *Store) Merge(platform, account string, cookies []*http.Cookie) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := sessionKey(platform, account)
+	current, ok := s.sessions[key]
+	if !ok {
+		return ErrNoSession
+	}
+
+	// 会话可能正被读取，在副本上合并
+	merged := *current
+	merged.Cookies = append([]*Cookie(nil), current.Cookies...)
+	for _, cookie := range cookies {
+		entry := &Cookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Expires:  cookie.Expires,
+			HttpOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		replaced := false
+		for i, existing := range merged.Cookies {
+			if existing.Name == cookie.Name {
+				merged.Cookies[i] = entry
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged.Cookies = append(merged.Cookies, entry)
+		}
+	}
+	merged.UpdatedAt = time.Now()
+
+	s.sessions[key] = &merged
+	return s.save()
+}
+
+// Delete 删除会话
+func (s *Store) Delete(platform, account string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.sessions, sessionKey(platform, account))
+	return s.save()
+}
+
+// Accounts 返回平台已保存会话的账号，按名称排序
+func (s *Store) Accounts(platform string) []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var accounts []string
+	for _, session := range s.sessions {
+		if session.Platform == platform {
+			accounts = append(accounts, session.Account)
+		}
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// Header 返回平台账号会话的Cookie字符串，没有有效会话时返回空字符串
+func (s *Store) Header(platform, account string) string {
+	session, err := s.Get(platform, account)
+	if err != nil {
+		return ""
+	}
+	return session.Header()
+}
+
+// SetRefresher 设置会话刷新函数，loginCookies 返回平台表示登录状态的Cookie名称
+func (s *Store) SetRefresher(refresher Refresher, loginCookies func(platform string) []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.refresher = refresher
+	s.loginCookies = loginCookies
+}
+
+// Refresh 刷新平台账号的会话并保存。
+// 同一会话的并发刷新只执行一次，刷新完成前到达的调用直接返回刷新结果
+func (s *Store) Refresh(platform, account string) (*Session, error) {
+	key := sessionKey(platform, account)
+
+	s.mutex.Lock()
+	refresher, loginCookies := s.refresher, s.loginCookies
+	lock, ok := s.refreshing[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.refreshing[key] = lock
+	}
+	previous := s.sessions[key]
+	s.mutex.Unlock()
+
+	if refresher == nil {
+		return nil, errors.New("未设置会话刷新函数")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// 等待期间其他调用已完成刷新
+	s.mutex.RLock()
+	current := s.sessions[key]
+	s.mutex.RUnlock()
+	if current != nil && current != previous {
+		return current, nil
+	}
+
+	if current == nil {
+		current = &Session{Platform: platform, Account: account}
+	}
+	cookies, err := refresher(current)
+	if err != nil {
+		return nil, fmt.Errorf("刷新 %s 账号 %s 的会话失败: %v", platform, account, err)
+	}
+
+	var names []string
+	if loginCookies != nil {
+		names = loginCookies(platform)
+	}
+	session := NewSession(platform, account, cookies, names)
+	if err := s.Put(session); err != nil {
+		return nil, err
+	}
+
+	logger.Info("已刷新 %s 账号 %s 的会话，有效期至 %s", platform, account, formatExpiry(session.ExpiresAt))
+	return session, nil
+}
+
+// formatExpiry 格式化会话有效期
+func formatExpiry(expiresAt time.Time) string {
+	if expiresAt.IsZero() {
+		return "未知"
+	}
+	return expiresAt.Format("2006-01-02 15:04")
+}
+
+// StartAutoRefresh 启动后台协程，定期刷新即将过期的会话
+func (s *Store) StartAutoRefresh() {
+	go func() {
+		ticker := time.NewTicker(s.checkInterval)
+		defer ticker.Stop()
+
+		for {
+			s.refreshExpiring()
+
+			select {
+			case <-ticker.C:
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// refreshExpiring 刷新即将过期的会话
+func (s *Store) refreshExpiring() {
+	deadline := time.Now().Add(s.refreshBefore)
+
+	s.mutex.RLock()
+	var expiring []*Session
+	for _, session := range s.sessions {
+		if !session.ExpiresAt.IsZero() && session.ExpiresAt.Before(deadline) {
+			expiring = append(expiring, session)
+		}
+	}
+	s.mutex.RUnlock()
+
+	for _, session := range expiring {
+		logger.Info("%s 账号 %s 的会话将于 %s 过期，正在刷新", session.Platform, session.Account, formatExpiry(session.ExpiresAt))
+		if _, err := s.Refresh(session.Platform, session.Account); err != nil {
+			logger.Warn("%v", err)
+		}
+	}
+}
+
+// Close 停止自动刷新
+func (s *Store) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
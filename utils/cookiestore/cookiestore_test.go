@@ -0,0 +1,98 @@
+package cookiestore
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readStoreFile(t *testing.T, path string) storeFile {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestStoreEncryption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.enc")
+	store, err := NewStore(Config{File: path, Key: "passphrase"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := NewSession("douyin", "default", ParseCookies("sessionid=abc; ttwid=def"), []string{"sessionid"})
+	if err := store.Put(session); err != nil {
+		t.Fatal(err)
+	}
+
+	// 文件中保存盐，内容不含明文Cookie
+	file := readStoreFile(t, path)
+	if len(file.Salt) != saltSize {
+		t.Errorf("盐长度 %d，期望 %d", len(file.Salt), saltSize)
+	}
+	if raw, _ := os.ReadFile(path); bytes.Contains(raw, []byte("sessionid")) {
+		t.Error("存储文件包含明文Cookie")
+	}
+
+	// 使用相同口令重新打开可以解密，盐保持不变
+	reopened, err := NewStore(Config{File: path, Key: "passphrase"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reopened.Get("douyin", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Header() != "sessionid=abc; ttwid=def" {
+		t.Errorf("解密后的Cookie %q", got.Header())
+	}
+	if err := reopened.Put(got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(readStoreFile(t, path).Salt, file.Salt) {
+		t.Error("重新保存后盐发生变化")
+	}
+
+	// 错误的口令无法解密
+	if _, err := NewStore(Config{File: path, Key: "wrong"}); err == nil {
+		t.Error("使用错误口令打开存储应返回错误")
+	}
+}
+
+func TestStoreSaltPerFile(t *testing.T) {
+	dir := t.TempDir()
+	var salts [][]byte
+	for _, name := range []string{"a.enc", "b.enc"} {
+		path := filepath.Join(dir, name)
+		store, err := NewStore(Config{File: path, Key: "passphrase"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Put(NewSession("douyin", "default", ParseCookies("sessionid=abc"), nil)); err != nil {
+			t.Fatal(err)
+		}
+		salts = append(salts, readStoreFile(t, path).Salt)
+	}
+	if bytes.Equal(salts[0], salts[1]) {
+		t.Error("不同存储文件使用了相同的盐")
+	}
+}
+
+func TestStoreMissingSalt(t *testing.T) {
+	// 没有盐的存储文件无法派生密钥
+	path := filepath.Join(t.TempDir(), "cookies.enc")
+	data, _ := json.Marshal(storeFile{Nonce: make([]byte, 12), Data: []byte("data")})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(Config{File: path, Key: "passphrase"}); err == nil {
+		t.Error("打开缺少盐的存储应返回错误")
+	}
+}
@@ -0,0 +1,276 @@
+package origin
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/logger"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed gazetteer.json
+var defaultGazetteer []byte
+
+//go:embed gi.json
+var defaultGIList []byte
+
+// 行政区划级别
+const (
+	levelProvince = iota + 1
+	levelCity
+	levelCounty
+)
+
+// Config 产地提取配置
+type Config struct {
+	Enabled bool   `json:"enabled"`
+	GIFile  string `json:"gi_file"` // 自定义地理标志产品列表文件，为空时使用内置列表
+}
+
+// division 行政区划
+type division struct {
+	Name     string     `json:"name"`
+	Short    string     `json:"short"`
+	Cities   []division `json:"cities,omitempty"`
+	Counties []division `json:"counties,omitempty"`
+}
+
+// GIProduct 地理标志产品
+type GIProduct struct {
+	Name     string   `json:"name"`
+	Product  string   `json:"product"`
+	Province string   `json:"province"`
+	City     string   `json:"city,omitempty"`
+	County   string   `json:"county,omitempty"`
+	Aliases  []string `json:"aliases,omitempty"`
+}
+
+// place 可匹配的地名，省市县均为全称
+type place struct {
+	text     string
+	level    int
+	province string
+	city     string
+	county   string
+}
+
+// giEntry 可匹配的地理标志产品名称
+type giEntry struct {
+	text    string
+	product *GIProduct
+}
+
+// Extractor 基于行政区划词典和地理标志产品列表的产地提取器
+type Extractor struct {
+	enabled bool
+	places  []place
+	gis     []giEntry
+
+	provinces map[string]string // 简称或全称 -> 省份全称
+	cities    map[string]string // 省份全称/简称或全称 -> 城市全称
+	counties  map[string]string // 城市全称/简称或全称 -> 县区全称
+}
+
+// NewExtractor 创建产地提取器
+func NewExtractor(config Config) (*Extractor, error) {
+	if !config.Enabled {
+		return &Extractor{enabled: false}, nil
+	}
+
+	extractor := &Extractor{
+		enabled:   true,
+		provinces: make(map[string]string),
+		cities:    make(map[string]string),
+		counties:  make(map[string]string),
+	}
+
+	// 加载行政区划
+	var gazetteer struct {
+		Provinces []division `json:"provinces"`
+	}
+	if err := json.Unmarshal(defaultGazetteer, &gazetteer); err != nil {
+		return nil, fmt.Errorf("解析行政区划词典失败: %v", err)
+	}
+	for _, province := range gazetteer.Provinces {
+		extractor.addPlace(province, levelProvince, place{province: province.Name})
+		for _, city := range province.Cities {
+			extractor.addPlace(city, levelCity, place{province: province.Name, city: city.Name})
+			for _, county := range city.Counties {
+				extractor.addPlace(county, levelCounty, place{province: province.Name, city: city.Name, county: county.Name})
+			}
+		}
+	}
+
+	// 加载地理标志产品列表
+	data := defaultGIList
+	if config.GIFile != "" {
+		var err error
+		data, err = os.ReadFile(config.GIFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取地理标志产品文件失败: %v", err)
+		}
+	}
+	var products []*GIProduct
+	if err := json.Unmarshal(data, &products); err != nil {
+		return nil, fmt.Errorf("解析地理标志产品列表失败: %v", err)
+	}
+	for _, product := range products {
+		extractor.resolveGI(product)
+		extractor.gis = append(extractor.gis, giEntry{text: product.Name, product: product})
+		for _, alias := range product.Aliases {
+			extractor.gis = append(extractor.gis, giEntry{text: alias, product: product})
+		}
+	}
+
+	// 长词优先匹配，相同文本相邻以便一起匹配
+	sort.SliceStable(extractor.places, func(i, j int) bool {
+		li, lj := utf8.RuneCountInString(extractor.places[i].text), utf8.RuneCountInString(extractor.places[j].text)
+		if li != lj {
+			return li > lj
+		}
+		return extractor.places[i].text < extractor.places[j].text
+	})
+	sort.SliceStable(extractor.gis, func(i, j int) bool {
+		return utf8.RuneCountInString(extractor.gis[i].text) > utf8.RuneCountInString(extractor.gis[j].text)
+	})
+
+	logger.Info("产地提取器已加载，共 %d 个地名 %d 个地理标志产品", len(extractor.places), len(products))
+	return extractor, nil
+}
+
+// addPlace 添加行政区划的全称和简称
+func (e *Extractor) addPlace(d division, level int, p place) {
+	p.level = level
+	names := []string{d.Name}
+	if d.Short != "" && d.Short != d.Name && utf8.RuneCountInString(d.Short) >= 2 {
+		names = append(names, d.Short)
+	}
+
+	for _, name := range names {
+		p.text = name
+		e.places = append(e.places, p)
+
+		switch level {
+		case levelProvince:
+			e.provinces[name] = d.Name
+		case levelCity:
+			e.cities[p.province+"/"+name] = d.Name
+		case levelCounty:
+			e.counties[p.city+"/"+name] = d.Name
+		}
+	}
+}
+
+// resolveGI 将地理标志产品中的省市县简称解析为全称
+func (e *Extractor) resolveGI(product *GIProduct) {
+	if name, ok := e.provinces[product.Province]; ok {
+		product.Province = name
+	}
+	if name, ok := e.cities[product.Province+"/"+product.City]; ok {
+		product.City = name
+	}
+	if name, ok := e.counties[product.City+"/"+product.County]; ok {
+		product.County = name
+	}
+}
+
+// IsEnabled 检查产地提取是否启用
+func (e *Extractor) IsEnabled() bool {
+	return e.enabled
+}
+
+// Extract 从若干文本中提取产地，未识别出产地时返回 nil
+func (e *Extractor) Extract(texts ...string) *crawler.OriginInfo {
+	if !e.enabled {
+		return nil
+	}
+
+	text := strings.Join(texts, " ")
+
+	// 地理标志产品直接确定产地
+	for _, gi := range e.gis {
+		if strings.Contains(text, gi.text) {
+			return &crawler.OriginInfo{
+				Province:  gi.product.Province,
+				City:      gi.product.City,
+				County:    gi.product.County,
+				GIProduct: gi.product.Name,
+				Matched:   gi.text,
+			}
+		}
+	}
+
+	// 匹配行政区划，已匹配的长地名不再参与短地名匹配
+	type hit struct {
+		place place
+		pos   int
+	}
+	var hits []hit
+	provinces := make(map[string]bool)
+	working := text
+	for i, p := range e.places {
+		if pos := strings.Index(working, p.text); pos >= 0 {
+			hits = append(hits, hit{place: p, pos: pos})
+			if p.level == levelProvince {
+				provinces[p.province] = true
+			}
+		}
+
+		// 同一文本的地名全部匹配完后再屏蔽
+		if i == len(e.places)-1 || e.places[i+1].text != p.text {
+			working = strings.ReplaceAll(working, p.text, strings.Repeat(" ", len(p.text)))
+		}
+	}
+
+	// 选择与文本中省份一致、级别最细、出现最早的地名
+	var best *hit
+	for i := range hits {
+		h := &hits[i]
+		if len(provinces) > 0 && !provinces[h.place.province] {
+			continue
+		}
+		if best == nil || h.place.level > best.place.level ||
+			(h.place.level == best.place.level && h.pos < best.pos) {
+			best = h
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	return &crawler.OriginInfo{
+		Province: best.place.province,
+		City:     best.place.city,
+		County:   best.place.county,
+		Matched:  best.place.text,
+	}
+}
+
+// ExtractVideo 提取视频的产地并附加到视频上
+func (e *Extractor) ExtractVideo(video *crawler.VideoData) *crawler.OriginInfo {
+	if !e.enabled {
+		return nil
+	}
+
+	video.Origin = e.Extract(video.Title, video.Description)
+	return video.Origin
+}
+
+// ExtractProduct 提取商品的产地并附加到商品上
+func (e *Extractor) ExtractProduct(product *crawler.ProductInfo) *crawler.OriginInfo {
+	if !e.enabled {
+		return nil
+	}
+
+	texts := []string{product.Name, product.Description, product.ShipFrom}
+	for _, sku := range product.SKUs {
+		texts = append(texts, sku.Name, sku.Origin)
+	}
+
+	product.Origin = e.Extract(texts...)
+	return product.Origin
+}
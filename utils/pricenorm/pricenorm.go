@@ -0,0 +1,247 @@
+package pricenorm
+
+import (
+	"Crawler/crawler"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 重量单位换算为公斤
+var unitToKg = map[string]float64{
+	"斤":  0.5,
+	"公斤": 1,
+	"千克": 1,
+	"kg": 1,
+	"克":  0.001,
+	"g":  0.001,
+	"两":  0.05,
+	"磅":  0.4536,
+	"lb": 0.4536,
+}
+
+var (
+	// 重量，如 5斤、2.5kg、4.5-5斤、两斤、半斤，可带“净重/毛重/带箱”前缀。
+	// 英文单位后不能紧跟字母，避免把 5 gift 这样的单词识别为重量
+	weightPattern = regexp.MustCompile(`(净重|净含量|毛重|带箱)?\s*([0-9]+(?:\.[0-9]+)?|[一二两三四五六七八九十半]+)(?:\s*[-~～至到]\s*([0-9]+(?:\.[0-9]+)?))?\s*(公斤|千克|kg\b|斤|克|g\b|两|磅|lb\b)`)
+
+	// 重量后的倍数，如 500g*3袋、5斤×2箱
+	multiplierPattern = regexp.MustCompile(`^\s*(?:/\s*[箱盒袋包件份])?\s*[*xX×]\s*([0-9]+)`)
+
+	// 计件，如 30枚、12个、2箱
+	countPattern = regexp.MustCompile(`([0-9]+|[一二两三四五六七八九十]+)\s*(枚|个|只|头|条|颗|箱|件|盒|袋|包|份|罐|瓶)`)
+)
+
+// 以“两”标注单只规格的计件单位，如大闸蟹“公4两 8只”
+var perPieceUnits = map[string]bool{
+	"只": true,
+	"头": true,
+	"条": true,
+	"个": true,
+}
+
+// Config 价格归一化配置
+type Config struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Normalizer 价格归一化器，从商品名称和规格文本中解析重量和件数，换算为单价
+type Normalizer struct {
+	enabled bool
+}
+
+// NewNormalizer 创建价格归一化器
+func NewNormalizer(config Config) *Normalizer {
+	return &Normalizer{
+		enabled: config.Enabled,
+	}
+}
+
+// IsEnabled 检查价格归一化是否启用
+func (n *Normalizer) IsEnabled() bool {
+	return n.enabled
+}
+
+// Parse 根据文本和售价计算归一化单价，无法解析时返回 nil
+func (n *Normalizer) Parse(text string, price float64) *crawler.UnitPrice {
+	if !n.enabled || price <= 0 {
+		return nil
+	}
+
+	text = strings.ToLower(text)
+
+	// 优先按重量计价
+	if unitPrice := parseWeight(text, price); unitPrice != nil {
+		return unitPrice
+	}
+
+	// 其次按件计价
+	match := countPattern.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+	count := parseNumber(match[1])
+	if count <= 0 {
+		return nil
+	}
+
+	return &crawler.UnitPrice{
+		Count:      int(count),
+		Unit:       match[2],
+		PerUnit:    round(price / count),
+		Confidence: 0.4,
+		Source:     match[0],
+	}
+}
+
+// parseWeight 解析文本中的重量并换算单价
+func parseWeight(text string, price float64) *crawler.UnitPrice {
+	matches := weightPattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return nil
+	}
+
+	// 有“净重”标注时以净重为准，否则取第一个重量
+	chosen := matches[0]
+	for _, m := range matches {
+		if m[2] >= 0 && strings.HasPrefix(text[m[2]:m[3]], "净") {
+			chosen = m
+			break
+		}
+	}
+
+	group := func(i int) string {
+		if chosen[2*i] < 0 {
+			return ""
+		}
+		return text[chosen[2*i]:chosen[2*i+1]]
+	}
+
+	weight := parseNumber(group(2))
+	if weight <= 0 {
+		return nil
+	}
+	confidence := 0.9
+
+	// 重量区间取中值
+	if upper := group(3); upper != "" {
+		if value := parseNumber(upper); value > weight {
+			weight = (weight + value) / 2
+			confidence -= 0.1
+		}
+	}
+
+	// 毛重和带箱重量包含包装
+	switch group(1) {
+	case "净重", "净含量":
+		confidence += 0.05
+	case "毛重", "带箱":
+		confidence -= 0.3
+	}
+
+	weightKg := weight * unitToKg[group(4)]
+	source := text[chosen[0]:chosen[1]]
+
+	// 重量后跟倍数时乘以件数
+	rest := text[chosen[1]:]
+	if m := multiplierPattern.FindStringSubmatchIndex(rest); m != nil {
+		if multiplier, err := strconv.Atoi(rest[m[2]:m[3]]); err == nil && multiplier > 0 {
+			weightKg *= float64(multiplier)
+			source += rest[m[0]:m[1]]
+		}
+	} else if group(4) == "两" {
+		// 按“两”标注的是单只重量，乘以只数
+		if m := countPattern.FindStringSubmatch(rest); m != nil && perPieceUnits[m[2]] {
+			if count := parseNumber(m[1]); count > 0 {
+				weightKg *= count
+				source += " " + m[0]
+				confidence -= 0.3
+			}
+		}
+	}
+
+	if weightKg <= 0 {
+		return nil
+	}
+
+	return &crawler.UnitPrice{
+		PerJin:     round(price / (weightKg * 2)),
+		PerKg:      round(price / weightKg),
+		WeightKg:   math.Round(weightKg*1000) / 1000,
+		Confidence: math.Round(confidence*100) / 100,
+		Source:     strings.TrimSpace(source),
+	}
+}
+
+// NormalizeProduct 计算商品及其各规格的归一化单价并附加到商品上
+func (n *Normalizer) NormalizeProduct(product *crawler.ProductInfo) *crawler.UnitPrice {
+	if !n.enabled {
+		return nil
+	}
+
+	// 各规格按规格文本计算，规格文本中没有重量时结合商品名称
+	var cheapest *crawler.UnitPrice
+	for _, sku := range product.SKUs {
+		sku.UnitPrice = n.Parse(sku.Name+" "+sku.Weight, sku.Price)
+		if sku.UnitPrice == nil {
+			sku.UnitPrice = n.Parse(product.Name, sku.Price)
+		}
+		if sku.UnitPrice != nil && sku.UnitPrice.PerKg > 0 &&
+			(cheapest == nil || sku.UnitPrice.PerKg < cheapest.PerKg) {
+			cheapest = sku.UnitPrice
+		}
+	}
+
+	// 商品按名称和售价计算，名称中没有重量时取单价最低的规格
+	product.UnitPrice = n.Parse(product.Name, product.Price)
+	if (product.UnitPrice == nil || product.UnitPrice.PerKg == 0) && cheapest != nil {
+		product.UnitPrice = cheapest
+	}
+
+	return product.UnitPrice
+}
+
+// parseNumber 解析阿拉伯数字或中文数字
+func parseNumber(text string) float64 {
+	if value, err := strconv.ParseFloat(text, 64); err == nil {
+		return value
+	}
+
+	digits := map[rune]float64{
+		'一': 1, '二': 2, '两': 2, '三': 3, '四': 4,
+		'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+	}
+
+	if text == "半" {
+		return 0.5
+	}
+
+	// 支持“十”“十二”“二十”“二十五”等写法
+	var value, current float64
+	for _, r := range text {
+		switch {
+		case r == '十':
+			if current == 0 {
+				current = 1
+			}
+			value += current * 10
+			current = 0
+		case r == '半':
+			current += 0.5
+		default:
+			digit, ok := digits[r]
+			if !ok {
+				return 0
+			}
+			current = digit
+		}
+	}
+
+	return value + current
+}
+
+// round 保留两位小数
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
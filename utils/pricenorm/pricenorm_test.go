@@ -0,0 +1,79 @@
+package pricenorm
+
+import (
+	"Crawler/crawler"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	n := NewNormalizer(Config{Enabled: true})
+
+	tests := []struct {
+		name  string
+		text  string
+		price float64
+		want  *crawler.UnitPrice
+	}{
+		{"斤", "砀山酥梨 5斤装", 30, &crawler.UnitPrice{WeightKg: 2.5, PerJin: 6, PerKg: 12, Confidence: 0.9, Source: "5斤"}},
+		{"公斤", "赣南脐橙 2.5kg 礼盒", 50, &crawler.UnitPrice{WeightKg: 2.5, PerJin: 10, PerKg: 20, Confidence: 0.9, Source: "2.5kg"}},
+		{"克", "丹东草莓 500g", 30, &crawler.UnitPrice{WeightKg: 0.5, PerJin: 30, PerKg: 60, Confidence: 0.9, Source: "500g"}},
+		{"大写英文单位", "东北大米 10KG", 60, &crawler.UnitPrice{WeightKg: 10, PerJin: 3, PerKg: 6, Confidence: 0.9, Source: "10kg"}},
+		{"两乘只数", "阳澄湖大闸蟹 公4两 8只", 200, &crawler.UnitPrice{WeightKg: 1.6, PerJin: 62.5, PerKg: 125, Confidence: 0.6, Source: "4两 8只"}},
+		{"多件装", "每日坚果 500g*3袋", 90, &crawler.UnitPrice{WeightKg: 1.5, PerJin: 30, PerKg: 60, Confidence: 0.9, Source: "500g*3"}},
+		{"重量区间", "猕猴桃 4.5-5斤", 38, &crawler.UnitPrice{WeightKg: 2.375, PerJin: 8, PerKg: 16, Confidence: 0.8, Source: "4.5-5斤"}},
+		{"净重优先", "带箱6斤 净重5斤 苹果", 40, &crawler.UnitPrice{WeightKg: 2.5, PerJin: 8, PerKg: 16, Confidence: 0.95, Source: "净重5斤"}},
+		{"中文数字", "农家土蜂蜜 五斤", 150, &crawler.UnitPrice{WeightKg: 2.5, PerJin: 30, PerKg: 60, Confidence: 0.9, Source: "五斤"}},
+		{"中文十位数字", "新米 二十斤", 100, &crawler.UnitPrice{WeightKg: 10, PerJin: 5, PerKg: 10, Confidence: 0.9, Source: "二十斤"}},
+		{"半斤", "明前龙井 半斤", 200, &crawler.UnitPrice{WeightKg: 0.25, PerJin: 400, PerKg: 800, Confidence: 0.9, Source: "半斤"}},
+		{"枚", "散养土鸡蛋 30枚", 45, &crawler.UnitPrice{Count: 30, Unit: "枚", PerUnit: 1.5, Confidence: 0.4, Source: "30枚"}},
+		{"只", "农家散养老母鸡 2只", 160, &crawler.UnitPrice{Count: 2, Unit: "只", PerUnit: 80, Confidence: 0.4, Source: "2只"}},
+		{"英文单词不是单位", "新鲜 5 gift", 10, nil},
+		{"英文单词中的单位", "5 lbs of love 礼盒", 10, nil},
+		{"没有规格", "新鲜水果", 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := n.Parse(tt.text, tt.price)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("Parse(%q) = %+v，期望无法解析", tt.text, got)
+				}
+				return
+			}
+			if got == nil || *got != *tt.want {
+				t.Errorf("Parse(%q) = %+v，期望 %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDisabled(t *testing.T) {
+	if got := NewNormalizer(Config{}).Parse("砀山酥梨 5斤", 30); got != nil {
+		t.Errorf("未启用时 Parse = %+v，期望为空", got)
+	}
+	if got := NewNormalizer(Config{Enabled: true}).Parse("砀山酥梨 5斤", 0); got != nil {
+		t.Errorf("售价为 0 时 Parse = %+v，期望为空", got)
+	}
+}
+
+func TestNormalizeProduct(t *testing.T) {
+	n := NewNormalizer(Config{Enabled: true})
+
+	// 名称中没有重量时取单价最低的规格
+	product := &crawler.ProductInfo{
+		Name:  "赣南脐橙 当季现摘",
+		Price: 30,
+		SKUs: []*crawler.ProductSKU{
+			{Name: "3斤装", Price: 30},
+			{Name: "10斤装", Price: 60},
+		},
+	}
+	got := n.NormalizeProduct(product)
+	if got == nil || got.PerJin != 6 || got.Source != "10斤" {
+		t.Errorf("商品单价 %+v，期望取 10斤装的 6 元/斤", got)
+	}
+	if product.SKUs[0].UnitPrice == nil || product.SKUs[0].UnitPrice.PerJin != 10 {
+		t.Errorf("3斤装规格单价 %+v，期望 10 元/斤", product.SKUs[0].UnitPrice)
+	}
+}
@@ -0,0 +1,255 @@
+package resolve
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	phonePattern   = regexp.MustCompile(`1[3-9]\d{9}`)
+	wechatPattern  = regexp.MustCompile(`(?i)(?:微信|威信|薇信|v信|vx|wx|薇)\s*[:：号]?\s*([a-zA-Z][-_a-zA-Z0-9]{5,19})`)
+	weightPattern  = regexp.MustCompile(`[0-9.]+\s*(?:斤|公斤|千克|kg|g|克|两|个|只|头|条|箱|盒|袋|枚|颗)装?`)
+	bracketPattern = regexp.MustCompile(`【[^】]*】|\[[^\]]*\]|\([^)]*\)|（[^）]*）`)
+)
+
+// 商品名称中不区分商品的营销用语
+var marketingWords = []string{
+	"包邮", "顺丰", "现摘", "现挖", "现发", "现货", "新鲜", "正宗", "当季", "应季", "产地直发", "产地直供", "直发", "直供",
+	"源头", "农家", "自产", "自种", "特产", "精选", "优选", "特级", "一级", "大果", "中果", "小果", "礼盒装", "礼盒",
+	"整箱", "批发", "官方", "旗舰店", "限时", "秒杀", "特价", "爆款", "推荐", "一件代发", "坏果包赔", "包赔",
+}
+
+// 创作者昵称中不区分身份的常见后缀
+var nicknameWords = []string{"官方", "旗舰店", "直播间", "小店", "的店", "店铺"}
+
+// creatorEntity 提取创作者的昵称、联系方式、地区和简介特征
+func (r *Resolver) creatorEntity(creator *Creator) *entity {
+	user := creator.User
+	e := &entity{
+		key:      creator.Platform + ":" + user.UserID,
+		platform: creator.Platform,
+		id:       user.UserID,
+		name:     user.Nickname,
+		contacts: make(map[string]bool),
+	}
+
+	e.norm = normalizeName(user.Nickname, nicknameWords)
+	e.grams = bigrams(e.norm)
+	e.desc = bigrams(normalizeName(user.Description, nil))
+
+	text := user.Description + " " + strings.Join(user.Tags, " ")
+	for _, phone := range phonePattern.FindAllString(text, -1) {
+		e.contacts["phone:"+phone] = true
+	}
+	for _, match := range wechatPattern.FindAllStringSubmatch(text, -1) {
+		e.contacts["wechat:"+strings.ToLower(match[1])] = true
+	}
+
+	if info := r.originExtractor.Extract(user.Nickname, user.Description, strings.Join(user.Tags, " ")); info != nil {
+		e.province, e.city, e.county = info.Province, info.City, info.County
+	}
+	return e
+}
+
+// productEntity 提取商品的名称、单价和产地特征，缺少单价和产地时补充计算
+func (r *Resolver) productEntity(product *Product) *entity {
+	info := product.Product
+	e := &entity{
+		key:      product.Platform + ":" + info.ProductID,
+		platform: product.Platform,
+		id:       info.ProductID,
+		name:     info.Name,
+	}
+
+	e.norm = normalizeName(info.Name, marketingWords)
+	e.grams = bigrams(e.norm)
+
+	if info.UnitPrice == nil {
+		r.priceNormalizer.NormalizeProduct(info)
+	}
+	if info.UnitPrice != nil {
+		e.perKg = info.UnitPrice.PerKg
+	}
+
+	if info.Origin == nil {
+		r.originExtractor.ExtractProduct(info)
+	}
+	if info.Origin != nil {
+		e.province, e.city, e.county, e.gi = info.Origin.Province, info.Origin.City, info.Origin.County, info.Origin.GIProduct
+	}
+	return e
+}
+
+// creatorScore 计算两个创作者为同一人的置信度
+func creatorScore(a, b *entity) (float64, []string) {
+	// 相同的手机号或微信号几乎可以确定是同一人
+	for contact := range a.contacts {
+		if b.contacts[contact] {
+			return 0.95, []string{"contact"}
+		}
+	}
+
+	var reasons []string
+	nameSim := nameSimilarity(a, b)
+	if nameSim > 0 {
+		reasons = append(reasons, "nickname")
+	}
+	score := nameSim * 0.7
+
+	switch regionMatch(a, b) {
+	case matchCity:
+		score += 0.2
+		reasons = append(reasons, "region")
+	case matchProvince:
+		score += 0.1
+		reasons = append(reasons, "region")
+	case matchConflict:
+		score -= 0.3
+	}
+
+	if sim := jaccard(a.desc, b.desc); sim > 0 {
+		score += sim * 0.1
+		reasons = append(reasons, "description")
+	}
+
+	return clamp(score), reasons
+}
+
+// productScore 计算两个商品为同一商品的置信度
+func productScore(a, b *entity) (float64, []string) {
+	var reasons []string
+	nameSim := nameSimilarity(a, b)
+	if nameSim > 0 {
+		reasons = append(reasons, "name")
+	}
+	score := nameSim * 0.55
+
+	// 每公斤单价接近说明规格和定位相近
+	if a.perKg > 0 && b.perKg > 0 {
+		ratio := min(a.perKg, b.perKg) / max(a.perKg, b.perKg)
+		if ratio >= 0.75 {
+			score += 0.2 * ratio
+			reasons = append(reasons, "unit_price")
+		} else if ratio < 0.5 {
+			score -= 0.2
+		}
+	}
+
+	if a.gi != "" && a.gi == b.gi {
+		score += 0.25
+		reasons = append(reasons, "gi_product")
+	} else {
+		switch regionMatch(a, b) {
+		case matchCity:
+			score += 0.2
+			reasons = append(reasons, "origin")
+		case matchProvince:
+			score += 0.1
+			reasons = append(reasons, "origin")
+		case matchConflict:
+			score -= 0.3
+		}
+	}
+
+	return clamp(score), reasons
+}
+
+// 地区比较结果
+const (
+	matchUnknown = iota
+	matchProvince
+	matchCity
+	matchConflict
+)
+
+// regionMatch 比较两个实体的地区，任一方缺少地区时返回 matchUnknown
+func regionMatch(a, b *entity) int {
+	if a.province == "" || b.province == "" {
+		return matchUnknown
+	}
+	if a.province != b.province {
+		return matchConflict
+	}
+	if (a.county != "" && a.county == b.county) || (a.city != "" && a.city == b.city) {
+		return matchCity
+	}
+	return matchProvince
+}
+
+// nameSimilarity 名称相似度，一方名称包含另一方时视为高度相似
+func nameSimilarity(a, b *entity) float64 {
+	if a.norm == "" || b.norm == "" {
+		return 0
+	}
+	if a.norm == b.norm {
+		return 1
+	}
+
+	sim := jaccard(a.grams, b.grams)
+	short, long := a.norm, b.norm
+	if len([]rune(short)) > len([]rune(long)) {
+		short, long = long, short
+	}
+	if len([]rune(short)) >= 2 && strings.Contains(long, short) {
+		sim = max(sim, 0.85)
+	}
+	return sim
+}
+
+// NormalizeProductName 归一化商品名称，去除规格、营销用语和符号，用于跨平台比价
+func NormalizeProductName(name string) string {
+	return normalizeName(name, marketingWords)
+}
+
+// normalizeName 去除括号内容、规格重量、营销用语和符号，只保留文字
+func normalizeName(name string, words []string) string {
+	name = strings.ToLower(name)
+	name = bracketPattern.ReplaceAllString(name, "")
+	name = weightPattern.ReplaceAllString(name, "")
+	for _, word := range words {
+		name = strings.ReplaceAll(name, word, "")
+	}
+
+	var builder strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// bigrams 计算文本的二元组集合，单字文本以自身作为特征
+func bigrams(text string) map[string]bool {
+	runes := []rune(text)
+	grams := make(map[string]bool)
+	if len(runes) == 1 {
+		grams[text] = true
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])] = true
+	}
+	return grams
+}
+
+// jaccard 计算两个集合的 Jaccard 相似度
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	intersection := 0
+	for item := range a {
+		if b[item] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+// clamp 将置信度限制在 0 到 1 之间并保留两位小数
+func clamp(score float64) float64 {
+	score = max(0, min(score, 1))
+	return float64(int(score*100+0.5)) / 100
+}
@@ -0,0 +1,386 @@
+package resolve
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/logger"
+	"Crawler/utils/origin"
+	"Crawler/utils/pricenorm"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 实体类型
+const (
+	EntityCreator = "creator"
+	EntityProduct = "product"
+)
+
+// 倒排索引中出现过于频繁的特征不用于生成候选对
+const maxPostings = 200
+
+// Config 跨平台实体识别配置
+type Config struct {
+	MinConfidence float64 `json:"min_confidence"` // 两个实体判定为同一实体的最低置信度
+	OverrideFile  string  `json:"override_file"`  // 人工覆盖文件
+}
+
+// Overrides 人工覆盖规则，实体以 平台:ID 表示，如 douyin:123
+type Overrides struct {
+	Link   []OverrideRule `json:"link"`   // 强制关联为同一实体
+	Unlink []OverrideRule `json:"unlink"` // 禁止关联
+}
+
+// OverrideRule 覆盖规则
+type OverrideRule struct {
+	Type     string   `json:"type"` // creator 或 product
+	Entities []string `json:"entities"`
+}
+
+// Creator 某个平台上的创作者
+type Creator struct {
+	Platform string
+	User     *crawler.UserData
+}
+
+// Product 某个平台上的商品
+type Product struct {
+	Platform string
+	Product  *crawler.ProductInfo
+}
+
+// entity 参与识别的实体及其特征
+type entity struct {
+	key      string // 平台:ID
+	platform string
+	id       string
+	name     string
+
+	grams    map[string]bool // 归一化名称的二元组
+	norm     string          // 归一化名称
+	contacts map[string]bool // 手机号、微信号
+	desc     map[string]bool // 简介的二元组
+	province string
+	city     string
+	county   string
+	gi       string
+	perKg    float64
+}
+
+// link 两个实体之间的关联
+type link struct {
+	a, b       int
+	confidence float64
+	reasons    []string
+	manual     bool
+}
+
+// Resolver 跨平台实体识别器
+type Resolver struct {
+	minConfidence   float64
+	overrides       Overrides
+	originExtractor *origin.Extractor
+	priceNormalizer *pricenorm.Normalizer
+}
+
+// NewResolver 创建跨平台实体识别器
+func NewResolver(config Config) (*Resolver, error) {
+	if config.MinConfidence <= 0 {
+		config.MinConfidence = 0.7
+	}
+
+	resolver := &Resolver{
+		minConfidence:   config.MinConfidence,
+		priceNormalizer: pricenorm.NewNormalizer(pricenorm.Config{Enabled: true}),
+	}
+
+	// 加载人工覆盖文件
+	if config.OverrideFile != "" {
+		data, err := os.ReadFile(config.OverrideFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取人工覆盖文件失败: %v", err)
+		}
+		if err := json.Unmarshal(data, &resolver.overrides); err != nil {
+			return nil, fmt.Errorf("解析人工覆盖文件失败: %v", err)
+		}
+	}
+
+	// 地区和产地比较依赖产地提取器
+	var err error
+	resolver.originExtractor, err = origin.NewExtractor(origin.Config{Enabled: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return resolver, nil
+}
+
+// ResolveCreators 识别不同平台上的同一创作者
+func (r *Resolver) ResolveCreators(creators []*Creator) []*crawler.EntityCluster {
+	entities := make([]*entity, 0, len(creators))
+	for _, creator := range creators {
+		entities = append(entities, r.creatorEntity(creator))
+	}
+	return r.resolve(EntityCreator, entities, creatorScore)
+}
+
+// ResolveProducts 识别不同平台上的同一商品
+func (r *Resolver) ResolveProducts(products []*Product) []*crawler.EntityCluster {
+	entities := make([]*entity, 0, len(products))
+	for _, product := range products {
+		entities = append(entities, r.productEntity(product))
+	}
+	return r.resolve(EntityProduct, entities, productScore)
+}
+
+// resolve 生成候选对并打分，按置信度从高到低合并为簇
+func (r *Resolver) resolve(entityType string, entities []*entity, score func(a, b *entity) (float64, []string)) []*crawler.EntityCluster {
+	index := make(map[string]int)
+	for i, e := range entities {
+		index[e.key] = i
+	}
+
+	// 人工指定的实体可能不在本次数据中，补充为只有ID的实体
+	var manualLinks []link
+	for _, rule := range r.overrides.Link {
+		if rule.Type != entityType {
+			continue
+		}
+		var members []int
+		for _, key := range rule.Entities {
+			i, ok := index[key]
+			if !ok {
+				platform, id, found := strings.Cut(key, ":")
+				if !found {
+					logger.Warn("人工覆盖规则中的实体格式错误: %s", key)
+					continue
+				}
+				i = len(entities)
+				entities = append(entities, &entity{key: key, platform: platform, id: id})
+				index[key] = i
+			}
+			members = append(members, i)
+		}
+		for j := 1; j < len(members); j++ {
+			manualLinks = append(manualLinks, link{a: members[0], b: members[j], confidence: 1, reasons: []string{"manual"}, manual: true})
+		}
+	}
+
+	forbidden := make(map[[2]int]bool)
+	for _, rule := range r.overrides.Unlink {
+		if rule.Type != entityType {
+			continue
+		}
+		for j := range rule.Entities {
+			for k := j + 1; k < len(rule.Entities); k++ {
+				a, okA := index[rule.Entities[j]]
+				b, okB := index[rule.Entities[k]]
+				if okA && okB {
+					forbidden[pairKey(a, b)] = true
+				}
+			}
+		}
+	}
+
+	// 通过共同的名称二元组和联系方式生成候选对，只比较不同平台的实体
+	postings := make(map[string][]int)
+	for i, e := range entities {
+		for gram := range e.grams {
+			postings["g:"+gram] = append(postings["g:"+gram], i)
+		}
+		for contact := range e.contacts {
+			postings["c:"+contact] = append(postings["c:"+contact], i)
+		}
+	}
+	candidates := make(map[[2]int]bool)
+	for _, list := range postings {
+		if len(list) > maxPostings {
+			continue
+		}
+		for j := range list {
+			for k := j + 1; k < len(list); k++ {
+				if entities[list[j]].platform != entities[list[k]].platform {
+					candidates[pairKey(list[j], list[k])] = true
+				}
+			}
+		}
+	}
+
+	links := manualLinks
+	for pair := range candidates {
+		if forbidden[pair] {
+			continue
+		}
+		confidence, reasons := score(entities[pair[0]], entities[pair[1]])
+		if confidence >= r.minConfidence {
+			links = append(links, link{a: pair[0], b: pair[1], confidence: confidence, reasons: reasons})
+		}
+	}
+
+	// 人工关联优先，其余按置信度从高到低合并
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].manual != links[j].manual {
+			return links[i].manual
+		}
+		if links[i].confidence != links[j].confidence {
+			return links[i].confidence > links[j].confidence
+		}
+		return entities[links[i].a].key+entities[links[i].b].key < entities[links[j].a].key+entities[links[j].b].key
+	})
+
+	groups := newUnionFind(len(entities))
+	for _, l := range links {
+		groups.merge(l, entities, forbidden)
+	}
+
+	return r.clusters(entityType, entities, groups)
+}
+
+// clusters 将合并结果转换为实体簇，只保留包含两个及以上实体的簇
+func (r *Resolver) clusters(entityType string, entities []*entity, groups *unionFind) []*crawler.EntityCluster {
+	members := make(map[int][]int)
+	for i := range entities {
+		root := groups.find(i)
+		members[root] = append(members[root], i)
+	}
+
+	var clusters []*crawler.EntityCluster
+	for root, list := range members {
+		if len(list) < 2 {
+			continue
+		}
+
+		cluster := &crawler.EntityCluster{
+			EntityType: entityType,
+			Confidence: groups.confidence[root],
+			Reasons:    groups.reasonList(root),
+			Manual:     groups.manual[root],
+		}
+		var keys []string
+		for _, i := range list {
+			e := entities[i]
+			cluster.Members = append(cluster.Members, &crawler.EntityMember{Platform: e.platform, ID: e.id, Name: e.name})
+			keys = append(keys, e.key)
+		}
+		sort.Slice(cluster.Members, func(i, j int) bool {
+			return cluster.Members[i].Platform+":"+cluster.Members[i].ID < cluster.Members[j].Platform+":"+cluster.Members[j].ID
+		})
+		sort.Strings(keys)
+
+		// 簇ID由成员决定，成员不变时多次运行结果一致
+		sum := sha1.Sum([]byte(strings.Join(keys, ",")))
+		cluster.ClusterID = entityType + "-" + hex.EncodeToString(sum[:])[:12]
+		clusters = append(clusters, cluster)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].ClusterID < clusters[j].ClusterID
+	})
+	return clusters
+}
+
+// unionFind 带约束的并查集：同一簇内每个平台只保留一个实体，且不能包含禁止关联的实体对
+type unionFind struct {
+	parent     []int
+	confidence map[int]float64
+	reasons    map[int]map[string]bool
+	manual     map[int]bool
+}
+
+// newUnionFind 创建并查集
+func newUnionFind(n int) *unionFind {
+	u := &unionFind{
+		parent:     make([]int, n),
+		confidence: make(map[int]float64),
+		reasons:    make(map[int]map[string]bool),
+		manual:     make(map[int]bool),
+	}
+	for i := range u.parent {
+		u.parent[i] = i
+	}
+	return u
+}
+
+// find 查找根节点
+func (u *unionFind) find(i int) int {
+	for u.parent[i] != i {
+		u.parent[i] = u.parent[u.parent[i]]
+		i = u.parent[i]
+	}
+	return i
+}
+
+// merge 按关联合并两个簇，违反约束时返回 false，人工关联不受平台约束
+func (u *unionFind) merge(l link, entities []*entity, forbidden map[[2]int]bool) bool {
+	rootA, rootB := u.find(l.a), u.find(l.b)
+	if rootA == rootB {
+		return false
+	}
+
+	var membersA, membersB []int
+	for i := range u.parent {
+		switch u.find(i) {
+		case rootA:
+			membersA = append(membersA, i)
+		case rootB:
+			membersB = append(membersB, i)
+		}
+	}
+	for _, a := range membersA {
+		for _, b := range membersB {
+			if forbidden[pairKey(a, b)] {
+				return false
+			}
+			if !l.manual && entities[a].platform == entities[b].platform {
+				return false
+			}
+		}
+	}
+
+	// 簇的置信度取最弱的关联
+	confidence := l.confidence
+	for _, root := range []int{rootA, rootB} {
+		if value, ok := u.confidence[root]; ok {
+			confidence = math.Min(confidence, value)
+		}
+	}
+	reasons := make(map[string]bool)
+	for _, root := range []int{rootA, rootB} {
+		for reason := range u.reasons[root] {
+			reasons[reason] = true
+		}
+	}
+	for _, reason := range l.reasons {
+		reasons[reason] = true
+	}
+	manual := l.manual || u.manual[rootA] || u.manual[rootB]
+
+	u.parent[rootB] = rootA
+	u.confidence[rootA] = confidence
+	u.reasons[rootA] = reasons
+	u.manual[rootA] = manual
+	return true
+}
+
+// reasonList 返回簇的关联原因
+func (u *unionFind) reasonList(root int) []string {
+	var reasons []string
+	for reason := range u.reasons[root] {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+// pairKey 生成无序实体对的键
+func pairKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
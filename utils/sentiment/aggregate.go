@@ -0,0 +1,103 @@
+package sentiment
+
+import (
+	"Crawler/crawler"
+	"math"
+	"sort"
+)
+
+// 汇总对象类型
+const (
+	TargetVideo   = "video"
+	TargetProduct = "product"
+)
+
+// Aggregator 按视频和商品汇总评论分析结果
+type Aggregator struct {
+	summaries map[string]*summary
+}
+
+// summary 汇总过程中的中间结果
+type summary struct {
+	result       *crawler.FeedbackSummary
+	sentimentSum float64
+	keywords     map[string]int
+}
+
+// NewAggregator 创建汇总器
+func NewAggregator() *Aggregator {
+	return &Aggregator{summaries: make(map[string]*summary)}
+}
+
+// Add 将一条分析结果计入指定视频或商品
+func (g *Aggregator) Add(targetType, targetID string, analysis *crawler.CommentAnalysis) {
+	if analysis == nil || targetID == "" {
+		return
+	}
+
+	key := targetType + "/" + targetID
+	s, ok := g.summaries[key]
+	if !ok {
+		s = &summary{
+			result: &crawler.FeedbackSummary{
+				TargetType: targetType,
+				TargetID:   targetID,
+				Complaints: make(map[string]int),
+			},
+			keywords: make(map[string]int),
+		}
+		g.summaries[key] = s
+	}
+
+	s.result.Total++
+	switch analysis.Label {
+	case LabelPositive:
+		s.result.Positive++
+	case LabelNegative:
+		s.result.Negative++
+	default:
+		s.result.Neutral++
+	}
+	s.sentimentSum += analysis.Sentiment
+
+	for _, complaint := range analysis.Complaints {
+		s.result.Complaints[complaint]++
+	}
+	for _, keyword := range analysis.Keywords {
+		s.keywords[keyword]++
+	}
+}
+
+// Summaries 返回全部汇总结果，按类型和ID排序，每项保留出现次数最多的 topKeywords 个关键词
+func (g *Aggregator) Summaries(topKeywords int) []*crawler.FeedbackSummary {
+	var results []*crawler.FeedbackSummary
+	for _, s := range g.summaries {
+		result := s.result
+		result.AvgSentiment = math.Round(s.sentimentSum/float64(result.Total)*100) / 100
+
+		// 关键词按次数降序，次数相同时按字典序
+		result.TopKeywords = nil
+		for word, count := range s.keywords {
+			result.TopKeywords = append(result.TopKeywords, crawler.KeywordCount{Word: word, Count: count})
+		}
+		sort.Slice(result.TopKeywords, func(i, j int) bool {
+			if result.TopKeywords[i].Count != result.TopKeywords[j].Count {
+				return result.TopKeywords[i].Count > result.TopKeywords[j].Count
+			}
+			return result.TopKeywords[i].Word < result.TopKeywords[j].Word
+		})
+		if len(result.TopKeywords) > topKeywords {
+			result.TopKeywords = result.TopKeywords[:topKeywords]
+		}
+
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].TargetType != results[j].TargetType {
+			return results[i].TargetType < results[j].TargetType
+		}
+		return results[i].TargetID < results[j].TargetID
+	})
+	return results
+}
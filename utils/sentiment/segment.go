@@ -0,0 +1,162 @@
+package sentiment
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// segmenter 基于词典的双向最大匹配分词器
+type segmenter struct {
+	words  map[string]bool
+	maxLen int // 词典中最长词的字数
+}
+
+// newSegmenter 创建分词器
+func newSegmenter() *segmenter {
+	return &segmenter{words: make(map[string]bool)}
+}
+
+// addWord 向词典中添加词
+func (s *segmenter) addWord(word string) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return
+	}
+	s.words[word] = true
+	if n := utf8.RuneCountInString(word); n > s.maxLen {
+		s.maxLen = n
+	}
+}
+
+// cut 对文本分词，标点作为单独的词保留以便划分分句，空白被丢弃
+func (s *segmenter) cut(text string) []string {
+	runes := []rune(strings.ToLower(text))
+	var tokens []string
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		// 表情，如 [赞]、[比心]
+		case r == '[':
+			end := i + 1
+			for end < len(runes) && end-i <= 6 && runes[end] != ']' && runes[end] != '[' {
+				end++
+			}
+			if end < len(runes) && runes[end] == ']' {
+				tokens = append(tokens, string(runes[i:end+1]))
+				i = end + 1
+			} else {
+				tokens = append(tokens, string(r))
+				i++
+			}
+
+		// 连续的字母和数字作为一个词
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			end := i
+			for end < len(runes) && runes[end] < utf8.RuneSelf && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			// 与后续汉字组成词典词时一并切分，如“q弹”
+			if n := s.longestMatch(runes[i:], end-i+1); n > 0 {
+				end = i + n
+			}
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			tokens = append(tokens, string(r))
+			i++
+
+		// 连续的汉字片段按词典切分
+		default:
+			end := i
+			for end < len(runes) && isWordRune(runes[end]) {
+				end++
+			}
+			tokens = append(tokens, s.cutSpan(runes[i:end])...)
+			i = end
+		}
+	}
+
+	return tokens
+}
+
+// longestMatch 返回从开头起不短于 minLen 的最长词典词的字数，没有时返回 0
+func (s *segmenter) longestMatch(runes []rune, minLen int) int {
+	for n := min(s.maxLen, len(runes)); n >= minLen; n-- {
+		if s.words[string(runes[:n])] {
+			return n
+		}
+	}
+	return 0
+}
+
+// cutSpan 对汉字片段分别进行正向和逆向最大匹配，取词数更少、单字更少的结果
+func (s *segmenter) cutSpan(span []rune) []string {
+	forward := s.forwardMatch(span)
+	backward := s.backwardMatch(span)
+
+	if len(forward) != len(backward) {
+		if len(forward) < len(backward) {
+			return forward
+		}
+		return backward
+	}
+	if singleCount(forward) < singleCount(backward) {
+		return forward
+	}
+	return backward
+}
+
+// forwardMatch 正向最大匹配
+func (s *segmenter) forwardMatch(span []rune) []string {
+	var tokens []string
+	for i := 0; i < len(span); {
+		n := min(s.maxLen, len(span)-i)
+		for ; n > 1; n-- {
+			if s.words[string(span[i:i+n])] {
+				break
+			}
+		}
+		tokens = append(tokens, string(span[i:i+max(n, 1)]))
+		i += max(n, 1)
+	}
+	return tokens
+}
+
+// backwardMatch 逆向最大匹配
+func (s *segmenter) backwardMatch(span []rune) []string {
+	var tokens []string
+	for j := len(span); j > 0; {
+		n := min(s.maxLen, j)
+		for ; n > 1; n-- {
+			if s.words[string(span[j-n:j])] {
+				break
+			}
+		}
+		n = max(n, 1)
+		tokens = append([]string{string(span[j-n : j])}, tokens...)
+		j -= n
+	}
+	return tokens
+}
+
+// isWordRune 判断是否为需要按词典切分的字符
+func isWordRune(r rune) bool {
+	return r >= utf8.RuneSelf && !unicode.IsSpace(r) && !unicode.IsPunct(r) && !unicode.IsSymbol(r) && r != '['
+}
+
+// singleCount 统计单字词的数量
+func singleCount(tokens []string) int {
+	count := 0
+	for _, token := range tokens {
+		if utf8.RuneCountInString(token) == 1 {
+			count++
+		}
+	}
+	return count
+}
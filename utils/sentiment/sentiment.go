@@ -0,0 +1,379 @@
+package sentiment
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/logger"
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed lexicon.json
+var defaultLexicon []byte
+
+//go:embed dict.txt
+var defaultDict []byte
+
+// 情感标签
+const (
+	LabelPositive = "positive"
+	LabelNeutral  = "neutral"
+	LabelNegative = "negative"
+)
+
+const (
+	labelThreshold = 0.15 // 情感得分绝对值低于该值时视为中性
+	negationFactor = -0.8 // 否定词使情感反转并减弱
+	contrastBefore = 0.6  // 转折词之前的分句权重
+	contrastAfter  = 1.5  // 转折词之后的分句权重
+	lookBack       = 3    // 向前查找否定词和程度副词的词数
+	maxKeywords    = 10   // 每条评论最多保留的关键词数
+)
+
+// Config 评论分析配置
+type Config struct {
+	Enabled     bool   `json:"enabled"`
+	LexiconFile string `json:"lexicon_file"` // 自定义情感词典文件，为空时使用内置词典
+	DictFile    string `json:"dict_file"`    // 附加分词词典文件，每行一个词
+}
+
+// Lexicon 情感词典
+type Lexicon struct {
+	Positive   map[string]float64 `json:"positive"`
+	Negative   map[string]float64 `json:"negative"`
+	Negators   []string           `json:"negators"`
+	Degree     map[string]float64 `json:"degree"`
+	Contrast   []string           `json:"contrast"`
+	Complaints []Complaint        `json:"complaints"`
+	Stopwords  []string           `json:"stopwords"`
+}
+
+// Complaint 投诉类别，命中投诉短语，或在负面分句中提及相关方面时归入该类别
+type Complaint struct {
+	Name    string   `json:"name"`
+	Label   string   `json:"label"`
+	Aspects []string `json:"aspects"`
+	Phrases []string `json:"phrases"`
+}
+
+// Analyzer 基于词典的评论分词、情感和投诉分析器，不依赖外部服务
+type Analyzer struct {
+	enabled   bool
+	segmenter *segmenter
+
+	sentiments map[string]float64 // 情感词 -> 带符号的权重
+	negators   map[string]bool
+	degree     map[string]float64
+	contrast   map[string]bool
+	stopwords  map[string]bool
+	aspects    map[string][]string // 方面词 -> 投诉类别
+	phrases    map[string][]string // 投诉短语 -> 投诉类别
+	categories []string            // 保持词典中的顺序
+}
+
+// NewAnalyzer 创建评论分析器
+func NewAnalyzer(config Config) (*Analyzer, error) {
+	if !config.Enabled {
+		return &Analyzer{enabled: false}, nil
+	}
+
+	// 加载情感词典
+	data := defaultLexicon
+	if config.LexiconFile != "" {
+		var err error
+		data, err = os.ReadFile(config.LexiconFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取情感词典文件失败: %v", err)
+		}
+	}
+
+	var lexicon Lexicon
+	if err := json.Unmarshal(data, &lexicon); err != nil {
+		return nil, fmt.Errorf("解析情感词典失败: %v", err)
+	}
+
+	analyzer := &Analyzer{
+		enabled:    true,
+		segmenter:  newSegmenter(),
+		sentiments: make(map[string]float64),
+		negators:   toSet(lexicon.Negators),
+		degree:     make(map[string]float64),
+		contrast:   toSet(lexicon.Contrast),
+		stopwords:  toSet(lexicon.Stopwords),
+		aspects:    make(map[string][]string),
+		phrases:    make(map[string][]string),
+	}
+
+	for word, weight := range lexicon.Positive {
+		analyzer.sentiments[strings.ToLower(word)] = weight
+	}
+	for word, weight := range lexicon.Negative {
+		analyzer.sentiments[strings.ToLower(word)] = -weight
+	}
+	for word, weight := range lexicon.Degree {
+		analyzer.degree[word] = weight
+	}
+	for _, complaint := range lexicon.Complaints {
+		analyzer.categories = append(analyzer.categories, complaint.Name)
+		for _, aspect := range complaint.Aspects {
+			analyzer.aspects[aspect] = append(analyzer.aspects[aspect], complaint.Name)
+		}
+		for _, phrase := range complaint.Phrases {
+			analyzer.phrases[phrase] = append(analyzer.phrases[phrase], complaint.Name)
+		}
+	}
+
+	// 词典中的所有词都加入分词词典
+	for word := range analyzer.sentiments {
+		analyzer.segmenter.addWord(word)
+	}
+	for _, words := range []map[string]bool{analyzer.negators, analyzer.contrast, analyzer.stopwords} {
+		for word := range words {
+			analyzer.segmenter.addWord(word)
+		}
+	}
+	for word := range analyzer.degree {
+		analyzer.segmenter.addWord(word)
+	}
+	for word := range analyzer.aspects {
+		analyzer.segmenter.addWord(word)
+	}
+	for word := range analyzer.phrases {
+		analyzer.segmenter.addWord(word)
+	}
+
+	// 加载分词词典
+	dicts := [][]byte{defaultDict}
+	if config.DictFile != "" {
+		dict, err := os.ReadFile(config.DictFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取分词词典文件失败: %v", err)
+		}
+		dicts = append(dicts, dict)
+	}
+	for _, dict := range dicts {
+		scanner := bufio.NewScanner(bytes.NewReader(dict))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			analyzer.segmenter.addWord(line)
+		}
+	}
+
+	logger.Info("评论分析器已加载，共 %d 个词 %d 个情感词", len(analyzer.segmenter.words), len(analyzer.sentiments))
+	return analyzer, nil
+}
+
+// IsEnabled 检查评论分析是否启用
+func (a *Analyzer) IsEnabled() bool {
+	return a.enabled
+}
+
+// Categories 返回投诉类别名称，保持词典中的顺序
+func (a *Analyzer) Categories() []string {
+	return a.categories
+}
+
+// Segment 对文本分词，返回的结果中包含标点
+func (a *Analyzer) Segment(text string) []string {
+	if !a.enabled {
+		return nil
+	}
+	return a.segmenter.cut(text)
+}
+
+// Analyze 分析文本的情感、投诉类别和关键词
+func (a *Analyzer) Analyze(text string) *crawler.CommentAnalysis {
+	if !a.enabled {
+		return nil
+	}
+
+	tokens := a.segmenter.cut(text)
+	result := &crawler.CommentAnalysis{}
+	complaints := make(map[string]bool)
+	keywords := make(map[string]bool)
+
+	var total, clauseScore float64
+	var clauseAspects []string
+	clauseWeight := 1.0
+	clauseStart := 0
+
+	// 分句结束时累计得分，负面分句中提及的方面计入投诉
+	endClause := func() {
+		total += clauseScore * clauseWeight
+		if clauseScore < 0 {
+			for _, aspect := range clauseAspects {
+				for _, category := range a.aspects[aspect] {
+					complaints[category] = true
+				}
+			}
+		}
+		clauseScore = 0
+		clauseAspects = nil
+		clauseWeight = 1
+	}
+
+	for i, token := range tokens {
+		// 标点和转折词划分分句，转折之后的内容更能代表真实评价
+		if isPunctuation(token) {
+			endClause()
+			clauseStart = i + 1
+			continue
+		}
+		if a.contrast[token] {
+			endClause()
+			total *= contrastBefore
+			clauseWeight = contrastAfter
+			clauseStart = i + 1
+			continue
+		}
+
+		if _, ok := a.aspects[token]; ok {
+			clauseAspects = append(clauseAspects, token)
+		}
+
+		// 投诉短语前有否定词时不计入，如“没有压坏”
+		if categories, ok := a.phrases[token]; ok && !a.negatedAt(tokens, clauseStart, i) {
+			for _, category := range categories {
+				complaints[category] = true
+			}
+		}
+
+		if a.isKeyword(token) && len(keywords) < maxKeywords && !keywords[token] {
+			keywords[token] = true
+			result.Keywords = append(result.Keywords, token)
+		}
+
+		weight, ok := a.sentiments[token]
+		if !ok {
+			continue
+		}
+
+		// 程度副词后紧跟情感词时只作为程度副词，如“好甜”中的“好”
+		if _, isDegree := a.degree[token]; isDegree && i+1 < len(tokens) {
+			if _, next := a.sentiments[tokens[i+1]]; next {
+				continue
+			}
+		}
+
+		clauseScore += weight * a.modifier(tokens, clauseStart, i)
+	}
+	endClause()
+
+	// 将累计得分映射到 -1 到 1
+	result.Sentiment = math.Round(math.Tanh(total/3)*100) / 100
+	result.Label = label(result.Sentiment)
+
+	for _, category := range a.categories {
+		if complaints[category] {
+			result.Complaints = append(result.Complaints, category)
+		}
+	}
+
+	return result
+}
+
+// modifier 根据情感词前面的否定词和程度副词计算修饰系数
+func (a *Analyzer) modifier(tokens []string, clauseStart, index int) float64 {
+	factor := 1.0
+	for j := index - 1; j >= clauseStart && j >= index-lookBack; j-- {
+		if a.negators[tokens[j]] {
+			factor *= negationFactor
+		} else if degree, ok := a.degree[tokens[j]]; ok {
+			factor *= degree
+		}
+	}
+	return factor
+}
+
+// negatedAt 判断分句中指定位置的词前面是否有否定词
+func (a *Analyzer) negatedAt(tokens []string, clauseStart, index int) bool {
+	for j := index - 1; j >= clauseStart && j >= index-lookBack; j-- {
+		if a.negators[tokens[j]] {
+			return true
+		}
+	}
+	return false
+}
+
+// isKeyword 判断词是否可作为关键词
+func (a *Analyzer) isKeyword(token string) bool {
+	if utf8.RuneCountInString(token) < 2 || strings.HasPrefix(token, "[") {
+		return false
+	}
+	if a.stopwords[token] || a.negators[token] || a.contrast[token] {
+		return false
+	}
+	if _, ok := a.degree[token]; ok {
+		return false
+	}
+	for _, r := range token {
+		if !unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeComment 分析评论并将结果附加到评论上
+func (a *Analyzer) AnalyzeComment(comment *crawler.CommentData) *crawler.CommentAnalysis {
+	if !a.enabled {
+		return nil
+	}
+
+	comment.Analysis = a.Analyze(comment.Content)
+	return comment.Analysis
+}
+
+// AnalyzeReview 分析商品评价并将结果附加到评价上，有评分时结合评分
+func (a *Analyzer) AnalyzeReview(review *crawler.ProductReview) *crawler.CommentAnalysis {
+	if !a.enabled {
+		return nil
+	}
+
+	analysis := a.Analyze(review.Content)
+	if review.Rating >= 1 && review.Rating <= 5 {
+		rating := float64(review.Rating-3) / 2
+		analysis.Sentiment = math.Round((analysis.Sentiment*0.7+rating*0.3)*100) / 100
+		analysis.Label = label(analysis.Sentiment)
+	}
+
+	review.Analysis = analysis
+	return review.Analysis
+}
+
+// label 根据情感得分给出情感标签
+func label(sentiment float64) string {
+	switch {
+	case sentiment >= labelThreshold:
+		return LabelPositive
+	case sentiment <= -labelThreshold:
+		return LabelNegative
+	default:
+		return LabelNeutral
+	}
+}
+
+// isPunctuation 判断词是否为标点
+func isPunctuation(token string) bool {
+	r, size := utf8.DecodeRuneInString(token)
+	return size == len(token) && (unicode.IsPunct(r) || unicode.IsSymbol(r))
+}
+
+// toSet 将词列表转换为集合
+func toSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,65 @@
+package spam
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+const (
+	minHashSize = 64 // MinHash 签名长度
+	lshBands    = 16 // LSH 分段数，每段 4 个值，相似度 0.6 的评论约 88% 的概率成为候选
+	lshRows     = minHashSize / lshBands
+)
+
+// signature MinHash 签名
+type signature [minHashSize]uint64
+
+// minHash 以相邻两个字符为特征计算文本的 MinHash 签名
+func minHash(runes []rune) signature {
+	var sig signature
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+
+	for i := 0; i+1 < len(runes); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(string(runes[i : i+2])))
+		feature := h.Sum64()
+
+		// 以不同种子混合特征哈希，模拟多个独立的哈希函数
+		for j := range sig {
+			if value := mix(feature ^ uint64(j)*0x9E3779B97F4A7C15); value < sig[j] {
+				sig[j] = value
+			}
+		}
+	}
+	return sig
+}
+
+// similarity 估计两个签名对应文本的 Jaccard 相似度
+func similarity(a, b *signature) float64 {
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / minHashSize
+}
+
+// bandKey 计算签名第 i 段的 LSH 键
+func bandKey(sig *signature, i int) uint64 {
+	key := uint64(i)
+	for _, value := range sig[i*lshRows : (i+1)*lshRows] {
+		key = mix(key ^ value)
+	}
+	return key
+}
+
+// mix splitmix64 混合函数
+func mix(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	x = (x ^ (x >> 30)) * 0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27)) * 0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
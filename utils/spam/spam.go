@@ -0,0 +1,318 @@
+package spam
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/logger"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// 判定原因
+const (
+	ReasonDuplicate     = "duplicate"           // 近似重复的模板评论
+	ReasonHighFrequency = "high_frequency_user" // 评论数异常多的用户
+	ReasonBurst         = "burst"               // 短时间内集中发布评论
+	ReasonLink          = "link"                // 包含链接
+	ReasonContact       = "contact"             // 包含微信、QQ、手机号等联系方式
+	ReasonEmoji         = "emoji_spam"          // 几乎只有表情
+	ReasonRepeated      = "repeated_chars"      // 大量重复字符
+)
+
+// 各项特征的得分
+const (
+	duplicateScore     = 0.5
+	crossVideoScore    = 0.1 // 近似重复评论出现在多个视频下时追加
+	highFrequencyScore = 0.3
+	burstScore         = 0.4
+	linkScore          = 0.6
+	contactScore       = 0.6
+	emojiScore         = 0.3
+	repeatedScore      = 0.2
+
+	minDuplicateRunes = 6 // 过短的评论（如“好吃”）不参与近似重复检测
+	minEmojiCount     = 3
+	minRepeatedRunes  = 6
+)
+
+var (
+	linkPattern    = regexp.MustCompile(`https?://|www\.|t\.cn/|\.com|\.cn/|点击链接|复制链接|打开链接`)
+	contactPattern = regexp.MustCompile(`加?微信|威信|薇信|v信|vx|wx[:：]|加v|qq|扣扣|私信我|私聊我|扫码|二维码|联系方式|1[3-9][0-9]{9}`)
+	emojiPattern   = regexp.MustCompile(`\[[^\[\]]{1,6}\]`)
+)
+
+// Config 垃圾评论检测配置
+type Config struct {
+	Enabled         bool    `json:"enabled"`
+	Threshold       float64 `json:"threshold"`         // 得分达到该值时判定为垃圾评论
+	MinSimilarity   float64 `json:"min_similarity"`    // 判定为近似重复的最低 Jaccard 相似度
+	MinDuplicates   int     `json:"min_duplicates"`    // 近似重复评论达到该条数时判定为模板评论
+	UserMaxComments int     `json:"user_max_comments"` // 同一用户评论数达到该值时判定为高频评论用户
+	BurstWindow     int64   `json:"burst_window"`      // 突发评论检测的时间窗口（秒）
+	BurstCount      int     `json:"burst_count"`       // 时间窗口内同一用户评论数达到该值时判定为突发评论
+	DropSpam        bool    `json:"drop_spam"`         // 是否丢弃判定为垃圾的评论
+}
+
+// cluster 近似重复评论簇，以第一条评论的签名作为代表
+type cluster struct {
+	signature signature
+	count     int
+	videos    map[string]bool
+}
+
+// userStats 用户评论统计
+type userStats struct {
+	count      int
+	timestamps []int64
+}
+
+// Detector 垃圾评论检测器，基于 MinHash 检测跨视频的近似重复评论、高频评论用户以及链接、联系方式和表情刷屏
+type Detector struct {
+	enabled bool
+	config  Config
+
+	clusters []*cluster
+	bands    [lshBands]map[uint64][]int // LSH 分段键 -> 簇下标
+	comments map[string]int             // 评论ID -> 簇下标，-1 表示不参与近似重复检测
+	users    map[string]*userStats
+	mutex    sync.Mutex
+}
+
+// NewDetector 创建垃圾评论检测器
+func NewDetector(config Config) *Detector {
+	if !config.Enabled {
+		return &Detector{enabled: false}
+	}
+
+	// 补全默认值
+	if config.Threshold <= 0 {
+		config.Threshold = 0.6
+	}
+	if config.MinSimilarity <= 0 {
+		config.MinSimilarity = 0.6
+	}
+	if config.MinDuplicates <= 0 {
+		config.MinDuplicates = 3
+	}
+	if config.UserMaxComments <= 0 {
+		config.UserMaxComments = 20
+	}
+	if config.BurstWindow <= 0 {
+		config.BurstWindow = 60
+	}
+	if config.BurstCount <= 0 {
+		config.BurstCount = 5
+	}
+
+	detector := &Detector{
+		enabled:  true,
+		config:   config,
+		comments: make(map[string]int),
+		users:    make(map[string]*userStats),
+	}
+	for i := range detector.bands {
+		detector.bands[i] = make(map[uint64][]int)
+	}
+
+	logger.Info("垃圾评论检测已启用，判定阈值 %.2f", config.Threshold)
+	return detector
+}
+
+// IsEnabled 检查垃圾评论检测是否启用
+func (d *Detector) IsEnabled() bool {
+	return d.enabled
+}
+
+// Observe 记录评论用于重复和高频检测，同一评论只记录一次
+func (d *Detector) Observe(comment *crawler.CommentData) {
+	if !d.enabled {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if _, ok := d.comments[comment.CommentID]; ok {
+		return
+	}
+
+	// 用户评论统计
+	if comment.UserID != "" {
+		stats, ok := d.users[comment.UserID]
+		if !ok {
+			stats = &userStats{}
+			d.users[comment.UserID] = stats
+		}
+		stats.count++
+		stats.timestamps = append(stats.timestamps, comment.Timestamp)
+	}
+
+	// 近似重复检测
+	runes := normalize(comment.Content)
+	if len(runes) < minDuplicateRunes {
+		d.comments[comment.CommentID] = -1
+		return
+	}
+
+	sig := minHash(runes)
+	index := d.findCluster(&sig)
+	if index < 0 {
+		index = len(d.clusters)
+		d.clusters = append(d.clusters, &cluster{signature: sig, videos: make(map[string]bool)})
+		for i := range d.bands {
+			key := bandKey(&sig, i)
+			d.bands[i][key] = append(d.bands[i][key], index)
+		}
+	}
+	d.clusters[index].count++
+	d.clusters[index].videos[comment.VideoID] = true
+	d.comments[comment.CommentID] = index
+}
+
+// findCluster 通过 LSH 查找候选簇，返回相似度达到阈值的簇，没有时返回 -1
+func (d *Detector) findCluster(sig *signature) int {
+	for i := range d.bands {
+		for _, index := range d.bands[i][bandKey(sig, i)] {
+			if similarity(&d.clusters[index].signature, sig) >= d.config.MinSimilarity {
+				return index
+			}
+		}
+	}
+	return -1
+}
+
+// Score 根据已记录的评论计算垃圾评论得分和判定原因，并附加到评论上
+func (d *Detector) Score(comment *crawler.CommentData) float64 {
+	if !d.enabled {
+		return 0
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var score float64
+	var reasons []string
+	add := func(value float64, reason string) {
+		score += value
+		reasons = append(reasons, reason)
+	}
+
+	// 模板评论
+	if index, ok := d.comments[comment.CommentID]; ok && index >= 0 {
+		c := d.clusters[index]
+		if c.count >= d.config.MinDuplicates {
+			value := duplicateScore
+			if len(c.videos) > 1 {
+				value += crossVideoScore
+			}
+			add(value, ReasonDuplicate)
+		}
+	}
+
+	// 高频评论用户
+	if stats, ok := d.users[comment.UserID]; ok && comment.UserID != "" {
+		if stats.count >= d.config.UserMaxComments {
+			add(highFrequencyScore, ReasonHighFrequency)
+		}
+		if comment.Timestamp > 0 {
+			burst := 0
+			for _, timestamp := range stats.timestamps {
+				if timestamp >= comment.Timestamp-d.config.BurstWindow && timestamp <= comment.Timestamp+d.config.BurstWindow {
+					burst++
+				}
+			}
+			if burst >= d.config.BurstCount {
+				add(burstScore, ReasonBurst)
+			}
+		}
+	}
+
+	// 内容特征
+	content := strings.ToLower(comment.Content)
+	if linkPattern.MatchString(content) {
+		add(linkScore, ReasonLink)
+	}
+	if contactPattern.MatchString(content) {
+		add(contactScore, ReasonContact)
+	}
+	if isEmojiSpam(content) {
+		add(emojiScore, ReasonEmoji)
+	}
+	if hasRepeatedRunes(content) {
+		add(repeatedScore, ReasonRepeated)
+	}
+
+	comment.SpamScore = math.Round(math.Min(score, 1)*100) / 100
+	comment.SpamReasons = reasons
+	return comment.SpamScore
+}
+
+// Check 记录评论并计算得分，返回 false 表示应丢弃该评论。
+// 采集过程中同一模板的前几条评论出现时尚未达到重复条数，离线检测时应先 Observe 全部评论再 Score。
+func (d *Detector) Check(comment *crawler.CommentData) bool {
+	if !d.enabled {
+		return true
+	}
+
+	d.Observe(comment)
+	d.Score(comment)
+	return d.Keep(comment)
+}
+
+// IsSpam 判断已评分的评论是否为垃圾评论
+func (d *Detector) IsSpam(comment *crawler.CommentData) bool {
+	return d.enabled && comment.SpamScore >= d.config.Threshold
+}
+
+// Keep 判断已评分的评论是否需要保留
+func (d *Detector) Keep(comment *crawler.CommentData) bool {
+	return !d.config.DropSpam || !d.IsSpam(comment)
+}
+
+// normalize 去除表情、标点、空白和数字，只保留文字用于计算签名
+func normalize(content string) []rune {
+	content = emojiPattern.ReplaceAllString(strings.ToLower(content), "")
+
+	var runes []rune
+	for _, r := range content {
+		if unicode.IsLetter(r) {
+			runes = append(runes, r)
+		}
+	}
+	return runes
+}
+
+// isEmojiSpam 判断评论是否几乎只由表情组成
+func isEmojiSpam(content string) bool {
+	emojis := len(emojiPattern.FindAllString(content, -1))
+	text := 0
+	for _, r := range emojiPattern.ReplaceAllString(content, "") {
+		switch {
+		case r >= 0x1F000 || (r >= 0x2600 && r <= 0x27BF):
+			emojis++
+		case unicode.IsLetter(r):
+			text++
+		}
+	}
+	return emojis >= minEmojiCount && text <= emojis/2
+}
+
+// hasRepeatedRunes 判断是否有同一字符连续出现多次，如“哈哈哈哈哈哈”
+func hasRepeatedRunes(content string) bool {
+	var last rune
+	count := 0
+	for _, r := range content {
+		if r == last {
+			count++
+			if count >= minRepeatedRunes && !unicode.IsSpace(r) {
+				return true
+			}
+		} else {
+			last = r
+			count = 1
+		}
+	}
+	return false
+}
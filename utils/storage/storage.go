@@ -5,6 +5,8 @@ import (
 	"Crawler/utils/logger"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -66,6 +68,17 @@ func NewManager(config Config) (*Manager, error) {
 		return nil, fmt.Errorf("初始化数据库失败: %v", err)
 	}
 
+	// 迁移旧版本创建的表
+	if err := manager.migrateKeys(); err != nil {
+		return nil, fmt.Errorf("迁移数据库失败: %v", err)
+	}
+	if err := manager.migrateColumns(); err != nil {
+		return nil, fmt.Errorf("迁移数据库失败: %v", err)
+	}
+	if err := manager.migrateIndexes(); err != nil {
+		return nil, fmt.Errorf("迁移数据库失败: %v", err)
+	}
+
 	// 准备SQL语句
 	if err := manager.prepareStatements(); err != nil {
 		return nil, fmt.Errorf("准备SQL语句失败: %v", err)
@@ -75,6 +88,11 @@ func NewManager(config Config) (*Manager, error) {
 	return manager, nil
 }
 
+// IsEnabled 检查是否启用了数据库存储
+func (m *Manager) IsEnabled() bool {
+	return m.enabled
+}
+
 // Close 关闭数据库连接
 func (m *Manager) Close() error {
 	if !m.enabled || m.db == nil {
@@ -99,7 +117,7 @@ func (m *Manager) initDatabase() error {
 	// 创建用户表
 	_, err := m.db.Exec(`
 		CREATE TABLE IF NOT EXISTS users (
-			user_id VARCHAR(64) PRIMARY KEY,
+			user_id VARCHAR(64) NOT NULL,
 			nickname VARCHAR(255) NOT NULL,
 			followers INT NOT NULL,
 			following INT NOT NULL,
@@ -107,7 +125,23 @@ func (m *Manager) initDatabase() error {
 			tags TEXT,
 			platform VARCHAR(32) NOT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (platform, user_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建用户关注关系表
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS user_edges (
+			from_user_id VARCHAR(64) NOT NULL,
+			to_user_id VARCHAR(64) NOT NULL,
+			platform VARCHAR(32) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (platform, from_user_id, to_user_id),
+			INDEX (platform, to_user_id)
 		)
 	`)
 	if err != nil {
@@ -117,7 +151,7 @@ func (m *Manager) initDatabase() error {
 	// 创建视频表
 	_, err = m.db.Exec(`
 		CREATE TABLE IF NOT EXISTS videos (
-			video_id VARCHAR(64) PRIMARY KEY,
+			video_id VARCHAR(64) NOT NULL,
 			user_id VARCHAR(64) NOT NULL,
 			title VARCHAR(255),
 			description TEXT,
@@ -125,10 +159,20 @@ func (m *Manager) initDatabase() error {
 			comments INT NOT NULL,
 			shares INT NOT NULL,
 			tags TEXT,
+			agri_category VARCHAR(32) NOT NULL DEFAULT '',
+			agri_crop VARCHAR(32) NOT NULL DEFAULT '',
+			agri_confidence DECIMAL(3,2) NOT NULL DEFAULT 0,
+			origin_province VARCHAR(32) NOT NULL DEFAULT '',
+			origin_city VARCHAR(32) NOT NULL DEFAULT '',
+			origin_county VARCHAR(32) NOT NULL DEFAULT '',
+			gi_product VARCHAR(64) NOT NULL DEFAULT '',
 			platform VARCHAR(32) NOT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-			INDEX (user_id)
+			PRIMARY KEY (platform, video_id),
+			INDEX (platform, user_id),
+			INDEX (agri_category),
+			INDEX (origin_province, origin_city)
 		)
 	`)
 	if err != nil {
@@ -138,18 +182,25 @@ func (m *Manager) initDatabase() error {
 	// 创建评论表
 	_, err = m.db.Exec(`
 		CREATE TABLE IF NOT EXISTS comments (
-			comment_id VARCHAR(64) PRIMARY KEY,
+			comment_id VARCHAR(64) NOT NULL,
 			video_id VARCHAR(64) NOT NULL,
 			user_id VARCHAR(64) NOT NULL,
+			parent_id VARCHAR(64) NOT NULL DEFAULT '',
+			reply_to_user_id VARCHAR(64) NOT NULL DEFAULT '',
 			content TEXT NOT NULL,
 			likes INT NOT NULL,
 			replies INT NOT NULL,
 			timestamp BIGINT NOT NULL,
+			spam_score DECIMAL(3,2) NOT NULL DEFAULT 0,
+			spam_reasons VARCHAR(255) NOT NULL DEFAULT '',
 			platform VARCHAR(32) NOT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-			INDEX (video_id),
-			INDEX (user_id)
+			PRIMARY KEY (platform, comment_id),
+			INDEX (platform, video_id),
+			INDEX (platform, user_id),
+			INDEX (platform, parent_id),
+			INDEX (spam_score)
 		)
 	`)
 	if err != nil {
@@ -159,15 +210,159 @@ func (m *Manager) initDatabase() error {
 	// 创建商品表
 	_, err = m.db.Exec(`
 		CREATE TABLE IF NOT EXISTS products (
-			product_id VARCHAR(64) PRIMARY KEY,
+			product_id VARCHAR(64) NOT NULL,
 			name VARCHAR(255) NOT NULL,
 			price DECIMAL(10,2) NOT NULL,
+			original_price DECIMAL(10,2) NOT NULL DEFAULT 0,
+			min_price DECIMAL(10,2) NOT NULL DEFAULT 0,
+			max_price DECIMAL(10,2) NOT NULL DEFAULT 0,
 			category VARCHAR(128),
 			description TEXT,
 			sales INT NOT NULL,
+			shop_id VARCHAR(64) NOT NULL DEFAULT '',
+			ship_from VARCHAR(128) NOT NULL DEFAULT '',
+			off_shelf BOOLEAN NOT NULL DEFAULT FALSE,
+			rating_score DECIMAL(3,2) NOT NULL DEFAULT 0,
+			review_count INT NOT NULL DEFAULT 0,
+			good_rate DECIMAL(5,4) NOT NULL DEFAULT 0,
+			agri_category VARCHAR(32) NOT NULL DEFAULT '',
+			agri_crop VARCHAR(32) NOT NULL DEFAULT '',
+			agri_confidence DECIMAL(3,2) NOT NULL DEFAULT 0,
+			origin_province VARCHAR(32) NOT NULL DEFAULT '',
+			origin_city VARCHAR(32) NOT NULL DEFAULT '',
+			origin_county VARCHAR(32) NOT NULL DEFAULT '',
+			gi_product VARCHAR(64) NOT NULL DEFAULT '',
+			weight_kg DECIMAL(10,3) NOT NULL DEFAULT 0,
+			price_per_jin DECIMAL(10,2) NOT NULL DEFAULT 0,
+			price_per_kg DECIMAL(10,2) NOT NULL DEFAULT 0,
+			unit_price_confidence DECIMAL(3,2) NOT NULL DEFAULT 0,
+			source VARCHAR(16) NOT NULL DEFAULT 'shelf',
+			match_name VARCHAR(255) NOT NULL DEFAULT '',
 			platform VARCHAR(32) NOT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (platform, product_id),
+			INDEX (platform, shop_id),
+			INDEX (agri_category),
+			INDEX (origin_province, origin_city),
+			INDEX (match_name, source)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建商品规格表
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS product_skus (
+			product_id VARCHAR(64) NOT NULL,
+			sku_id VARCHAR(64) NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			weight VARCHAR(64),
+			grade VARCHAR(64),
+			origin VARCHAR(128),
+			price DECIMAL(10,2) NOT NULL,
+			original_price DECIMAL(10,2) NOT NULL,
+			stock INT NOT NULL,
+			weight_kg DECIMAL(10,3) NOT NULL DEFAULT 0,
+			price_per_jin DECIMAL(10,2) NOT NULL DEFAULT 0,
+			price_per_kg DECIMAL(10,2) NOT NULL DEFAULT 0,
+			unit_price_confidence DECIMAL(3,2) NOT NULL DEFAULT 0,
+			platform VARCHAR(32) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (platform, product_id, sku_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建商品评价表
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS product_reviews (
+			review_id VARCHAR(64) NOT NULL,
+			product_id VARCHAR(64) NOT NULL,
+			user_id VARCHAR(64) NOT NULL,
+			content TEXT NOT NULL,
+			rating TINYINT NOT NULL,
+			sku_name VARCHAR(255),
+			timestamp BIGINT NOT NULL,
+			platform VARCHAR(32) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (platform, review_id),
+			INDEX (platform, product_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建店铺表
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS shops (
+			shop_id VARCHAR(64) NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			rating DECIMAL(3,2) NOT NULL,
+			followers INT NOT NULL,
+			category VARCHAR(128),
+			location VARCHAR(255),
+			platform VARCHAR(32) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (platform, shop_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建直播间表
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS live_rooms (
+			room_id VARCHAR(64) NOT NULL,
+			user_id VARCHAR(64) NOT NULL,
+			title VARCHAR(255),
+			start_time BIGINT NOT NULL,
+			platform VARCHAR(32) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (platform, room_id),
+			INDEX (platform, user_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建直播采样表
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS live_samples (
+			room_id VARCHAR(64) NOT NULL,
+			timestamp BIGINT NOT NULL,
+			viewer_count INT NOT NULL,
+			total_viewers INT NOT NULL,
+			likes INT NOT NULL,
+			platform VARCHAR(32) NOT NULL,
+			PRIMARY KEY (platform, room_id, timestamp)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建直播采样商品表，记录每次采样时购物车商品的价格和销量
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS live_products (
+			room_id VARCHAR(64) NOT NULL,
+			timestamp BIGINT NOT NULL,
+			product_id VARCHAR(64) NOT NULL,
+			price DECIMAL(10,2) NOT NULL,
+			sales INT NOT NULL,
+			platform VARCHAR(32) NOT NULL,
+			PRIMARY KEY (platform, room_id, timestamp, product_id),
+			INDEX (platform, product_id)
 		)
 	`)
 	if err != nil {
@@ -181,8 +376,104 @@ func (m *Manager) initDatabase() error {
 			product_id VARCHAR(64) NOT NULL,
 			platform VARCHAR(32) NOT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (video_id, product_id),
-			INDEX (product_id)
+			PRIMARY KEY (platform, video_id, product_id),
+			INDEX (platform, product_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建商品变化事件表
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS product_events (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			product_id VARCHAR(64) NOT NULL,
+			event_type VARCHAR(32) NOT NULL,
+			old_value DECIMAL(12,2) NOT NULL,
+			new_value DECIMAL(12,2) NOT NULL,
+			change_percent DECIMAL(8,2) NOT NULL,
+			timestamp BIGINT NOT NULL,
+			platform VARCHAR(32) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (platform, product_id),
+			INDEX (event_type, timestamp)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建评论分析结果表，source_type 为 comment 或 review
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS comment_analysis (
+			source_type VARCHAR(16) NOT NULL,
+			source_id VARCHAR(64) NOT NULL,
+			sentiment DECIMAL(3,2) NOT NULL,
+			label VARCHAR(16) NOT NULL,
+			complaints VARCHAR(255) NOT NULL DEFAULT '',
+			keywords TEXT,
+			platform VARCHAR(32) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (platform, source_type, source_id),
+			INDEX (label)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建评论汇总表，target_type 为 video 或 product
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS feedback_summaries (
+			target_type VARCHAR(16) NOT NULL,
+			target_id VARCHAR(64) NOT NULL,
+			total INT NOT NULL,
+			positive INT NOT NULL,
+			neutral INT NOT NULL,
+			negative INT NOT NULL,
+			avg_sentiment DECIMAL(3,2) NOT NULL,
+			complaints TEXT,
+			top_keywords TEXT,
+			platform VARCHAR(32) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (platform, target_type, target_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建实体簇表，entity_type 为 creator 或 product
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS entity_clusters (
+			cluster_id VARCHAR(64) PRIMARY KEY,
+			entity_type VARCHAR(16) NOT NULL,
+			confidence DECIMAL(3,2) NOT NULL,
+			reasons TEXT,
+			manual BOOLEAN DEFAULT FALSE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (entity_type)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// 创建实体关联表，每个平台上的实体最多属于一个簇
+	_, err = m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS entity_links (
+			entity_type VARCHAR(16) NOT NULL,
+			platform VARCHAR(32) NOT NULL,
+			entity_id VARCHAR(64) NOT NULL,
+			name VARCHAR(255),
+			cluster_id VARCHAR(64) NOT NULL,
+			confidence DECIMAL(3,2) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (entity_type, platform, entity_id),
+			INDEX (cluster_id)
 		)
 	`)
 	if err != nil {
@@ -192,6 +483,223 @@ func (m *Manager) initDatabase() error {
 	return nil
 }
 
+// compositeKeys 各表的主键，不同平台的ID可能相同，因此主键都以平台开头
+var compositeKeys = []struct {
+	table   string
+	columns []string
+}{
+	{"users", []string{"platform", "user_id"}},
+	{"user_edges", []string{"platform", "from_user_id", "to_user_id"}},
+	{"videos", []string{"platform", "video_id"}},
+	{"comments", []string{"platform", "comment_id"}},
+	{"products", []string{"platform", "product_id"}},
+	{"product_skus", []string{"platform", "product_id", "sku_id"}},
+	{"product_reviews", []string{"platform", "review_id"}},
+	{"shops", []string{"platform", "shop_id"}},
+	{"live_rooms", []string{"platform", "room_id"}},
+	{"live_samples", []string{"platform", "room_id", "timestamp"}},
+	{"live_products", []string{"platform", "room_id", "timestamp", "product_id"}},
+	{"video_products", []string{"platform", "video_id", "product_id"}},
+	{"comment_analysis", []string{"platform", "source_type", "source_id"}},
+	{"feedback_summaries", []string{"platform", "target_type", "target_id"}},
+}
+
+// migrateKeys 将旧版本只以原始ID为主键的表迁移为 (platform, id) 复合主键。
+// 旧主键是新主键的子集，已有数据不会产生冲突；已迁移的表不做改动
+func (m *Manager) migrateKeys() error {
+	if !m.enabled || m.db == nil {
+		return nil
+	}
+
+	for _, key := range compositeKeys {
+		rows, err := m.db.Query(`
+			SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+			ORDER BY ORDINAL_POSITION
+		`, key.table)
+		if err != nil {
+			return err
+		}
+
+		var columns []string
+		for rows.Next() {
+			var column string
+			if err := rows.Scan(&column); err != nil {
+				rows.Close()
+				return err
+			}
+			columns = append(columns, column)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if strings.Join(columns, ",") == strings.Join(key.columns, ",") {
+			continue
+		}
+
+		_, err = m.db.Exec(fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY, ADD PRIMARY KEY (%s)",
+			key.table, strings.Join(key.columns, ", ")))
+		if err != nil {
+			return fmt.Errorf("迁移表 %s 的主键失败: %v", key.table, err)
+		}
+		logger.Info("已将表 %s 的主键从 (%s) 迁移为 (%s)", key.table, strings.Join(columns, ", "), strings.Join(key.columns, ", "))
+	}
+
+	return nil
+}
+
+// addedColumns 旧版本创建的表中缺少的列
+var addedColumns = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	// 评论回复
+	{"comments", "parent_id", "VARCHAR(64) NOT NULL DEFAULT ''"},
+	{"comments", "reply_to_user_id", "VARCHAR(64) NOT NULL DEFAULT ''"},
+
+	// 垃圾评论检测
+	{"comments", "spam_score", "DECIMAL(3,2) NOT NULL DEFAULT 0"},
+	{"comments", "spam_reasons", "VARCHAR(255) NOT NULL DEFAULT ''"},
+
+	// 店铺
+	{"products", "shop_id", "VARCHAR(64) NOT NULL DEFAULT ''"},
+
+	// 价格区间和评价汇总
+	{"products", "original_price", "DECIMAL(10,2) NOT NULL DEFAULT 0"},
+	{"products", "min_price", "DECIMAL(10,2) NOT NULL DEFAULT 0"},
+	{"products", "max_price", "DECIMAL(10,2) NOT NULL DEFAULT 0"},
+	{"products", "ship_from", "VARCHAR(128) NOT NULL DEFAULT ''"},
+	{"products", "rating_score", "DECIMAL(3,2) NOT NULL DEFAULT 0"},
+	{"products", "review_count", "INT NOT NULL DEFAULT 0"},
+	{"products", "good_rate", "DECIMAL(5,4) NOT NULL DEFAULT 0"},
+
+	// 农产品分类
+	{"videos", "agri_category", "VARCHAR(32) NOT NULL DEFAULT ''"},
+	{"videos", "agri_crop", "VARCHAR(32) NOT NULL DEFAULT ''"},
+	{"videos", "agri_confidence", "DECIMAL(3,2) NOT NULL DEFAULT 0"},
+	{"products", "agri_category", "VARCHAR(32) NOT NULL DEFAULT ''"},
+	{"products", "agri_crop", "VARCHAR(32) NOT NULL DEFAULT ''"},
+	{"products", "agri_confidence", "DECIMAL(3,2) NOT NULL DEFAULT 0"},
+
+	// 产地
+	{"videos", "origin_province", "VARCHAR(32) NOT NULL DEFAULT ''"},
+	{"videos", "origin_city", "VARCHAR(32) NOT NULL DEFAULT ''"},
+	{"videos", "origin_county", "VARCHAR(32) NOT NULL DEFAULT ''"},
+	{"videos", "gi_product", "VARCHAR(64) NOT NULL DEFAULT ''"},
+	{"products", "origin_province", "VARCHAR(32) NOT NULL DEFAULT ''"},
+	{"products", "origin_city", "VARCHAR(32) NOT NULL DEFAULT ''"},
+	{"products", "origin_county", "VARCHAR(32) NOT NULL DEFAULT ''"},
+	{"products", "gi_product", "VARCHAR(64) NOT NULL DEFAULT ''"},
+
+	// 单价归一化
+	{"products", "weight_kg", "DECIMAL(10,3) NOT NULL DEFAULT 0"},
+	{"products", "price_per_jin", "DECIMAL(10,2) NOT NULL DEFAULT 0"},
+	{"products", "price_per_kg", "DECIMAL(10,2) NOT NULL DEFAULT 0"},
+	{"products", "unit_price_confidence", "DECIMAL(3,2) NOT NULL DEFAULT 0"},
+	{"product_skus", "weight_kg", "DECIMAL(10,3) NOT NULL DEFAULT 0"},
+	{"product_skus", "price_per_jin", "DECIMAL(10,2) NOT NULL DEFAULT 0"},
+	{"product_skus", "price_per_kg", "DECIMAL(10,2) NOT NULL DEFAULT 0"},
+	{"product_skus", "unit_price_confidence", "DECIMAL(3,2) NOT NULL DEFAULT 0"},
+
+	// 商品变化追踪
+	{"products", "off_shelf", "BOOLEAN NOT NULL DEFAULT FALSE"},
+
+	// 比价商品来源
+	{"products", "source", "VARCHAR(16) NOT NULL DEFAULT 'shelf'"},
+	{"products", "match_name", "VARCHAR(255) NOT NULL DEFAULT ''"},
+}
+
+// migrateColumns 为旧版本创建的表补充新增的列，已有数据使用列的默认值
+func (m *Manager) migrateColumns() error {
+	if !m.enabled || m.db == nil {
+		return nil
+	}
+
+	for _, column := range addedColumns {
+		var count int
+		err := m.db.QueryRow(`
+			SELECT COUNT(*) FROM information_schema.COLUMNS
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+		`, column.table, column.column).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		_, err = m.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", column.table, column.column, column.definition))
+		if err != nil {
+			return fmt.Errorf("为表 %s 添加列 %s 失败: %v", column.table, column.column, err)
+		}
+		logger.Info("已为表 %s 添加列 %s", column.table, column.column)
+	}
+
+	return nil
+}
+
+// addedIndexes 旧版本创建的表中缺少的索引，columns 为索引包含的列
+var addedIndexes = []struct {
+	table   string
+	columns string
+}{
+	// 按平台查询的索引，旧版本的索引只包含原始ID
+	{"user_edges", "platform, to_user_id"},
+	{"videos", "platform, user_id"},
+	{"comments", "platform, video_id"},
+	{"comments", "platform, user_id"},
+	{"comments", "platform, parent_id"},
+	{"products", "platform, shop_id"},
+	{"product_reviews", "platform, product_id"},
+	{"live_rooms", "platform, user_id"},
+	{"live_products", "platform, product_id"},
+	{"video_products", "platform, product_id"},
+	{"product_events", "platform, product_id"},
+
+	{"videos", "agri_category"},
+	{"products", "agri_category"},
+	{"videos", "origin_province, origin_city"},
+	{"products", "origin_province, origin_city"},
+	{"comments", "spam_score"},
+	{"products", "match_name, source"},
+}
+
+// migrateIndexes 为旧版本创建的表补充新增的索引，已有包含相同列的索引时跳过
+func (m *Manager) migrateIndexes() error {
+	if !m.enabled || m.db == nil {
+		return nil
+	}
+
+	for _, index := range addedIndexes {
+		var count int
+		err := m.db.QueryRow(`
+			SELECT COUNT(*) FROM (
+				SELECT GROUP_CONCAT(COLUMN_NAME ORDER BY SEQ_IN_INDEX SEPARATOR ', ') AS columns
+				FROM information_schema.STATISTICS
+				WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+				GROUP BY INDEX_NAME
+			) AS indexes WHERE columns = ?
+		`, index.table, index.columns).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		_, err = m.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD INDEX (%s)", index.table, index.columns))
+		if err != nil {
+			return fmt.Errorf("为表 %s 添加索引 (%s) 失败: %v", index.table, index.columns, err)
+		}
+		logger.Info("已为表 %s 添加索引 (%s)", index.table, index.columns)
+	}
+
+	return nil
+}
+
 // prepareStatements 准备SQL语句
 func (m *Manager) prepareStatements() error {
 	if !m.enabled || m.db == nil {
@@ -215,10 +723,23 @@ func (m *Manager) prepareStatements() error {
 	}
 	m.prepared["insertUser"] = insertUser
 
+	// 插入用户关注关系
+	insertUserEdge, err := m.db.Prepare(`
+		INSERT INTO user_edges (from_user_id, to_user_id, platform)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			created_at = created_at
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["insertUserEdge"] = insertUserEdge
+
 	// 插入视频
 	insertVideo, err := m.db.Prepare(`
-		INSERT INTO videos (video_id, user_id, title, description, likes, comments, shares, tags, platform)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO videos (video_id, user_id, title, description, likes, comments, shares, tags,
+			agri_category, agri_crop, agri_confidence, origin_province, origin_city, origin_county, gi_product, platform)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON DUPLICATE KEY UPDATE
 			title = VALUES(title),
 			description = VALUES(description),
@@ -226,6 +747,13 @@ func (m *Manager) prepareStatements() error {
 			comments = VALUES(comments),
 			shares = VALUES(shares),
 			tags = VALUES(tags),
+			agri_category = VALUES(agri_category),
+			agri_crop = VALUES(agri_crop),
+			agri_confidence = VALUES(agri_confidence),
+			origin_province = VALUES(origin_province),
+			origin_city = VALUES(origin_city),
+			origin_county = VALUES(origin_county),
+			gi_product = VALUES(gi_product),
 			updated_at = CURRENT_TIMESTAMP
 	`)
 	if err != nil {
@@ -235,12 +763,15 @@ func (m *Manager) prepareStatements() error {
 
 	// 插入评论
 	insertComment, err := m.db.Prepare(`
-		INSERT INTO comments (comment_id, video_id, user_id, content, likes, replies, timestamp, platform)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO comments (comment_id, video_id, user_id, parent_id, reply_to_user_id, content, likes, replies, timestamp,
+			spam_score, spam_reasons, platform)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON DUPLICATE KEY UPDATE
 			content = VALUES(content),
 			likes = VALUES(likes),
 			replies = VALUES(replies),
+			spam_score = VALUES(spam_score),
+			spam_reasons = VALUES(spam_reasons),
 			updated_at = CURRENT_TIMESTAMP
 	`)
 	if err != nil {
@@ -248,16 +779,53 @@ func (m *Manager) prepareStatements() error {
 	}
 	m.prepared["insertComment"] = insertComment
 
+	// 查询评论回复
+	selectReplies, err := m.db.Prepare(`
+		SELECT comment_id, video_id, user_id, parent_id, reply_to_user_id, content, likes, replies, timestamp
+		FROM comments
+		WHERE parent_id = ? AND platform = ?
+		ORDER BY timestamp
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["selectReplies"] = selectReplies
+
 	// 插入商品
 	insertProduct, err := m.db.Prepare(`
-		INSERT INTO products (product_id, name, price, category, description, sales, platform)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO products (product_id, name, price, original_price, min_price, max_price, category, description, sales,
+			shop_id, ship_from, off_shelf, rating_score, review_count, good_rate, agri_category, agri_crop, agri_confidence,
+			origin_province, origin_city, origin_county, gi_product, weight_kg, price_per_jin, price_per_kg,
+			unit_price_confidence, source, match_name, platform)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON DUPLICATE KEY UPDATE
 			name = VALUES(name),
 			price = VALUES(price),
+			original_price = VALUES(original_price),
+			min_price = VALUES(min_price),
+			max_price = VALUES(max_price),
 			category = VALUES(category),
 			description = VALUES(description),
 			sales = VALUES(sales),
+			shop_id = IF(VALUES(shop_id) = '', shop_id, VALUES(shop_id)),
+			ship_from = VALUES(ship_from),
+			off_shelf = VALUES(off_shelf),
+			rating_score = VALUES(rating_score),
+			review_count = VALUES(review_count),
+			good_rate = VALUES(good_rate),
+			agri_category = VALUES(agri_category),
+			agri_crop = VALUES(agri_crop),
+			agri_confidence = VALUES(agri_confidence),
+			origin_province = VALUES(origin_province),
+			origin_city = VALUES(origin_city),
+			origin_county = VALUES(origin_county),
+			gi_product = VALUES(gi_product),
+			weight_kg = VALUES(weight_kg),
+			price_per_jin = VALUES(price_per_jin),
+			price_per_kg = VALUES(price_per_kg),
+			unit_price_confidence = VALUES(unit_price_confidence),
+			source = VALUES(source),
+			match_name = IF(VALUES(match_name) = '', match_name, VALUES(match_name)),
 			updated_at = CURRENT_TIMESTAMP
 	`)
 	if err != nil {
@@ -265,6 +833,113 @@ func (m *Manager) prepareStatements() error {
 	}
 	m.prepared["insertProduct"] = insertProduct
 
+	// 插入商品规格
+	insertProductSKU, err := m.db.Prepare(`
+		INSERT INTO product_skus (product_id, sku_id, name, weight, grade, origin, price, original_price, stock,
+			weight_kg, price_per_jin, price_per_kg, unit_price_confidence, platform)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			name = VALUES(name),
+			weight = VALUES(weight),
+			grade = VALUES(grade),
+			origin = VALUES(origin),
+			price = VALUES(price),
+			original_price = VALUES(original_price),
+			stock = VALUES(stock),
+			weight_kg = VALUES(weight_kg),
+			price_per_jin = VALUES(price_per_jin),
+			price_per_kg = VALUES(price_per_kg),
+			unit_price_confidence = VALUES(unit_price_confidence),
+			updated_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["insertProductSKU"] = insertProductSKU
+
+	// 插入商品评价
+	insertProductReview, err := m.db.Prepare(`
+		INSERT INTO product_reviews (review_id, product_id, user_id, content, rating, sku_name, timestamp, platform)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			content = VALUES(content),
+			rating = VALUES(rating),
+			updated_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["insertProductReview"] = insertProductReview
+
+	// 插入店铺
+	insertShop, err := m.db.Prepare(`
+		INSERT INTO shops (shop_id, name, rating, followers, category, location, platform)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			name = VALUES(name),
+			rating = VALUES(rating),
+			followers = VALUES(followers),
+			category = VALUES(category),
+			location = VALUES(location),
+			updated_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["insertShop"] = insertShop
+
+	// 查询带货店铺商品的视频
+	selectShopVideos, err := m.db.Prepare(`
+		SELECT DISTINCT vp.video_id
+		FROM products p
+		JOIN video_products vp ON vp.product_id = p.product_id AND vp.platform = p.platform
+		WHERE p.shop_id = ? AND p.platform = ?
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["selectShopVideos"] = selectShopVideos
+
+	// 插入直播间
+	insertLiveRoom, err := m.db.Prepare(`
+		INSERT INTO live_rooms (room_id, user_id, title, start_time, platform)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			title = VALUES(title),
+			updated_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["insertLiveRoom"] = insertLiveRoom
+
+	// 插入直播采样
+	insertLiveSample, err := m.db.Prepare(`
+		INSERT INTO live_samples (room_id, timestamp, viewer_count, total_viewers, likes, platform)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			viewer_count = VALUES(viewer_count),
+			total_viewers = VALUES(total_viewers),
+			likes = VALUES(likes)
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["insertLiveSample"] = insertLiveSample
+
+	// 插入直播采样商品
+	insertLiveProduct, err := m.db.Prepare(`
+		INSERT INTO live_products (room_id, timestamp, product_id, price, sales, platform)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			price = VALUES(price),
+			sales = VALUES(sales)
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["insertLiveProduct"] = insertLiveProduct
+
 	// 插入视频商品关联
 	insertVideoProduct, err := m.db.Prepare(`
 		INSERT INTO video_products (video_id, product_id, platform)
@@ -277,6 +952,64 @@ func (m *Manager) prepareStatements() error {
 	}
 	m.prepared["insertVideoProduct"] = insertVideoProduct
 
+	// 查询上一次保存的商品
+	selectProduct, err := m.db.Prepare(`
+		SELECT product_id, name, price, original_price, min_price, max_price, category, description, sales,
+			shop_id, ship_from, off_shelf
+		FROM products
+		WHERE product_id = ? AND platform = ?
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["selectProduct"] = selectProduct
+
+	// 插入商品变化事件
+	insertProductEvent, err := m.db.Prepare(`
+		INSERT INTO product_events (product_id, event_type, old_value, new_value, change_percent, timestamp, platform)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["insertProductEvent"] = insertProductEvent
+
+	// 插入评论分析结果
+	insertCommentAnalysis, err := m.db.Prepare(`
+		INSERT INTO comment_analysis (source_type, source_id, sentiment, label, complaints, keywords, platform)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			sentiment = VALUES(sentiment),
+			label = VALUES(label),
+			complaints = VALUES(complaints),
+			keywords = VALUES(keywords),
+			updated_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["insertCommentAnalysis"] = insertCommentAnalysis
+
+	// 插入评论汇总
+	insertFeedbackSummary, err := m.db.Prepare(`
+		INSERT INTO feedback_summaries (target_type, target_id, total, positive, neutral, negative, avg_sentiment,
+			complaints, top_keywords, platform)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			total = VALUES(total),
+			positive = VALUES(positive),
+			neutral = VALUES(neutral),
+			negative = VALUES(negative),
+			avg_sentiment = VALUES(avg_sentiment),
+			complaints = VALUES(complaints),
+			top_keywords = VALUES(top_keywords),
+			updated_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+	m.prepared["insertFeedbackSummary"] = insertFeedbackSummary
+
 	return nil
 }
 
@@ -315,6 +1048,26 @@ func (m *Manager) SaveUser(userData *crawler.UserData, platform string) error {
 	return nil
 }
 
+// SaveUserEdge 保存用户关注关系
+func (m *Manager) SaveUserEdge(edge *crawler.UserEdge, platform string) error {
+	if !m.enabled || m.db == nil {
+		return nil
+	}
+
+	// 执行插入
+	_, err := m.prepared["insertUserEdge"].Exec(
+		edge.FromUserID,
+		edge.ToUserID,
+		platform,
+	)
+	if err != nil {
+		return fmt.Errorf("保存用户关注关系到数据库失败: %v", err)
+	}
+
+	logger.Debug("已保存用户关注关系 %s -> %s 到数据库", edge.FromUserID, edge.ToUserID)
+	return nil
+}
+
 // SaveVideo 保存视频数据
 func (m *Manager) SaveVideo(videoData *crawler.VideoData, platform string) error {
 	if !m.enabled || m.db == nil {
@@ -332,6 +1085,18 @@ func (m *Manager) SaveVideo(videoData *crawler.VideoData, platform string) error
 		}
 	}
 
+	// 农产品分类结果
+	var agri crawler.AgriClass
+	if videoData.Agri != nil {
+		agri = *videoData.Agri
+	}
+
+	// 产地信息
+	var origin crawler.OriginInfo
+	if videoData.Origin != nil {
+		origin = *videoData.Origin
+	}
+
 	// 执行插入
 	_, err := m.prepared["insertVideo"].Exec(
 		videoData.VideoID,
@@ -342,6 +1107,13 @@ func (m *Manager) SaveVideo(videoData *crawler.VideoData, platform string) error
 		videoData.Comments,
 		videoData.Shares,
 		tags,
+		agri.Category,
+		agri.Crop,
+		agri.Confidence,
+		origin.Province,
+		origin.City,
+		origin.County,
+		origin.GIProduct,
 		platform,
 	)
 	if err != nil {
@@ -381,10 +1153,14 @@ func (m *Manager) SaveComment(commentData *crawler.CommentData, platform string)
 		commentData.CommentID,
 		commentData.VideoID,
 		commentData.UserID,
+		commentData.ParentID,
+		commentData.ReplyToUserID,
 		commentData.Content,
 		commentData.Likes,
 		commentData.Replies,
 		commentData.Timestamp,
+		commentData.SpamScore,
+		strings.Join(commentData.SpamReasons, ","),
 		platform,
 	)
 	if err != nil {
@@ -395,26 +1171,449 @@ func (m *Manager) SaveComment(commentData *crawler.CommentData, platform string)
 	return nil
 }
 
+// GetCommentReplies 查询一级评论下的全部回复，用于还原评论楼层
+func (m *Manager) GetCommentReplies(commentID string, platform string) ([]*crawler.CommentData, error) {
+	if !m.enabled || m.db == nil {
+		return nil, nil
+	}
+
+	rows, err := m.prepared["selectReplies"].Query(commentID, platform)
+	if err != nil {
+		return nil, fmt.Errorf("查询评论回复失败: %v", err)
+	}
+	defer rows.Close()
+
+	var replies []*crawler.CommentData
+	for rows.Next() {
+		reply := &crawler.CommentData{}
+		if err := rows.Scan(
+			&reply.CommentID,
+			&reply.VideoID,
+			&reply.UserID,
+			&reply.ParentID,
+			&reply.ReplyToUserID,
+			&reply.Content,
+			&reply.Likes,
+			&reply.Replies,
+			&reply.Timestamp,
+		); err != nil {
+			return nil, fmt.Errorf("读取评论回复失败: %v", err)
+		}
+		replies = append(replies, reply)
+	}
+
+	return replies, rows.Err()
+}
+
 // SaveProduct 保存商品数据
 func (m *Manager) SaveProduct(productInfo *crawler.ProductInfo, platform string) error {
 	if !m.enabled || m.db == nil {
 		return nil
 	}
 
+	// 评价汇总
+	var rating crawler.RatingSummary
+	if productInfo.Rating != nil {
+		rating = *productInfo.Rating
+	}
+
+	// 农产品分类结果
+	var agri crawler.AgriClass
+	if productInfo.Agri != nil {
+		agri = *productInfo.Agri
+	}
+
+	// 产地信息
+	var origin crawler.OriginInfo
+	if productInfo.Origin != nil {
+		origin = *productInfo.Origin
+	}
+
+	// 归一化单价
+	var unitPrice crawler.UnitPrice
+	if productInfo.UnitPrice != nil {
+		unitPrice = *productInfo.UnitPrice
+	}
+
+	// 未标记来源的商品来自短视频平台
+	source := productInfo.Source
+	if source == "" {
+		source = crawler.SourceShelf
+	}
+
 	// 执行插入
 	_, err := m.prepared["insertProduct"].Exec(
 		productInfo.ProductID,
 		productInfo.Name,
 		productInfo.Price,
+		productInfo.OriginalPrice,
+		productInfo.MinPrice,
+		productInfo.MaxPrice,
 		productInfo.Category,
 		productInfo.Description,
 		productInfo.Sales,
+		productInfo.ShopID,
+		productInfo.ShipFrom,
+		productInfo.OffShelf,
+		rating.Score,
+		rating.ReviewCount,
+		rating.GoodRate,
+		agri.Category,
+		agri.Crop,
+		agri.Confidence,
+		origin.Province,
+		origin.City,
+		origin.County,
+		origin.GIProduct,
+		unitPrice.WeightKg,
+		unitPrice.PerJin,
+		unitPrice.PerKg,
+		unitPrice.Confidence,
+		source,
+		productInfo.MatchName,
 		platform,
 	)
 	if err != nil {
 		return fmt.Errorf("保存商品数据到数据库失败: %v", err)
 	}
 
+	// 保存商品规格
+	for _, sku := range productInfo.SKUs {
+		var skuUnitPrice crawler.UnitPrice
+		if sku.UnitPrice != nil {
+			skuUnitPrice = *sku.UnitPrice
+		}
+
+		_, err := m.prepared["insertProductSKU"].Exec(
+			productInfo.ProductID,
+			sku.SkuID,
+			sku.Name,
+			sku.Weight,
+			sku.Grade,
+			sku.Origin,
+			sku.Price,
+			sku.OriginalPrice,
+			sku.Stock,
+			skuUnitPrice.WeightKg,
+			skuUnitPrice.PerJin,
+			skuUnitPrice.PerKg,
+			skuUnitPrice.Confidence,
+			platform,
+		)
+		if err != nil {
+			return fmt.Errorf("保存商品规格到数据库失败: %v", err)
+		}
+	}
+
 	logger.Debug("已保存商品 %s 的数据到数据库", productInfo.ProductID)
 	return nil
 }
+
+// LoadProduct 查询上一次保存的商品信息，商品不存在时返回 nil
+func (m *Manager) LoadProduct(productID string, platform string) (*crawler.ProductInfo, error) {
+	if !m.enabled || m.db == nil {
+		return nil, nil
+	}
+
+	var product crawler.ProductInfo
+	var category, description sql.NullString
+	err := m.prepared["selectProduct"].QueryRow(productID, platform).Scan(
+		&product.ProductID,
+		&product.Name,
+		&product.Price,
+		&product.OriginalPrice,
+		&product.MinPrice,
+		&product.MaxPrice,
+		&category,
+		&description,
+		&product.Sales,
+		&product.ShopID,
+		&product.ShipFrom,
+		&product.OffShelf,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询商品数据失败: %v", err)
+	}
+	product.Category = category.String
+	product.Description = description.String
+
+	return &product, nil
+}
+
+// SaveProductReview 保存商品评价
+func (m *Manager) SaveProductReview(review *crawler.ProductReview, platform string) error {
+	if !m.enabled || m.db == nil {
+		return nil
+	}
+
+	// 执行插入
+	_, err := m.prepared["insertProductReview"].Exec(
+		review.ReviewID,
+		review.ProductID,
+		review.UserID,
+		review.Content,
+		review.Rating,
+		review.SkuName,
+		review.Timestamp,
+		platform,
+	)
+	if err != nil {
+		return fmt.Errorf("保存商品评价到数据库失败: %v", err)
+	}
+
+	logger.Debug("已保存商品评价 %s 的数据到数据库", review.ReviewID)
+	return nil
+}
+
+// SaveShop 保存店铺数据
+func (m *Manager) SaveShop(shopData *crawler.ShopData, platform string) error {
+	if !m.enabled || m.db == nil {
+		return nil
+	}
+
+	// 执行插入
+	_, err := m.prepared["insertShop"].Exec(
+		shopData.ShopID,
+		shopData.Name,
+		shopData.Rating,
+		shopData.Followers,
+		shopData.Category,
+		shopData.Location,
+		platform,
+	)
+	if err != nil {
+		return fmt.Errorf("保存店铺数据到数据库失败: %v", err)
+	}
+
+	logger.Debug("已保存店铺 %s 的数据到数据库", shopData.ShopID)
+	return nil
+}
+
+// GetShopVideos 查询带货过店铺商品的视频ID
+func (m *Manager) GetShopVideos(shopID string, platform string) ([]string, error) {
+	if !m.enabled || m.db == nil {
+		return nil, nil
+	}
+
+	rows, err := m.prepared["selectShopVideos"].Query(shopID, platform)
+	if err != nil {
+		return nil, fmt.Errorf("查询店铺关联视频失败: %v", err)
+	}
+	defer rows.Close()
+
+	var videoIDs []string
+	for rows.Next() {
+		var videoID string
+		if err := rows.Scan(&videoID); err != nil {
+			return nil, fmt.Errorf("读取店铺关联视频失败: %v", err)
+		}
+		videoIDs = append(videoIDs, videoID)
+	}
+
+	return videoIDs, rows.Err()
+}
+
+// SaveLiveRoom 保存直播间信息
+func (m *Manager) SaveLiveRoom(room *crawler.LiveRoom, platform string) error {
+	if !m.enabled || m.db == nil {
+		return nil
+	}
+
+	// 执行插入
+	_, err := m.prepared["insertLiveRoom"].Exec(
+		room.RoomID,
+		room.UserID,
+		room.Title,
+		room.StartTime,
+		platform,
+	)
+	if err != nil {
+		return fmt.Errorf("保存直播间数据到数据库失败: %v", err)
+	}
+
+	logger.Debug("已保存直播间 %s 的数据到数据库", room.RoomID)
+	return nil
+}
+
+// SaveLiveSample 保存直播采样数据及采样时的购物车商品
+func (m *Manager) SaveLiveSample(sample *crawler.LiveSample, platform string) error {
+	if !m.enabled || m.db == nil {
+		return nil
+	}
+
+	// 执行插入
+	_, err := m.prepared["insertLiveSample"].Exec(
+		sample.RoomID,
+		sample.Timestamp,
+		sample.ViewerCount,
+		sample.TotalViewers,
+		sample.Likes,
+		platform,
+	)
+	if err != nil {
+		return fmt.Errorf("保存直播采样数据到数据库失败: %v", err)
+	}
+
+	for _, product := range sample.Products {
+		if product.ProductID == "" {
+			continue
+		}
+
+		// 保存商品信息
+		if err := m.SaveProduct(product, platform); err != nil {
+			return err
+		}
+
+		// 保存采样时的商品价格和销量
+		_, err := m.prepared["insertLiveProduct"].Exec(
+			sample.RoomID,
+			sample.Timestamp,
+			product.ProductID,
+			product.Price,
+			product.Sales,
+			platform,
+		)
+		if err != nil {
+			return fmt.Errorf("保存直播商品数据到数据库失败: %v", err)
+		}
+	}
+
+	logger.Debug("已保存直播间 %s 的采样数据到数据库", sample.RoomID)
+	return nil
+}
+
+// SaveProductEvent 保存商品变化事件
+func (m *Manager) SaveProductEvent(event *crawler.ProductEvent) error {
+	if !m.enabled || m.db == nil {
+		return nil
+	}
+
+	// 执行插入
+	_, err := m.prepared["insertProductEvent"].Exec(
+		event.ProductID,
+		string(event.Type),
+		event.OldValue,
+		event.NewValue,
+		event.ChangePercent,
+		event.Timestamp,
+		event.Platform,
+	)
+	if err != nil {
+		return fmt.Errorf("保存商品变化事件到数据库失败: %v", err)
+	}
+
+	logger.Debug("已保存商品 %s 的 %s 事件到数据库", event.ProductID, event.Type)
+	return nil
+}
+
+// SaveCommentAnalysis 保存评论或商品评价的分析结果，sourceType 为 comment 或 review
+func (m *Manager) SaveCommentAnalysis(sourceType, sourceID string, analysis *crawler.CommentAnalysis, platform string) error {
+	if !m.enabled || m.db == nil || analysis == nil {
+		return nil
+	}
+
+	// 执行插入
+	_, err := m.prepared["insertCommentAnalysis"].Exec(
+		sourceType,
+		sourceID,
+		analysis.Sentiment,
+		analysis.Label,
+		strings.Join(analysis.Complaints, ","),
+		strings.Join(analysis.Keywords, ","),
+		platform,
+	)
+	if err != nil {
+		return fmt.Errorf("保存评论分析结果到数据库失败: %v", err)
+	}
+
+	logger.Debug("已保存 %s %s 的分析结果到数据库", sourceType, sourceID)
+	return nil
+}
+
+// SaveFeedbackSummary 保存视频或商品的评论汇总
+func (m *Manager) SaveFeedbackSummary(summary *crawler.FeedbackSummary, platform string) error {
+	if !m.enabled || m.db == nil {
+		return nil
+	}
+
+	// 投诉类别和关键词以 名称:次数 的形式保存
+	var complaints, keywords []string
+	for category, count := range summary.Complaints {
+		complaints = append(complaints, fmt.Sprintf("%s:%d", category, count))
+	}
+	sort.Strings(complaints)
+	for _, keyword := range summary.TopKeywords {
+		keywords = append(keywords, fmt.Sprintf("%s:%d", keyword.Word, keyword.Count))
+	}
+
+	// 执行插入
+	_, err := m.prepared["insertFeedbackSummary"].Exec(
+		summary.TargetType,
+		summary.TargetID,
+		summary.Total,
+		summary.Positive,
+		summary.Neutral,
+		summary.Negative,
+		summary.AvgSentiment,
+		strings.Join(complaints, ","),
+		strings.Join(keywords, ","),
+		platform,
+	)
+	if err != nil {
+		return fmt.Errorf("保存评论汇总到数据库失败: %v", err)
+	}
+
+	logger.Debug("已保存 %s %s 的评论汇总到数据库", summary.TargetType, summary.TargetID)
+	return nil
+}
+
+// ReplaceEntityClusters 用新的识别结果替换指定类型的全部实体簇和实体关联
+func (m *Manager) ReplaceEntityClusters(entityType string, clusters []*crawler.EntityCluster) error {
+	if !m.enabled || m.db == nil {
+		return nil
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("保存实体簇到数据库失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	// 每次识别都基于全部数据，旧结果整体删除
+	if _, err := tx.Exec(`DELETE FROM entity_links WHERE entity_type = ?`, entityType); err != nil {
+		return fmt.Errorf("保存实体簇到数据库失败: %v", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM entity_clusters WHERE entity_type = ?`, entityType); err != nil {
+		return fmt.Errorf("保存实体簇到数据库失败: %v", err)
+	}
+
+	for _, cluster := range clusters {
+		_, err := tx.Exec(`
+			INSERT INTO entity_clusters (cluster_id, entity_type, confidence, reasons, manual)
+			VALUES (?, ?, ?, ?, ?)
+		`, cluster.ClusterID, cluster.EntityType, cluster.Confidence, strings.Join(cluster.Reasons, ","), cluster.Manual)
+		if err != nil {
+			return fmt.Errorf("保存实体簇到数据库失败: %v", err)
+		}
+
+		for _, member := range cluster.Members {
+			_, err := tx.Exec(`
+				INSERT INTO entity_links (entity_type, platform, entity_id, name, cluster_id, confidence)
+				VALUES (?, ?, ?, ?, ?, ?)
+			`, cluster.EntityType, member.Platform, member.ID, member.Name, cluster.ClusterID, cluster.Confidence)
+			if err != nil {
+				return fmt.Errorf("保存实体关联到数据库失败: %v", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("保存实体簇到数据库失败: %v", err)
+	}
+
+	logger.Debug("已保存 %d 个%s实体簇到数据库", len(clusters), entityType)
+	return nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"Crawler/crawler"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// newTestManager 创建使用 sqlmock 的存储管理器，准备SQL语句时不检查语句内容
+func newTestManager(t *testing.T) (*Manager, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 64; i++ {
+		mock.ExpectPrepare("")
+	}
+
+	m := &Manager{enabled: true, dbType: "mysql", db: db, prepared: make(map[string]*sql.Stmt)}
+	if err := m.prepareStatements(); err != nil {
+		t.Fatalf("准备SQL语句失败: %v", err)
+	}
+	return m, mock
+}
+
+// capture 记录插入语句中对应位置参数的值
+type capture struct {
+	value *driver.Value
+}
+
+func (c capture) Match(v driver.Value) bool {
+	*c.value = v
+	return true
+}
+
+// captureArgs 返回 n 个参数的匹配条件，captures 中位置的参数值记录到对应变量，其余参数不检查
+func captureArgs(n int, captures map[int]*driver.Value) []driver.Value {
+	args := make([]driver.Value, n)
+	for i := range args {
+		if value, ok := captures[i]; ok {
+			args[i] = capture{value}
+		} else {
+			args[i] = sqlmock.AnyArg()
+		}
+	}
+	return args
+}
+
+func TestShopVideos(t *testing.T) {
+	m, mock := newTestManager(t)
+
+	// 店铺商品记录所属店铺，视频商品关联记录带货的视频
+	var productID, shopID, linkedVideo, linkedProduct driver.Value
+	mock.ExpectExec("INSERT INTO shops").WithArgs(captureArgs(7, nil)...).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO products").
+		WithArgs(captureArgs(29, map[int]*driver.Value{0: &productID, 9: &shopID})...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO videos").WithArgs(captureArgs(16, nil)...).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO products").WithArgs(captureArgs(29, nil)...).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO video_products").
+		WithArgs(captureArgs(3, map[int]*driver.Value{0: &linkedVideo, 1: &linkedProduct})...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := m.SaveShop(&crawler.ShopData{ShopID: "shop1", Name: "砀山梨园"}, "douyin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveProduct(&crawler.ProductInfo{ProductID: "p1", Name: "砀山酥梨 5斤", ShopID: "shop1"}, "douyin"); err != nil {
+		t.Fatal(err)
+	}
+	video := &crawler.VideoData{VideoID: "v1", UserID: "u1", ProductInfo: &crawler.ProductInfo{ProductID: "p1", Name: "砀山酥梨 5斤"}}
+	if err := m.SaveVideo(video, "douyin"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 按写入的行连接商品和视频商品关联
+	if shopID != "shop1" || linkedProduct != productID {
+		t.Fatalf("商品 %v 的店铺 %v，视频关联商品 %v，无法连接到店铺", productID, shopID, linkedProduct)
+	}
+	mock.ExpectQuery("JOIN video_products").
+		WithArgs("shop1", "douyin").
+		WillReturnRows(sqlmock.NewRows([]string{"video_id"}).AddRow(linkedVideo))
+
+	videoIDs, err := m.GetShopVideos("shop1", "douyin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(videoIDs) != 1 || videoIDs[0] != "v1" {
+		t.Errorf("店铺关联视频 %v，期望 [v1]", videoIDs)
+	}
+}
+
+func TestDisabledManager(t *testing.T) {
+	m, err := NewManager(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.IsEnabled() {
+		t.Error("未启用时 IsEnabled 应返回 false")
+	}
+	if err := m.SaveShop(&crawler.ShopData{ShopID: "shop1"}, "douyin"); err != nil {
+		t.Errorf("未启用时不应写入: %v", err)
+	}
+	if videoIDs, err := m.GetShopVideos("shop1", "douyin"); err != nil || videoIDs != nil {
+		t.Errorf("未启用时查询结果 %v %v，期望为空", videoIDs, err)
+	}
+}
@@ -0,0 +1,155 @@
+package tracker
+
+import (
+	"Crawler/crawler"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotifierConfig 通知器配置
+type NotifierConfig struct {
+	Type    string            `json:"type"`              // webhook、file 或 stdout
+	URL     string            `json:"url,omitempty"`     // webhook 地址
+	File    string            `json:"file,omitempty"`    // 事件文件路径，每行一条 JSON
+	Headers map[string]string `json:"headers,omitempty"` // webhook 附加请求头
+	Timeout int               `json:"timeout,omitempty"` // webhook 超时时间（秒）
+}
+
+// Notifier 商品变化事件通知器
+type Notifier interface {
+	Notify(event *crawler.ProductEvent) error
+}
+
+// NewNotifier 根据配置创建通知器
+func NewNotifier(config NotifierConfig) (Notifier, error) {
+	switch config.Type {
+	case "webhook":
+		if config.URL == "" {
+			return nil, fmt.Errorf("webhook 通知器缺少 url")
+		}
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = 10
+		}
+		return &WebhookNotifier{
+			url:     config.URL,
+			headers: config.Headers,
+			client:  &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		}, nil
+	case "file":
+		if config.File == "" {
+			return nil, fmt.Errorf("file 通知器缺少 file")
+		}
+		return &FileNotifier{path: config.File}, nil
+	case "stdout":
+		return &StdoutNotifier{}, nil
+	default:
+		return nil, fmt.Errorf("不支持的通知器类型: %s", config.Type)
+	}
+}
+
+// ParseNotifiers 解析命令行通知器列表，格式如 stdout,file:events.jsonl,webhook:http://127.0.0.1:8080/hook
+func ParseNotifiers(spec string) []NotifierConfig {
+	var configs []NotifierConfig
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, ":", 2)
+		config := NotifierConfig{Type: parts[0]}
+		if len(parts) == 2 {
+			switch config.Type {
+			case "webhook":
+				config.URL = parts[1]
+			case "file":
+				config.File = parts[1]
+			}
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// WebhookNotifier 以 JSON POST 发送事件
+type WebhookNotifier struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// Notify 发送事件到 webhook
+func (n *WebhookNotifier) Notify(event *crawler.ProductEvent) error {
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", n.url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range n.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook 返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// FileNotifier 将事件追加写入本地文件，每行一条 JSON
+type FileNotifier struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// Notify 追加事件到文件
+func (n *FileNotifier) Notify(event *crawler.ProductEvent) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(jsonData, '\n'))
+	return err
+}
+
+// StdoutNotifier 将事件打印到标准输出
+type StdoutNotifier struct{}
+
+// Notify 打印事件
+func (n *StdoutNotifier) Notify(event *crawler.ProductEvent) error {
+	switch event.Type {
+	case crawler.EventOffShelf, crawler.EventBackOnShelf:
+		fmt.Printf("[%s] 商品 %s（%s）\n", event.Type, event.ProductID, event.Name)
+	default:
+		fmt.Printf("[%s] 商品 %s（%s）: %.2f -> %.2f (%+.2f%%)\n",
+			event.Type, event.ProductID, event.Name, event.OldValue, event.NewValue, event.ChangePercent)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package tracker
+
+import (
+	"Crawler/crawler"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var received crawler.ProductEvent
+	var token, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("X-Token")
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("解析事件失败: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(NotifierConfig{
+		Type:    "webhook",
+		URL:     server.URL,
+		Headers: map[string]string{"X-Token": "secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := &crawler.ProductEvent{
+		Type:          crawler.EventPriceDrop,
+		ProductID:     "p1",
+		Name:          "烟台苹果",
+		Platform:      "douyin",
+		OldValue:      50,
+		NewValue:      40,
+		ChangePercent: -20,
+	}
+	if err := notifier.Notify(event); err != nil {
+		t.Fatalf("发送事件失败: %v", err)
+	}
+
+	if received != *event {
+		t.Errorf("收到的事件 %+v，期望 %+v", received, *event)
+	}
+	if token != "secret" {
+		t.Errorf("附加请求头 X-Token = %q，期望 secret", token)
+	}
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q，期望 application/json", contentType)
+	}
+}
+
+func TestWebhookNotifierStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(NotifierConfig{Type: "webhook", URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(&crawler.ProductEvent{Type: crawler.EventOffShelf, ProductID: "p1"}); err == nil {
+		t.Error("webhook 返回 500 时应返回错误")
+	}
+}
+
+func TestParseNotifiers(t *testing.T) {
+	configs := ParseNotifiers("stdout, file:events.jsonl,webhook:http://127.0.0.1:8080/hook,")
+	want := []NotifierConfig{
+		{Type: "stdout"},
+		{Type: "file", File: "events.jsonl"},
+		{Type: "webhook", URL: "http://127.0.0.1:8080/hook"},
+	}
+	if len(configs) != len(want) {
+		t.Fatalf("解析出 %d 个通知器，期望 %d 个", len(configs), len(want))
+	}
+	for i := range want {
+		if configs[i].Type != want[i].Type || configs[i].File != want[i].File || configs[i].URL != want[i].URL {
+			t.Errorf("第 %d 个通知器 %+v，期望 %+v", i, configs[i], want[i])
+		}
+	}
+}
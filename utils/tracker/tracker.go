@@ -0,0 +1,178 @@
+package tracker
+
+import (
+	"Crawler/crawler"
+	"Crawler/utils/logger"
+	"math"
+	"time"
+)
+
+// Config 商品变化追踪配置
+type Config struct {
+	Enabled          bool             `json:"enabled"`
+	PriceDropPercent float64          `json:"price_drop_percent"` // 降价超过该百分比时触发事件
+	PriceRisePercent float64          `json:"price_rise_percent"` // 涨价超过该百分比时触发事件，0表示不追踪涨价
+	SalesJumpPercent float64          `json:"sales_jump_percent"` // 销量增长超过该百分比时触发事件
+	SalesJumpMin     int              `json:"sales_jump_min"`     // 销量增长的最小绝对值，避免小基数误报
+	Notifiers        []NotifierConfig `json:"notifiers"`
+}
+
+// Store 提供上一次保存的商品信息，商品不存在时返回 nil，通常为 storage.Manager
+type Store interface {
+	LoadProduct(productID string, platform string) (*crawler.ProductInfo, error)
+}
+
+// Tracker 商品变化追踪器，对比本次采集结果与上一次保存的商品信息
+type Tracker struct {
+	enabled   bool
+	config    Config
+	store     Store
+	notifiers []Notifier
+}
+
+// NewTracker 创建商品变化追踪器
+func NewTracker(config Config, store Store) (*Tracker, error) {
+	if !config.Enabled {
+		return &Tracker{enabled: false}, nil
+	}
+
+	tracker := &Tracker{
+		enabled: true,
+		config:  config,
+		store:   store,
+	}
+
+	// 创建通知器
+	for _, notifierConfig := range config.Notifiers {
+		notifier, err := NewNotifier(notifierConfig)
+		if err != nil {
+			return nil, err
+		}
+		tracker.notifiers = append(tracker.notifiers, notifier)
+	}
+
+	logger.Info("商品变化追踪已启用，共 %d 个通知器", len(tracker.notifiers))
+	return tracker, nil
+}
+
+// IsEnabled 检查商品变化追踪是否启用
+func (t *Tracker) IsEnabled() bool {
+	return t.enabled
+}
+
+// Track 对比商品与上一次保存的版本，发送并返回检测到的变化事件，需在保存本次商品前调用
+func (t *Tracker) Track(product *crawler.ProductInfo, platform string) []*crawler.ProductEvent {
+	if !t.enabled {
+		return nil
+	}
+
+	previous, err := t.store.LoadProduct(product.ProductID, platform)
+	if err != nil {
+		logger.Warn("读取商品 %s 的历史数据失败: %v", product.ProductID, err)
+		return nil
+	}
+	if previous == nil {
+		return nil
+	}
+
+	events := t.Diff(previous, product, platform)
+	t.notify(events)
+	return events
+}
+
+// TrackMissing 处理已无法获取的商品（接口返回商品不存在），上一次保存的商品未下架时发送下架事件。
+// 返回标记为下架的上一次商品信息供调用方保存，没有历史数据或已是下架状态时返回 nil
+func (t *Tracker) TrackMissing(productID string, platform string) (*crawler.ProductInfo, []*crawler.ProductEvent) {
+	if !t.enabled {
+		return nil, nil
+	}
+
+	previous, err := t.store.LoadProduct(productID, platform)
+	if err != nil {
+		logger.Warn("读取商品 %s 的历史数据失败: %v", productID, err)
+		return nil, nil
+	}
+	if previous == nil || previous.OffShelf {
+		return nil, nil
+	}
+
+	current := *previous
+	current.OffShelf = true
+	events := t.Diff(previous, &current, platform)
+	t.notify(events)
+	return &current, events
+}
+
+// notify 通过全部通知器发送事件
+func (t *Tracker) notify(events []*crawler.ProductEvent) {
+	for _, event := range events {
+		for _, notifier := range t.notifiers {
+			if err := notifier.Notify(event); err != nil {
+				logger.Warn("发送商品 %s 的 %s 事件失败: %v", event.ProductID, event.Type, err)
+			}
+		}
+	}
+}
+
+// Diff 对比两次采集的商品信息，返回超过阈值的变化事件
+func (t *Tracker) Diff(previous, current *crawler.ProductInfo, platform string) []*crawler.ProductEvent {
+	var events []*crawler.ProductEvent
+	now := time.Now().Unix()
+
+	// 下架商品的接口数据可能不含名称
+	name := current.Name
+	if name == "" {
+		name = previous.Name
+	}
+
+	newEvent := func(eventType crawler.ProductEventType, oldValue, newValue float64) *crawler.ProductEvent {
+		return &crawler.ProductEvent{
+			Type:          eventType,
+			ProductID:     current.ProductID,
+			Name:          name,
+			Platform:      platform,
+			OldValue:      oldValue,
+			NewValue:      newValue,
+			ChangePercent: changePercent(oldValue, newValue),
+			Timestamp:     now,
+		}
+	}
+
+	// 上下架状态变化
+	if !previous.OffShelf && current.OffShelf {
+		events = append(events, newEvent(crawler.EventOffShelf, 0, 0))
+		return events
+	}
+	if previous.OffShelf && !current.OffShelf {
+		events = append(events, newEvent(crawler.EventBackOnShelf, 0, 0))
+	}
+
+	// 价格变化，价格缺失时不比较
+	if previous.Price > 0 && current.Price > 0 {
+		change := changePercent(previous.Price, current.Price)
+		if t.config.PriceDropPercent > 0 && -change >= t.config.PriceDropPercent {
+			events = append(events, newEvent(crawler.EventPriceDrop, previous.Price, current.Price))
+		}
+		if t.config.PriceRisePercent > 0 && change >= t.config.PriceRisePercent {
+			events = append(events, newEvent(crawler.EventPriceRise, previous.Price, current.Price))
+		}
+	}
+
+	// 销量激增，原销量为 0 时只按绝对值判断
+	increase := current.Sales - previous.Sales
+	if t.config.SalesJumpPercent > 0 && increase > 0 && increase >= t.config.SalesJumpMin {
+		if previous.Sales == 0 || changePercent(float64(previous.Sales), float64(current.Sales)) >= t.config.SalesJumpPercent {
+			events = append(events, newEvent(crawler.EventSalesJump, float64(previous.Sales), float64(current.Sales)))
+		}
+	}
+
+	return events
+}
+
+// changePercent 计算变化百分比，保留两位小数
+func changePercent(oldValue, newValue float64) float64 {
+	if oldValue == 0 {
+		return 0
+	}
+	return math.Round((newValue-oldValue)/oldValue*10000) / 100
+}
@@ -0,0 +1,104 @@
+package tracker
+
+import (
+	"Crawler/crawler"
+	"testing"
+)
+
+// memoryStore 按平台和商品ID保存商品的测试存储
+type memoryStore map[string]*crawler.ProductInfo
+
+func (s memoryStore) LoadProduct(productID string, platform string) (*crawler.ProductInfo, error) {
+	return s[platform+"/"+productID], nil
+}
+
+// recorder 记录收到的事件
+type recorder struct {
+	events []*crawler.ProductEvent
+}
+
+func (r *recorder) Notify(event *crawler.ProductEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func newTestTracker(store Store) (*Tracker, *recorder) {
+	r := &recorder{}
+	return &Tracker{
+		enabled: true,
+		config: Config{
+			PriceDropPercent: 10,
+			SalesJumpPercent: 50,
+			SalesJumpMin:     100,
+		},
+		store:     store,
+		notifiers: []Notifier{r},
+	}, r
+}
+
+func TestTrack(t *testing.T) {
+	store := memoryStore{
+		"douyin/p1": {ProductID: "p1", Name: "烟台苹果", Price: 50, Sales: 100},
+	}
+	tracker, r := newTestTracker(store)
+
+	tests := []struct {
+		name     string
+		platform string
+		current  *crawler.ProductInfo
+		want     []crawler.ProductEventType
+	}{
+		{"降价和销量激增", "douyin", &crawler.ProductInfo{ProductID: "p1", Price: 40, Sales: 300}, []crawler.ProductEventType{crawler.EventPriceDrop, crawler.EventSalesJump}},
+		{"变化未超过阈值", "douyin", &crawler.ProductInfo{ProductID: "p1", Price: 48, Sales: 150}, nil},
+		{"下架", "douyin", &crawler.ProductInfo{ProductID: "p1", OffShelf: true}, []crawler.ProductEventType{crawler.EventOffShelf}},
+		{"其他平台的同ID商品没有历史", "kuaishou", &crawler.ProductInfo{ProductID: "p1", Price: 10}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := tracker.Track(tt.current, tt.platform)
+			if len(events) != len(tt.want) {
+				t.Fatalf("检测到 %d 个事件，期望 %d 个: %+v", len(events), len(tt.want), events)
+			}
+			for i, event := range events {
+				if event.Type != tt.want[i] {
+					t.Errorf("第 %d 个事件类型 %s，期望 %s", i, event.Type, tt.want[i])
+				}
+				if event.Name != "烟台苹果" {
+					t.Errorf("事件商品名称 %q，期望使用历史数据中的名称", event.Name)
+				}
+			}
+		})
+	}
+	if len(r.events) != 3 {
+		t.Errorf("通知器收到 %d 个事件，期望 3 个", len(r.events))
+	}
+}
+
+func TestTrackMissing(t *testing.T) {
+	store := memoryStore{
+		"douyin/p1": {ProductID: "p1", Name: "烟台苹果", Price: 50},
+		"douyin/p2": {ProductID: "p2", Name: "赣南脐橙", OffShelf: true},
+	}
+	tracker, r := newTestTracker(store)
+
+	product, events := tracker.TrackMissing("p1", "douyin")
+	if product == nil || !product.OffShelf || product.Name != "烟台苹果" {
+		t.Fatalf("返回的商品 %+v，期望标记为下架的历史商品", product)
+	}
+	if len(events) != 1 || events[0].Type != crawler.EventOffShelf {
+		t.Fatalf("事件 %+v，期望一个下架事件", events)
+	}
+	if store["douyin/p1"].OffShelf {
+		t.Error("不应修改存储中的历史商品")
+	}
+
+	// 已下架和没有历史的商品不再发送事件
+	for _, id := range []string{"p2", "p3"} {
+		if product, events := tracker.TrackMissing(id, "douyin"); product != nil || events != nil {
+			t.Errorf("商品 %s 返回 %+v %+v，期望为空", id, product, events)
+		}
+	}
+	if len(r.events) != 1 {
+		t.Errorf("通知器收到 %d 个事件，期望 1 个", len(r.events))
+	}
+}